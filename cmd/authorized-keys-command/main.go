@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"ssh-key-manager/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// authorized-keys-command는 sshd AuthorizedKeysCommand로 실행되어 관리 서버에서 계정의 authorized_keys를 가져와 출력합니다.
+// 응답은 사용자 CA 서명(호스트, 포트, 계정 포함)을 확인한 뒤 캐시에 저장하며, 관리 서버에 접속할 수 없으면 max-stale 이내의 캐시를 사용합니다.
+//
+// sshd_config 예:
+//
+//	AuthorizedKeysCommand /usr/local/bin/authorized-keys-command -url https://keys.example.com -host web01.example.com %u
+//	AuthorizedKeysCommandUser nobody
+//
+// 호스트 토큰(token-file)과 캐시 디렉토리는 AuthorizedKeysCommandUser만 읽고 쓸 수 있어야 합니다.
+// sshd는 표준 출력만 사용하므로 오류는 표준 에러로만 기록하고, 가져올 수 없으면 아무것도 출력하지 않습니다(키 인증 거부).
+
+const (
+	maxResponseSize = 1 << 20         // 응답 최대 크기 (1MB)
+	maxClockSkew    = 5 * time.Minute // 발급 시각 허용 오차
+)
+
+// cachedKeys는 캐시 파일 형식입니다. 서명을 함께 저장하여 읽을 때 다시 검증합니다.
+type cachedKeys struct {
+	Host      string `json:"host"`
+	Port      int    `json:"port"`
+	User      string `json:"user"`
+	IssuedAt  int64  `json:"issued_at"`
+	Signature string `json:"signature"`
+	Content   string `json:"content"`
+}
+
+// errRejected는 관리 서버가 요청을 거부했음을 나타냅니다 (캐시로 대체하지 않음).
+var errRejected = errors.New("관리 서버가 요청을 거부했습니다")
+
+func main() {
+	hostname, _ := os.Hostname()
+
+	baseURL := flag.String("url", "", "관리 서버 주소 (예: https://keys.example.com)")
+	host := flag.String("host", hostname, "관리 서버에 등록된 이 서버의 호스트")
+	port := flag.Int("port", 0, "SSH 포트 (0이면 호스트의 모든 포트에 등록된 계정 키)")
+	tokenFile := flag.String("token-file", "/etc/ssh/skm_pull_token", "호스트 토큰 파일")
+	caKeyFile := flag.String("ca-key", "/etc/ssh/user_ca.pub", "응답 서명을 확인할 사용자 CA 공개키 파일")
+	cacheDir := flag.String("cache-dir", "/var/cache/ssh-key-manager", "캐시 디렉토리")
+	cacheTTL := flag.Duration("cache-ttl", time.Minute, "이 시간 안에 받은 캐시는 관리 서버에 묻지 않고 사용")
+	maxStale := flag.Duration("max-stale", 24*time.Hour, "관리 서버에 접속할 수 없을 때 사용할 캐시의 최대 나이")
+	timeout := flag.Duration("timeout", 5*time.Second, "관리 서버 요청 타임아웃")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("authorized-keys-command: ")
+
+	if flag.NArg() != 1 || *baseURL == "" || *host == "" {
+		fmt.Fprintln(os.Stderr, "사용법: authorized-keys-command -url <관리 서버 주소> [-host <호스트>] <계정>")
+		os.Exit(2)
+	}
+	user := flag.Arg(0)
+
+	caKeys, err := readCAKeys(*caKeyFile)
+	if err != nil {
+		log.Fatalf("❌ 사용자 CA 공개키를 읽을 수 없습니다: %v", err)
+	}
+
+	cachePath := filepath.Join(*cacheDir, url.PathEscape(*host), strconv.Itoa(*port), url.PathEscape(user)+".json")
+	cached, cacheErr := readCache(cachePath, caKeys, *host, *port, user)
+
+	// 최근에 받은 캐시는 그대로 사용 (로그인 시도마다 관리 서버에 묻지 않음)
+	if cacheErr == nil && time.Since(time.Unix(cached.IssuedAt, 0)) < *cacheTTL {
+		fmt.Print(cached.Content)
+		return
+	}
+
+	fetched, err := fetchKeys(*baseURL, *host, user, *port, *tokenFile, caKeys, *timeout)
+	if err == nil {
+		if err := writeCache(cachePath, fetched); err != nil {
+			log.Printf("⚠️ 캐시 저장 실패: %v", err)
+		}
+		fmt.Print(fetched.Content)
+		return
+	}
+
+	if errors.Is(err, errRejected) {
+		// 토큰 폐기 등으로 거부되면 이전 캐시도 사용하지 않음
+		log.Printf("❌ %v", err)
+		os.Remove(cachePath)
+		os.Exit(1)
+	}
+
+	log.Printf("⚠️ 관리 서버에서 가져오지 못했습니다: %v", err)
+	if cacheErr != nil {
+		log.Printf("❌ 사용할 수 있는 캐시가 없습니다: %v", cacheErr)
+		os.Exit(1)
+	}
+	age := time.Since(time.Unix(cached.IssuedAt, 0))
+	if age > *maxStale {
+		log.Printf("❌ 캐시가 너무 오래되었습니다 (%s 전, 최대 %s)", age.Round(time.Second), *maxStale)
+		os.Exit(1)
+	}
+
+	log.Printf("📦 캐시 사용 (%s 전에 받은 내용)", age.Round(time.Second))
+	fmt.Print(cached.Content)
+}
+
+// fetchKeys는 관리 서버에서 authorized_keys를 가져오고 서명을 확인합니다.
+func fetchKeys(baseURL, host, user string, port int, tokenFile string, caKeys []ssh.PublicKey, timeout time.Duration) (*cachedKeys, error) {
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("호스트 토큰을 읽을 수 없습니다: %v", err)
+	}
+
+	query := url.Values{"user": {user}}
+	if port > 0 {
+		query.Set("port", strconv.Itoa(port))
+	}
+	endpoint := strings.TrimRight(baseURL, "/") + "/api/hosts/" + url.PathEscape(host) + "/authorized-keys?" + query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxResponseSize {
+		return nil, errors.New("응답이 너무 큽니다")
+	}
+	switch {
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return nil, fmt.Errorf("%w (HTTP %d): %s", errRejected, resp.StatusCode, bytes.TrimSpace(body))
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	issuedAt, err := strconv.ParseInt(resp.Header.Get(utils.PulledKeysIssuedAtHeader), 10, 64)
+	if err != nil {
+		return nil, errors.New("응답에 발급 시각이 없습니다")
+	}
+	if skew := time.Since(time.Unix(issuedAt, 0)); skew > maxClockSkew || skew < -maxClockSkew {
+		return nil, fmt.Errorf("응답 발급 시각이 현재 시각과 %s 차이납니다 (시계 확인 필요)", skew.Round(time.Second))
+	}
+
+	fetched := &cachedKeys{
+		Host:      host,
+		Port:      port,
+		User:      user,
+		IssuedAt:  issuedAt,
+		Signature: resp.Header.Get(utils.PulledKeysSignatureHeader),
+		Content:   string(body),
+	}
+	if err := verifyKeys(fetched, caKeys); err != nil {
+		return nil, fmt.Errorf("응답 서명 확인 실패: %v", err)
+	}
+	return fetched, nil
+}
+
+// verifyKeys는 CA 공개키 중 하나로 서명이 확인되는지 검사합니다.
+func verifyKeys(keys *cachedKeys, caKeys []ssh.PublicKey) error {
+	var lastErr error
+	for _, caKey := range caKeys {
+		lastErr = utils.VerifyPulledAuthorizedKeys(caKey, keys.Host, keys.Port, keys.User, keys.IssuedAt, keys.Content, keys.Signature)
+		if lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// readCAKeys는 CA 공개키 파일(TrustedUserCAKeys 형식, 한 줄에 하나)을 읽습니다.
+func readCAKeys(path string) ([]ssh.PublicKey, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var keys []ssh.PublicKey
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("공개키가 없습니다")
+	}
+	return keys, nil
+}
+
+// readCache는 캐시 파일을 읽고 서명과 대상(호스트, 포트, 계정)을 확인합니다.
+func readCache(path string, caKeys []ssh.PublicKey, host string, port int, user string) (*cachedKeys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cached cachedKeys
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, fmt.Errorf("캐시 형식이 올바르지 않습니다: %v", err)
+	}
+	if cached.Host != host || cached.Port != port || cached.User != user {
+		return nil, errors.New("다른 호스트/포트/계정의 캐시입니다")
+	}
+	if err := verifyKeys(&cached, caKeys); err != nil {
+		return nil, fmt.Errorf("캐시 서명 확인 실패: %v", err)
+	}
+	return &cached, nil
+}
+
+// writeCache는 캐시 파일을 원자적으로 교체합니다.
+func writeCache(path string, keys *cachedKeys) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
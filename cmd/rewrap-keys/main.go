@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"ssh-key-manager/config"
+	"ssh-key-manager/database"
+	"ssh-key-manager/models"
+	"ssh-key-manager/services"
+	"ssh-key-manager/utils"
+	"time"
+)
+
+// rewrap-keys는 마스터 키 교체 후 저장된 모든 SSH 키의 데이터 키를 새 마스터 키로 재래핑합니다.
+//
+// 교체 절차:
+//  1. rewrap-keys -generate 로 새 마스터 키 항목을 생성
+//  2. 새 키를 MASTER_KEY(또는 MASTER_KEY_FILE 첫 줄)에, 기존 키를 MASTER_KEY_PREVIOUS(또는 파일 다음 줄)에 등록
+//  3. rewrap-keys 실행 (먼저 -dry-run으로 확인 권장)
+//  4. 실패 건이 없으면 이전 마스터 키를 설정에서 제거
+//
+// 암호화 도입 전의 평문 개인키는 서버 시작 시 변환하지 않습니다.
+// 업그레이드 후 평문 행이 정상 데이터인지 확인한 뒤 rewrap-keys -encrypt-plaintext 를 한 번 실행합니다.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "변경 없이 재래핑 대상만 확인")
+	encryptPlaintext := flag.Bool("encrypt-plaintext", false, "평문으로 남은 개인키를 암호화 (암호화 도입 후 한 번만 실행)")
+	generate := flag.Bool("generate", false, "새 마스터 키 항목을 생성하여 출력하고 종료")
+	flag.Parse()
+
+	if *generate {
+		entry, err := utils.GenerateMasterKeyEntry("mk-" + time.Now().Format("20060102"))
+		if err != nil {
+			log.Fatalf("❌ 마스터 키 생성 실패: %v", err)
+		}
+		fmt.Println(entry)
+		return
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("❌ 설정 로드 실패: %v", err)
+	}
+
+	manager, err := database.NewMigrationManager(cfg)
+	if err != nil {
+		log.Fatalf("❌ 데이터베이스 연결 실패: %v", err)
+	}
+	defer manager.Close()
+	models.SetDB(manager.DB)
+
+	result, err := services.RewrapAllKeys(*dryRun, *encryptPlaintext)
+	if err != nil {
+		log.Fatalf("❌ 재래핑 실패: %v", err)
+	}
+
+	fmt.Printf("활성 마스터 키: %s\n", result.ActiveKeyID)
+	fmt.Printf("전체: %d, 재래핑: %d, 신규 암호화: %d, 평문: %d, 유지: %d, 실패: %d\n",
+		result.Total, result.Rewrapped, result.Encrypted, result.Plaintext, result.AlreadyDone, result.Failed)
+	if result.Plaintext > 0 {
+		fmt.Println("평문 개인키가 남아 있습니다. 확인 후 -encrypt-plaintext 옵션으로 암호화하세요")
+	}
+	if *dryRun {
+		fmt.Println("dry-run 모드: 데이터베이스는 변경되지 않았습니다")
+	}
+
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
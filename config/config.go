@@ -25,15 +25,50 @@ type Config struct {
 	// JWT settings
 	JWTSecret string
 
+	// Private key encryption settings (envelope encryption)
+	MasterKey         string // 활성 마스터 키 ("<키ID>:<base64>")
+	MasterKeyFile     string // 마스터 키 파일 경로 (설정 시 MasterKey보다 우선)
+	MasterKeyPrevious string // 복호화에만 사용하는 이전 마스터 키들 (쉼표 구분)
+
 	// Server settings
-	ServerPort string
-	KeyBits    int
+	ServerPort   string
+	KeyBits      int
+	KeyAlgorithm string // 기본 키 알고리즘 (rsa, ed25519, ecdsa-p256 등)
+	PPKVersion   int    // 생성 시 저장할 PPK 파일 버전 (2 또는 3)
 
 	// SSH Key Auto Installation settings
 	AutoInstallKeys bool
 	SSHUser         string
 	SSHHomePath     string
 
+	// Key expiry / rotation settings
+	KeyMaxAgeDays          int           // 조직 기본 최대 키 사용 기간 (일, 0이면 만료 없음, 부서 설정이 우선)
+	KeyExpiryWarningDays   int           // 만료 며칠 전부터 소유자에게 알릴지
+	KeyExpiryCheckInterval time.Duration // 만료 검사 스케줄러 실행 간격 (0이면 비활성화)
+	KeyAutoRotate          bool          // 만료된 키를 자동으로 교체할지 여부 (false면 폐기만 함)
+
+	// SSH certificate settings
+	CertDefaultTTL time.Duration // 사용자 인증서 기본 유효 기간
+	CertMaxTTL     time.Duration // 사용자 인증서 최대 유효 기간
+	HostCertTTL    time.Duration // 호스트 인증서 기본 유효 기간
+	HostCertMaxTTL time.Duration // 호스트 인증서 최대 유효 기간
+	KnownHostsFile string        // 원격 서버 접속 시 호스트 키 검증에 사용할 known_hosts 파일
+
+	// Remote SSH transport settings
+	SSHIdentityFiles  []string      // 원격 서버 접속에 사용할 개인키 파일 (비어있으면 ~/.ssh/id_*)
+	SSHConnectTimeout time.Duration // 원격 서버 연결(핸드셰이크 포함) 타임아웃
+	SSHCommandTimeout time.Duration // 원격 명령 실행 타임아웃
+	SSHIdleTimeout    time.Duration // 재사용을 위해 유휴 연결을 유지하는 시간
+
+	// Drift detection settings
+	DriftScanInterval time.Duration // 서버 authorized_keys 드리프트 검사 주기 (0이면 비활성화)
+
+	// Deployment job settings
+	DeployJobWorkers   int           // 동시에 실행할 비동기 배포 작업 수
+	DeployMaxParallel  int           // 배포 하나에서 동시에 작업할 서버 수
+	DeployMaxRetries   int           // 네트워크 오류 시 서버별 재시도 횟수
+	DeployRetryBackoff time.Duration // 첫 재시도 대기 시간 (재시도마다 두 배)
+
 	// Admin settings
 	AdminUsername string
 	AdminPassword string
@@ -41,19 +76,42 @@ type Config struct {
 
 // envDefaults는 .env 파일 생성 시 사용할 기본값들을 정의합니다.
 var envDefaults = map[string]string{
-	"DB_HOST":           "postgres",
-	"DB_PORT":           "5432",
-	"DB_USER":           "postgres",
-	"DB_PASSWORD":       "password",
-	"DB_NAME":           "key-manager",
-	"JWT_SECRET":        "", // 런타임에 생성됨
-	"SERVER_PORT":       "8080",
-	"KEY_BITS":          "4096",
-	"AUTO_INSTALL_KEYS": "false",
-	"SSH_USER":          "robos",
-	"SSH_HOME_PATH":     "/home/$SSH_USER",
-	"ADMIN_USERNAME":    "admin",
-	"ADMIN_PASSWORD":    "", // 런타임에 생성됨
+	"DB_HOST":                   "postgres",
+	"DB_PORT":                   "5432",
+	"DB_USER":                   "postgres",
+	"DB_PASSWORD":               "password",
+	"DB_NAME":                   "key-manager",
+	"JWT_SECRET":                "", // 런타임에 생성됨
+	"MASTER_KEY":                "", // 런타임에 생성됨
+	"MASTER_KEY_FILE":           "",
+	"MASTER_KEY_PREVIOUS":       "",
+	"SERVER_PORT":               "8080",
+	"KEY_BITS":                  "4096",
+	"KEY_ALGORITHM":             "rsa",
+	"PPK_VERSION":               "3",
+	"AUTO_INSTALL_KEYS":         "false",
+	"SSH_USER":                  "robos",
+	"SSH_HOME_PATH":             "/home/$SSH_USER",
+	"KEY_MAX_AGE_DAYS":          "0",
+	"KEY_EXPIRY_WARN_DAYS":      "14",
+	"KEY_EXPIRY_CHECK_INTERVAL": "1h",
+	"KEY_AUTO_ROTATE":           "true",
+	"CERT_DEFAULT_TTL":          "8h",
+	"CERT_MAX_TTL":              "24h",
+	"HOST_CERT_TTL":             "8760h",
+	"HOST_CERT_MAX_TTL":         "8760h",
+	"SSH_KNOWN_HOSTS_FILE":      "./data/known_hosts",
+	"SSH_IDENTITY_FILES":        "",
+	"SSH_CONNECT_TIMEOUT":       "10s",
+	"SSH_COMMAND_TIMEOUT":       "60s",
+	"SSH_IDLE_TIMEOUT":          "5m",
+	"DRIFT_SCAN_INTERVAL":       "6h",
+	"DEPLOY_JOB_WORKERS":        "4",
+	"DEPLOY_MAX_PARALLEL":       "10",
+	"DEPLOY_MAX_RETRIES":        "2",
+	"DEPLOY_RETRY_BACKOFF":      "2s",
+	"ADMIN_USERNAME":            "admin",
+	"ADMIN_PASSWORD":            "", // 런타임에 생성됨
 }
 
 // LoadConfig는 .env 파일에서 모든 설정을 로드합니다.
@@ -75,18 +133,27 @@ func LoadConfig() (*Config, error) {
 		log.Printf("경고: .env 파일을 로드할 수 없습니다. 환경 변수를 직접 사용합니다. 오류: %v", err)
 	}
 
+	// 개인키 암호화 도입 전에 만든 .env에는 마스터 키가 없으므로 생성하여 추가
+	if getEnv("MASTER_KEY", "") == "" && getEnv("MASTER_KEY_FILE", "") == "" {
+		appendMasterKey(envFilePath)
+	}
+
 	cfg := &Config{
-		DBHost:        getEnv("DB_HOST", envDefaults["DB_HOST"]),
-		DBPort:        getEnv("DB_PORT", envDefaults["DB_PORT"]),
-		DBUser:        getEnv("DB_USER", envDefaults["DB_USER"]),
-		DBPassword:    getEnv("DB_PASSWORD", envDefaults["DB_PASSWORD"]),
-		DBName:        getEnv("DB_NAME", envDefaults["DB_NAME"]),
-		JWTSecret:     getEnv("JWT_SECRET", envDefaults["JWT_SECRET"]),
-		ServerPort:    getEnv("SERVER_PORT", envDefaults["SERVER_PORT"]),
-		SSHUser:       getEnv("SSH_USER", envDefaults["SSH_USER"]),
-		SSHHomePath:   expandEnvVars(getEnv("SSH_HOME_PATH", envDefaults["SSH_HOME_PATH"])),
-		AdminUsername: getEnv("ADMIN_USERNAME", envDefaults["ADMIN_USERNAME"]),
-		AdminPassword: getEnv("ADMIN_PASSWORD", envDefaults["ADMIN_PASSWORD"]),
+		DBHost:            getEnv("DB_HOST", envDefaults["DB_HOST"]),
+		DBPort:            getEnv("DB_PORT", envDefaults["DB_PORT"]),
+		DBUser:            getEnv("DB_USER", envDefaults["DB_USER"]),
+		DBPassword:        getEnv("DB_PASSWORD", envDefaults["DB_PASSWORD"]),
+		DBName:            getEnv("DB_NAME", envDefaults["DB_NAME"]),
+		JWTSecret:         getEnv("JWT_SECRET", envDefaults["JWT_SECRET"]),
+		MasterKey:         getEnv("MASTER_KEY", envDefaults["MASTER_KEY"]),
+		MasterKeyFile:     getEnv("MASTER_KEY_FILE", envDefaults["MASTER_KEY_FILE"]),
+		MasterKeyPrevious: getEnv("MASTER_KEY_PREVIOUS", envDefaults["MASTER_KEY_PREVIOUS"]),
+		ServerPort:        getEnv("SERVER_PORT", envDefaults["SERVER_PORT"]),
+		KeyAlgorithm:      getEnv("KEY_ALGORITHM", envDefaults["KEY_ALGORITHM"]),
+		SSHUser:           getEnv("SSH_USER", envDefaults["SSH_USER"]),
+		SSHHomePath:       expandEnvVars(getEnv("SSH_HOME_PATH", envDefaults["SSH_HOME_PATH"])),
+		AdminUsername:     getEnv("ADMIN_USERNAME", envDefaults["ADMIN_USERNAME"]),
+		AdminPassword:     getEnv("ADMIN_PASSWORD", envDefaults["ADMIN_PASSWORD"]),
 	}
 
 	// KeyBits 파싱 (정수형)
@@ -99,6 +166,15 @@ func LoadConfig() (*Config, error) {
 		cfg.KeyBits = keyBits
 	}
 
+	// PPKVersion 파싱 (2 또는 3, PuTTY 0.75 미만은 2 필요)
+	ppkVersion, err := strconv.Atoi(getEnv("PPK_VERSION", envDefaults["PPK_VERSION"]))
+	if err != nil || (ppkVersion != 2 && ppkVersion != 3) {
+		log.Printf("경고: PPK_VERSION 값이 올바르지 않아 기본값(3) 사용: %s", getEnv("PPK_VERSION", envDefaults["PPK_VERSION"]))
+		cfg.PPKVersion = 3
+	} else {
+		cfg.PPKVersion = ppkVersion
+	}
+
 	// AutoInstallKeys 파싱 (불리언)
 	autoInstallStr := getEnv("AUTO_INSTALL_KEYS", envDefaults["AUTO_INSTALL_KEYS"])
 	autoInstall, err := strconv.ParseBool(autoInstallStr)
@@ -109,6 +185,110 @@ func LoadConfig() (*Config, error) {
 		cfg.AutoInstallKeys = autoInstall
 	}
 
+	// 키 만료 / 교체 설정 파싱
+	maxAgeDays, err := strconv.Atoi(getEnv("KEY_MAX_AGE_DAYS", envDefaults["KEY_MAX_AGE_DAYS"]))
+	if err != nil || maxAgeDays < 0 {
+		log.Printf("경고: KEY_MAX_AGE_DAYS 값이 올바르지 않아 기본값(0, 만료 없음) 사용: %s", getEnv("KEY_MAX_AGE_DAYS", envDefaults["KEY_MAX_AGE_DAYS"]))
+		maxAgeDays = 0
+	}
+	cfg.KeyMaxAgeDays = maxAgeDays
+
+	warnDays, err := strconv.Atoi(getEnv("KEY_EXPIRY_WARN_DAYS", envDefaults["KEY_EXPIRY_WARN_DAYS"]))
+	if err != nil || warnDays < 0 {
+		log.Printf("경고: KEY_EXPIRY_WARN_DAYS 값이 올바르지 않아 기본값(14) 사용: %s", getEnv("KEY_EXPIRY_WARN_DAYS", envDefaults["KEY_EXPIRY_WARN_DAYS"]))
+		warnDays = 14
+	}
+	cfg.KeyExpiryWarningDays = warnDays
+
+	checkInterval, err := time.ParseDuration(getEnv("KEY_EXPIRY_CHECK_INTERVAL", envDefaults["KEY_EXPIRY_CHECK_INTERVAL"]))
+	if err != nil || checkInterval < 0 {
+		log.Printf("경고: KEY_EXPIRY_CHECK_INTERVAL 값이 올바르지 않아 기본값(1h) 사용: %s", getEnv("KEY_EXPIRY_CHECK_INTERVAL", envDefaults["KEY_EXPIRY_CHECK_INTERVAL"]))
+		checkInterval = time.Hour
+	}
+	cfg.KeyExpiryCheckInterval = checkInterval
+
+	autoRotate, err := strconv.ParseBool(getEnv("KEY_AUTO_ROTATE", envDefaults["KEY_AUTO_ROTATE"]))
+	if err != nil {
+		log.Printf("경고: KEY_AUTO_ROTATE 파싱 실패, 기본값(true) 사용. 오류: %v", err)
+		autoRotate = true
+	}
+	cfg.KeyAutoRotate = autoRotate
+
+	// SSH 인증서 유효 기간 파싱
+	certDefaultTTL, err := time.ParseDuration(getEnv("CERT_DEFAULT_TTL", envDefaults["CERT_DEFAULT_TTL"]))
+	if err != nil || certDefaultTTL <= 0 {
+		log.Printf("경고: CERT_DEFAULT_TTL 값이 올바르지 않아 기본값(8h) 사용: %s", getEnv("CERT_DEFAULT_TTL", envDefaults["CERT_DEFAULT_TTL"]))
+		certDefaultTTL = 8 * time.Hour
+	}
+	certMaxTTL, err := time.ParseDuration(getEnv("CERT_MAX_TTL", envDefaults["CERT_MAX_TTL"]))
+	if err != nil || certMaxTTL <= 0 {
+		log.Printf("경고: CERT_MAX_TTL 값이 올바르지 않아 기본값(24h) 사용: %s", getEnv("CERT_MAX_TTL", envDefaults["CERT_MAX_TTL"]))
+		certMaxTTL = 24 * time.Hour
+	}
+	if certDefaultTTL > certMaxTTL {
+		certDefaultTTL = certMaxTTL
+	}
+	cfg.CertDefaultTTL = certDefaultTTL
+	cfg.CertMaxTTL = certMaxTTL
+
+	hostCertTTL, err := time.ParseDuration(getEnv("HOST_CERT_TTL", envDefaults["HOST_CERT_TTL"]))
+	if err != nil || hostCertTTL <= 0 {
+		log.Printf("경고: HOST_CERT_TTL 값이 올바르지 않아 기본값(8760h) 사용: %s", getEnv("HOST_CERT_TTL", envDefaults["HOST_CERT_TTL"]))
+		hostCertTTL = 8760 * time.Hour
+	}
+	hostCertMaxTTL, err := time.ParseDuration(getEnv("HOST_CERT_MAX_TTL", envDefaults["HOST_CERT_MAX_TTL"]))
+	if err != nil || hostCertMaxTTL <= 0 {
+		log.Printf("경고: HOST_CERT_MAX_TTL 값이 올바르지 않아 기본값(8760h) 사용: %s", getEnv("HOST_CERT_MAX_TTL", envDefaults["HOST_CERT_MAX_TTL"]))
+		hostCertMaxTTL = 8760 * time.Hour
+	}
+	if hostCertTTL > hostCertMaxTTL {
+		hostCertTTL = hostCertMaxTTL
+	}
+	cfg.HostCertTTL = hostCertTTL
+	cfg.HostCertMaxTTL = hostCertMaxTTL
+	cfg.KnownHostsFile = getEnv("SSH_KNOWN_HOSTS_FILE", envDefaults["SSH_KNOWN_HOSTS_FILE"])
+
+	// 원격 SSH 접속 설정 파싱
+	for _, path := range strings.Split(getEnv("SSH_IDENTITY_FILES", envDefaults["SSH_IDENTITY_FILES"]), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			cfg.SSHIdentityFiles = append(cfg.SSHIdentityFiles, path)
+		}
+	}
+	cfg.SSHConnectTimeout = parsePositiveDuration("SSH_CONNECT_TIMEOUT", 10*time.Second)
+	cfg.SSHCommandTimeout = parsePositiveDuration("SSH_COMMAND_TIMEOUT", 60*time.Second)
+	cfg.SSHIdleTimeout = parsePositiveDuration("SSH_IDLE_TIMEOUT", 5*time.Minute)
+
+	// 드리프트 검사 설정 파싱
+	driftInterval, err := time.ParseDuration(getEnv("DRIFT_SCAN_INTERVAL", envDefaults["DRIFT_SCAN_INTERVAL"]))
+	if err != nil || driftInterval < 0 {
+		log.Printf("경고: DRIFT_SCAN_INTERVAL 값이 올바르지 않아 기본값(6h) 사용: %s", getEnv("DRIFT_SCAN_INTERVAL", envDefaults["DRIFT_SCAN_INTERVAL"]))
+		driftInterval = 6 * time.Hour
+	}
+	cfg.DriftScanInterval = driftInterval
+
+	// 배포 작업 설정 파싱
+	jobWorkers, err := strconv.Atoi(getEnv("DEPLOY_JOB_WORKERS", envDefaults["DEPLOY_JOB_WORKERS"]))
+	if err != nil || jobWorkers <= 0 {
+		log.Printf("경고: DEPLOY_JOB_WORKERS 값이 올바르지 않아 기본값(4) 사용: %s", getEnv("DEPLOY_JOB_WORKERS", envDefaults["DEPLOY_JOB_WORKERS"]))
+		jobWorkers = 4
+	}
+	cfg.DeployJobWorkers = jobWorkers
+
+	maxParallel, err := strconv.Atoi(getEnv("DEPLOY_MAX_PARALLEL", envDefaults["DEPLOY_MAX_PARALLEL"]))
+	if err != nil || maxParallel <= 0 {
+		log.Printf("경고: DEPLOY_MAX_PARALLEL 값이 올바르지 않아 기본값(10) 사용: %s", getEnv("DEPLOY_MAX_PARALLEL", envDefaults["DEPLOY_MAX_PARALLEL"]))
+		maxParallel = 10
+	}
+	cfg.DeployMaxParallel = maxParallel
+
+	maxRetries, err := strconv.Atoi(getEnv("DEPLOY_MAX_RETRIES", envDefaults["DEPLOY_MAX_RETRIES"]))
+	if err != nil || maxRetries < 0 {
+		log.Printf("경고: DEPLOY_MAX_RETRIES 값이 올바르지 않아 기본값(2) 사용: %s", getEnv("DEPLOY_MAX_RETRIES", envDefaults["DEPLOY_MAX_RETRIES"]))
+		maxRetries = 2
+	}
+	cfg.DeployMaxRetries = maxRetries
+	cfg.DeployRetryBackoff = parsePositiveDuration("DEPLOY_RETRY_BACKOFF", 2*time.Second)
+
 	// 설정 검증
 	if err := validateConfig(cfg); err != nil {
 		log.Printf("경고: 설정 검증 실패: %v", err)
@@ -117,6 +297,17 @@ func LoadConfig() (*Config, error) {
 	return cfg, nil
 }
 
+// parsePositiveDuration은 기간 환경변수를 파싱합니다. 값이 올바르지 않으면 기본값을 사용합니다.
+func parsePositiveDuration(key string, fallback time.Duration) time.Duration {
+	value := getEnv(key, envDefaults[key])
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("경고: %s 값이 올바르지 않아 기본값(%s) 사용: %s", key, fallback, value)
+		return fallback
+	}
+	return duration
+}
+
 // createEnvFile은 현재 환경변수와 기본값을 기반으로 .env 파일을 생성합니다.
 func createEnvFile(filePath string) error {
 	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
@@ -141,15 +332,50 @@ func createEnvFile(filePath string) error {
 	fmt.Fprintf(file, "\n# JWT 설정\n")
 	writeEnvVarWithGenerator(file, "JWT_SECRET", "JWT 서명 키 (자동 생성됨)", generateJWTSecret)
 
+	fmt.Fprintf(file, "\n# 개인키 암호화 설정 (봉투 암호화)\n")
+	writeEnvVarWithGenerator(file, "MASTER_KEY", "개인키 암호화 마스터 키 (자동 생성됨, 분실 시 저장된 개인키 복구 불가)", generateMasterKey)
+	writeEnvVar(file, "MASTER_KEY_FILE", "마스터 키 파일 경로 (설정 시 MASTER_KEY보다 우선, 첫 줄이 활성 키)")
+	writeEnvVar(file, "MASTER_KEY_PREVIOUS", "교체 전 마스터 키 목록 (쉼표 구분, 복호화 전용)")
+
 	fmt.Fprintf(file, "\n# 서버 설정\n")
 	writeEnvVar(file, "SERVER_PORT", "서버 포트")
 	writeEnvVar(file, "KEY_BITS", "SSH 키 비트 수")
+	writeEnvVar(file, "KEY_ALGORITHM", "기본 SSH 키 알고리즘 (rsa, ed25519, ecdsa-p256, ecdsa-p384, ecdsa-p521)")
+	writeEnvVar(file, "PPK_VERSION", "생성 키의 PPK 버전 (3: PuTTY 0.75 이상, 2: 이전 버전 호환)")
 
 	fmt.Fprintf(file, "\n# SSH 키 자동 설치 설정\n")
 	writeEnvVar(file, "AUTO_INSTALL_KEYS", "SSH 키 자동 설치 여부")
 	writeEnvVar(file, "SSH_USER", "SSH 사용자명")
 	writeEnvVar(file, "SSH_HOME_PATH", "SSH 홈 디렉토리 경로")
 
+	fmt.Fprintf(file, "\n# 키 만료 / 자동 교체 설정\n")
+	writeEnvVar(file, "KEY_MAX_AGE_DAYS", "조직 기본 최대 키 사용 기간 (일, 0이면 만료 없음, 부서별 설정이 우선)")
+	writeEnvVar(file, "KEY_EXPIRY_WARN_DAYS", "만료 며칠 전부터 키 소유자에게 알릴지")
+	writeEnvVar(file, "KEY_EXPIRY_CHECK_INTERVAL", "만료 검사 주기 (예: 30m, 1h, 0이면 비활성화)")
+	writeEnvVar(file, "KEY_AUTO_ROTATE", "만료된 키 자동 교체 여부 (false면 폐기 후 서버에서 제거만 함)")
+
+	fmt.Fprintf(file, "\n# SSH 인증서 설정\n")
+	writeEnvVar(file, "CERT_DEFAULT_TTL", "사용자 인증서 기본 유효 기간 (예: 8h)")
+	writeEnvVar(file, "CERT_MAX_TTL", "사용자 인증서 최대 유효 기간 (요청 가능한 최대값)")
+	writeEnvVar(file, "HOST_CERT_TTL", "호스트 인증서 기본 유효 기간 (예: 8760h = 1년)")
+	writeEnvVar(file, "HOST_CERT_MAX_TTL", "호스트 인증서 최대 유효 기간 (요청 가능한 최대값)")
+	writeEnvVar(file, "SSH_KNOWN_HOSTS_FILE", "원격 서버 호스트 키 검증용 known_hosts 파일 (호스트 CA 줄 자동 기록)")
+
+	fmt.Fprintf(file, "\n# 원격 SSH 접속 설정\n")
+	writeEnvVar(file, "SSH_IDENTITY_FILES", "원격 서버 접속용 개인키 파일 (쉼표 구분, 비어있으면 ~/.ssh/id_ed25519, id_ecdsa, id_rsa 및 ssh-agent 사용)")
+	writeEnvVar(file, "SSH_CONNECT_TIMEOUT", "원격 서버 연결 타임아웃 (예: 10s)")
+	writeEnvVar(file, "SSH_COMMAND_TIMEOUT", "원격 명령 실행 타임아웃 (예: 60s)")
+	writeEnvVar(file, "SSH_IDLE_TIMEOUT", "유휴 SSH 연결 유지 시간 (예: 5m)")
+
+	fmt.Fprintf(file, "\n# 드리프트 검사 설정\n")
+	writeEnvVar(file, "DRIFT_SCAN_INTERVAL", "서버 authorized_keys와 배포 기록 비교 주기 (예: 6h, 0이면 비활성화)")
+
+	fmt.Fprintf(file, "\n# 배포 작업 설정\n")
+	writeEnvVar(file, "DEPLOY_JOB_WORKERS", "동시에 실행할 비동기 배포 작업 수")
+	writeEnvVar(file, "DEPLOY_MAX_PARALLEL", "배포 하나에서 동시에 작업할 서버 수")
+	writeEnvVar(file, "DEPLOY_MAX_RETRIES", "서버 접속 실패, 시간 초과 시 서버별 재시도 횟수 (인증 실패는 재시도하지 않음, 0이면 재시도 없음)")
+	writeEnvVar(file, "DEPLOY_RETRY_BACKOFF", "첫 재시도 대기 시간, 재시도마다 두 배 (예: 2s)")
+
 	fmt.Fprintf(file, "\n# 관리자 설정\n")
 	writeEnvVar(file, "ADMIN_USERNAME", "초기 관리자 사용자명")
 	writeEnvVar(file, "ADMIN_PASSWORD", "초기 관리자 비밀번호")
@@ -219,6 +445,40 @@ func generateJWTSecret() string {
 	return base64.URLEncoding.EncodeToString(bytes)
 }
 
+// generateMasterKey는 개인키 암호화용 256비트 마스터 키를 "<키ID>:<base64>" 형식으로 생성합니다.
+func generateMasterKey() string {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		log.Printf("경고: 마스터 키 생성 실패, 수동으로 MASTER_KEY를 설정하세요: %v", err)
+		return ""
+	}
+
+	return "mk-" + time.Now().Format("20060102") + ":" + base64.StdEncoding.EncodeToString(bytes)
+}
+
+// appendMasterKey는 새 마스터 키를 생성하여 기존 .env 파일 끝에 추가하고 현재 프로세스 환경변수에도 설정합니다.
+// 파일에 쓸 수 없으면 설정하지 않으며, 시작 시 개인키 암호화 확인에서 실패합니다.
+func appendMasterKey(filePath string) {
+	masterKey := generateMasterKey()
+	if masterKey == "" {
+		return
+	}
+
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Printf("경고: MASTER_KEY를 .env에 저장할 수 없습니다. MASTER_KEY 또는 MASTER_KEY_FILE을 직접 설정하세요: %v", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "\n# 개인키 암호화 마스터 키 (자동 생성됨, 분실 시 저장된 개인키 복구 불가)\nMASTER_KEY=%s\n", masterKey); err != nil {
+		log.Printf("경고: MASTER_KEY를 .env에 저장할 수 없습니다. MASTER_KEY 또는 MASTER_KEY_FILE을 직접 설정하세요: %v", err)
+		return
+	}
+	os.Setenv("MASTER_KEY", masterKey)
+	log.Printf("정보: MASTER_KEY가 없어 새로 생성하여 %s에 추가했습니다 (백업해두세요)", filePath)
+}
+
 // shouldIncludeExtraEnv는 추가로 포함할 환경변수인지 판단합니다.
 func shouldIncludeExtraEnv(env string) bool {
 	// 기본 설정에 이미 포함된 것들은 제외
@@ -267,6 +527,10 @@ func validateConfig(cfg *Config) error {
 		missingFields = append(missingFields, "JWT_SECRET")
 	}
 
+	if cfg.MasterKey == "" && cfg.MasterKeyFile == "" {
+		log.Printf("보안 경고: MASTER_KEY 또는 MASTER_KEY_FILE이 설정되지 않아 SSH 개인키를 저장할 수 없습니다")
+	}
+
 	// JWT 시크릿 길이 검증
 	if cfg.JWTSecret != "" && len(cfg.JWTSecret) < 32 {
 		log.Printf("보안 경고: JWT_SECRET이 너무 짧습니다 (최소 32자 권장). 현재 길이: %d", len(cfg.JWTSecret))
@@ -305,8 +569,12 @@ func (c *Config) LogConfig() {
 	log.Printf("DB Name: %s", c.DBName)
 	log.Printf("DB Password: %s", maskSensitive(c.DBPassword))
 	log.Printf("JWT Secret: %s", maskSensitive(c.JWTSecret))
+	log.Printf("Master Key: %s", maskSensitive(c.MasterKey))
+	log.Printf("Master Key File: %s", c.MasterKeyFile)
 	log.Printf("Server Port: %s", c.ServerPort)
 	log.Printf("Key Bits: %d", c.KeyBits)
+	log.Printf("Key Algorithm: %s", c.KeyAlgorithm)
+	log.Printf("PPK Version: %d", c.PPKVersion)
 	log.Printf("Auto Install Keys: %t", c.AutoInstallKeys)
 	log.Printf("SSH User: %s", c.SSHUser)
 	log.Printf("SSH Home Path: %s", c.SSHHomePath)
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"ssh-key-manager/helpers"
+	"ssh-key-manager/models"
+	"ssh-key-manager/services"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IssueUserCertificate는 사용자 키를 사용자 CA로 서명한 단기 인증서를 발급합니다.
+// download=true 쿼리 파라미터를 지정하면 *-cert.pub 파일로 응답합니다.
+func IssueUserCertificate(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	var req types.UserCertificateRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var cert *types.CertificateResponse
+	err = utils.LogOperation("SSH 사용자 인증서 발급", func() error {
+		utils.LogServiceCall("CertificateService", "IssueUserCertificate", userID, req.SSHKeyID, req.ValidFor)
+		var issueErr error
+		cert, issueErr = services.IssueUserCertificate(userID, req)
+		return issueErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "발급", "SSH 인증서", false, err.Error())
+		return utils.HandleServiceError(c, err, "SSH 인증서 발급")
+	}
+
+	utils.LogUserAction(userID, "발급", "SSH 인증서", true, fmt.Sprintf("일련번호: %d", cert.Serial))
+	utils.LogSecurityEvent("SSH 인증서 발급", userID, fmt.Sprintf("일련번호 %d, principal %v, 만료 %s", cert.Serial, cert.Principals, cert.ValidBefore.Format("2006-01-02 15:04")), "low")
+
+	if c.QueryParam("download") == "true" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="id-cert.pub"`)
+		c.Response().Header().Set("Cache-Control", "no-store")
+		return c.Blob(http.StatusOK, "text/plain", []byte(cert.Certificate+"\n"))
+	}
+
+	return helpers.SuccessWithMessageResponse(c, "SSH 인증서가 발급되었습니다", cert)
+}
+
+// ListCertificates는 사용자가 발급받은 인증서 목록을 조회합니다.
+func ListCertificates(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	utils.LogServiceCall("CertificateService", "ListUserCertificates", userID)
+	certs, err := services.ListUserCertificates(userID)
+	if err != nil {
+		utils.LogUserAction(userID, "조회", "SSH 인증서 목록", false, err.Error())
+		return helpers.InternalServerErrorResponse(c, "SSH 인증서 목록 조회 실패")
+	}
+
+	utils.LogUserAction(userID, "조회", "SSH 인증서 목록", true, fmt.Sprintf("총 %d개", len(certs)))
+	return helpers.ListResponse(c, certs, len(certs))
+}
+
+// GetUserCAPublicKey는 사용자 CA 공개키를 반환합니다 (인증 불필요).
+// 서버의 TrustedUserCAKeys 파일로 그대로 저장할 수 있도록 기본은 text/plain으로 응답하며,
+// format=json이면 핑거프린트와 설정 방법을 포함한 JSON으로 응답합니다.
+func GetUserCAPublicKey(c echo.Context) error {
+	return respondCAPublicKey(c, models.CATypeUser)
+}
+
+// GetHostCAPublicKey는 호스트 CA 공개키를 반환합니다 (인증 불필요).
+// 응답 형식은 GetUserCAPublicKey와 같습니다.
+func GetHostCAPublicKey(c echo.Context) error {
+	return respondCAPublicKey(c, models.CATypeHost)
+}
+
+// SignServerHostCertificate는 서버 호스트 키를 호스트 CA로 서명합니다 (관리자 전용).
+// host_public_key를 생략하면 서버에 접속하여 호스트 키를 조회합니다.
+func SignServerHostCertificate(c echo.Context) error {
+	adminID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	serverID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	var req types.HostCertificateRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var cert *types.HostCertificateResponse
+	err = utils.LogOperation("SSH 호스트 인증서 발급", func() error {
+		utils.LogServiceCall("CertificateService", "SignServerHostKey", adminID, serverID, req.ValidFor)
+		var signErr error
+		cert, signErr = services.SignServerHostKey(adminID, serverID, req)
+		return signErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(adminID, "발급", "SSH 호스트 인증서", false, err.Error())
+		return utils.HandleServiceError(c, err, "SSH 호스트 인증서 발급")
+	}
+
+	utils.LogUserAction(adminID, "발급", "SSH 호스트 인증서", true, fmt.Sprintf("서버 ID: %d, 일련번호: %d", serverID, cert.Serial))
+	utils.LogSecurityEvent("SSH 호스트 인증서 발급", adminID, fmt.Sprintf("서버 ID %d, 호스트 키 %s, principal %v", serverID, cert.Fingerprint, cert.Principals), "medium")
+	return helpers.SuccessWithMessageResponse(c, "SSH 호스트 인증서가 발급되었습니다", cert)
+}
+
+// DownloadKnownHosts는 호스트 CA를 신뢰하는 known_hosts 파일을 내려받습니다.
+// 기본은 호스트 인증서가 발급된 서버에만 적용되며, scope=all이면 모든 호스트(*)에 적용됩니다.
+func DownloadKnownHosts(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	allHosts := c.QueryParam("scope") == "all"
+	utils.LogServiceCall("CertificateService", "GenerateKnownHosts", userID, allHosts)
+	content, err := services.GenerateKnownHosts(allHosts)
+	if err != nil {
+		utils.LogUserAction(userID, "다운로드", "known_hosts", false, err.Error())
+		return utils.HandleServiceError(c, err, "known_hosts 생성")
+	}
+
+	utils.LogUserAction(userID, "다운로드", "known_hosts", true, "")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="known_hosts"`)
+	return c.Blob(http.StatusOK, "text/plain", []byte(content))
+}
+
+// respondCAPublicKey는 CA 공개키를 text/plain 또는 JSON으로 응답합니다.
+func respondCAPublicKey(c echo.Context, caType string) error {
+	ca, err := services.GetCAPublicKey(caType)
+	if err != nil {
+		return utils.HandleServiceError(c, err, "CA 공개키 조회")
+	}
+
+	if c.QueryParam("format") == "json" {
+		return helpers.SuccessResponse(c, ca)
+	}
+	return c.Blob(http.StatusOK, "text/plain", []byte(ca.PublicKey+"\n"))
+}
@@ -0,0 +1,320 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"ssh-key-manager/helpers"
+	"ssh-key-manager/models"
+	"ssh-key-manager/services"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"time"
+
+	echojwt "github.com/labstack/echo-jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+// eventStreamHeartbeat는 프록시가 유휴 연결을 끊지 않도록 주석 줄을 보내는 주기입니다.
+const eventStreamHeartbeat = 15 * time.Second
+
+// SubmitDeploymentJob godoc
+// @Summary Submit a background deployment job
+// @Description Queue a key deployment (type deploy_key, parameters as KeyDeploymentRequest) or removal (type undeploy_key, parameters as KeyUndeployRequest) and return immediately. Poll the job for progress
+// @Tags jobs
+// @Accept  json
+// @Produce  json
+// @Param   request  body   types.TaskRequest  true  "Job type and parameters"
+// @Security BearerAuth
+// @Success 202 {object} types.ProgressResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /jobs [post]
+func SubmitDeploymentJob(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	var req types.TaskRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	utils.LogServiceCall("DeploymentJobService", "SubmitDeploymentJob", userID, req.Type)
+	progress, err := services.SubmitDeploymentJob(userID, req)
+	if err != nil {
+		utils.LogUserAction(userID, "등록", "배포 작업", false, err.Error())
+		return utils.HandleServiceError(c, err, "배포 작업 등록")
+	}
+
+	utils.LogUserAction(userID, "등록", "배포 작업", true, fmt.Sprintf("작업 ID: %s (%s)", progress.TaskID, req.Type))
+	return helpers.AcceptedResponse(c, "배포 작업이 등록되었습니다", progress)
+}
+
+// GetDeploymentJobs godoc
+// @Summary List deployment jobs
+// @Description List the current user's 50 most recent deployment jobs with their progress
+// @Tags jobs
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /jobs [get]
+func GetDeploymentJobs(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	utils.LogServiceCall("DeploymentJobService", "GetUserDeploymentJobs", userID)
+	jobs, err := services.GetUserDeploymentJobs(userID)
+	if err != nil {
+		utils.LogUserAction(userID, "조회", "배포 작업", false, err.Error())
+		return helpers.InternalServerErrorResponse(c, "배포 작업 목록 조회 실패")
+	}
+
+	return helpers.ListResponse(c, jobs, len(jobs))
+}
+
+// GetDeploymentJob godoc
+// @Summary Get deployment job progress
+// @Description Get a deployment job's status, progress and per-server results so far
+// @Tags jobs
+// @Produce  json
+// @Param   id  path  int  true  "Job ID"
+// @Security BearerAuth
+// @Success 200 {object} types.ProgressResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /jobs/{id} [get]
+func GetDeploymentJob(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	jobID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	progress, err := services.GetDeploymentJob(userID, jobID)
+	if err != nil {
+		return utils.HandleServiceError(c, err, "배포 작업 조회")
+	}
+
+	return helpers.SuccessResponse(c, progress)
+}
+
+// CancelDeploymentJob godoc
+// @Summary Cancel a deployment job
+// @Description Cancel a pending job, or stop a running job after aborting the server in progress. Servers already processed are not reverted
+// @Tags jobs
+// @Produce  json
+// @Param   id  path  int  true  "Job ID"
+// @Security BearerAuth
+// @Success 200 {object} types.ProgressResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /jobs/{id}/cancel [post]
+func CancelDeploymentJob(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	jobID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	utils.LogServiceCall("DeploymentJobService", "CancelDeploymentJob", userID, jobID)
+	progress, err := services.CancelDeploymentJob(userID, jobID)
+	if err != nil {
+		utils.LogUserAction(userID, "취소", "배포 작업", false, err.Error())
+		if errors.Is(err, services.ErrDeploymentJobFinished) {
+			return helpers.ConflictResponse(c, err.Error())
+		}
+		return utils.HandleServiceError(c, err, "배포 작업 취소")
+	}
+
+	utils.LogUserAction(userID, "취소", "배포 작업", true, fmt.Sprintf("작업 ID: %d", jobID))
+	return helpers.SuccessWithMessageResponse(c, "배포 작업 취소를 요청했습니다", progress)
+}
+
+// IssueDeploymentEventToken godoc
+// @Summary Issue a short-lived event stream token
+// @Description Issue a token valid for 60 seconds that authenticates only the event stream endpoints via the token query parameter (browser EventSource cannot send an Authorization header). Request a new token for each (re)connection
+// @Tags jobs
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /jobs/events/token [post]
+func IssueDeploymentEventToken(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	token, err := utils.GenerateEventStreamToken(userID)
+	if err != nil {
+		utils.LogUserAction(userID, "발급", "이벤트 스트림 토큰", false, err.Error())
+		return helpers.InternalServerErrorResponse(c, "스트림 토큰 발급 중 오류가 발생했습니다")
+	}
+
+	return helpers.SuccessResponse(c, map[string]interface{}{
+		"token":      token,
+		"expires_in": int(utils.EventStreamTokenTTL.Seconds()),
+	})
+}
+
+// EventStreamAuth는 이벤트 스트림 라우트의 인증 미들웨어입니다.
+// token 쿼리 파라미터가 있으면 IssueDeploymentEventToken으로 발급한 스트림 토큰으로 인증하고(EventSource용),
+// 없으면 일반 API와 같이 Authorization 헤더의 JWT로 인증합니다.
+func EventStreamAuth(jwtConfig echojwt.Config) echo.MiddlewareFunc {
+	headerAuth := echojwt.WithConfig(jwtConfig)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withHeader := headerAuth(next)
+		return func(c echo.Context) error {
+			tokenString := c.QueryParam("token")
+			if tokenString == "" {
+				return withHeader(c)
+			}
+
+			token, err := utils.ParseEventStreamToken(tokenString)
+			if err != nil {
+				utils.LogSecurityEvent("스트림 토큰 인증 실패", 0, err.Error(), "low")
+				return helpers.UnauthorizedResponse(c, "invalid or expired stream token")
+			}
+			c.Set(jwtConfig.ContextKey, token)
+			return next(c)
+		}
+	}
+}
+
+// StreamDeploymentJobEvents godoc
+// @Summary Stream deployment job events (SSE)
+// @Description Stream a job's events as Server-Sent Events: a snapshot of the current progress, then connecting, installed, removed, failed (with error_message), retried, and job_finished, after which the stream ends.
+// @Tags jobs
+// @Produce  text/event-stream
+// @Param   id     path   int     true   "Job ID"
+// @Param   token  query  string  false  "Stream token from POST /jobs/events/token (instead of the Authorization header)"
+// @Security BearerAuth
+// @Success 200 {object} types.DeploymentEvent
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /jobs/{id}/events [get]
+func StreamDeploymentJobEvents(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	jobID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	// 진행 상황을 읽는 사이의 이벤트를 놓치지 않도록 먼저 구독
+	events, unsubscribe := services.SubscribeDeploymentEvents(userID, jobID)
+	defer unsubscribe()
+
+	progress, err := services.GetDeploymentJob(userID, jobID)
+	if err != nil {
+		return utils.HandleServiceError(c, err, "배포 작업 조회")
+	}
+
+	startEventStream(c)
+	snapshot := types.DeploymentEvent{Event: types.DeploymentEventSnapshot, JobID: jobID, Job: progress, Time: time.Now()}
+	if err := writeDeploymentEvent(c, snapshot); err != nil {
+		return nil
+	}
+	if progress.Status != models.JobStatusPending && progress.Status != models.JobStatusRunning {
+		return nil
+	}
+
+	return streamDeploymentEvents(c, events, true)
+}
+
+// StreamDeploymentEvents godoc
+// @Summary Stream all deployment events of the current user (SSE)
+// @Description Stream every deployment event of the current user as Server-Sent Events, including background jobs (job_id set) and deployments run directly in a request (job_id 0).
+// @Tags jobs
+// @Produce  text/event-stream
+// @Param   token  query  string  false  "Stream token from POST /jobs/events/token (instead of the Authorization header)"
+// @Security BearerAuth
+// @Success 200 {object} types.DeploymentEvent
+// @Failure 401 {object} map[string]interface{}
+// @Router /jobs/events [get]
+func StreamDeploymentEvents(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	events, unsubscribe := services.SubscribeDeploymentEvents(userID, 0)
+	defer unsubscribe()
+
+	startEventStream(c)
+	return streamDeploymentEvents(c, events, false)
+}
+
+// startEventStream은 SSE 응답 헤더를 보냅니다.
+func startEventStream(c echo.Context) {
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, "text/event-stream")
+	header.Set(echo.HeaderCacheControl, "no-cache")
+	header.Set(echo.HeaderConnection, "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // nginx 버퍼링 끄기
+	c.Response().WriteHeader(http.StatusOK)
+	c.Response().Flush()
+}
+
+// streamDeploymentEvents는 클라이언트 연결이 끊기거나 구독이 끝날 때까지 이벤트를 보냅니다.
+// untilJobFinished이면 job_finished 이벤트를 보낸 뒤 끝냅니다.
+func streamDeploymentEvents(c echo.Context, events <-chan types.DeploymentEvent, untilJobFinished bool) error {
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Response(), ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			c.Response().Flush()
+
+		case event, ok := <-events:
+			if !ok {
+				// 처리가 늦어 구독이 끊김 (클라이언트가 다시 연결하면 현재 상황부터 받음)
+				return nil
+			}
+			if err := writeDeploymentEvent(c, event); err != nil {
+				return nil
+			}
+			if untilJobFinished && event.Event == types.DeploymentEventJobFinished {
+				return nil
+			}
+		}
+	}
+}
+
+// writeDeploymentEvent는 이벤트 하나를 SSE 형식으로 씁니다.
+func writeDeploymentEvent(c echo.Context, event types.DeploymentEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.Response(), "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Event, data); err != nil {
+		return err
+	}
+	c.Response().Flush()
+	return nil
+}
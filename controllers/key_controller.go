@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"fmt"
+	"net/http"
 	"ssh-key-manager/helpers"
 	"ssh-key-manager/services"
+	"ssh-key-manager/types"
 	"ssh-key-manager/utils"
 
 	"github.com/labstack/echo/v4"
@@ -35,58 +38,390 @@ func ValidateToken(c echo.Context) error {
 	return helpers.SuccessResponse(c, responseData)
 }
 
-// CreateKey는 SSH 키 쌍을 생성하거나 재생성합니다.
+// CreateKey는 새 SSH 키 쌍을 생성합니다.
+// 요청 본문의 algorithm으로 rsa, ed25519, ecdsa-p256, ecdsa-p384, ecdsa-p521 중 하나를 선택할 수 있습니다.
+// 같은 이름의 키가 이미 있으면 force_replace가 true일 때만 교체합니다.
 func CreateKey(c echo.Context) error {
 	userID, err := utils.UserIDFromToken(c)
 	if err != nil {
 		return helpers.UnauthorizedResponse(c, "Invalid token")
 	}
 
+	var req types.SSHKeyCreateRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	opts := types.SSHKeyGenerationOptions{
+		Name:          req.Name,
+		Algorithm:     req.Algorithm,
+		Bits:          req.Bits,
+		Comment:       req.Comment,
+		Passphrase:    req.Passphrase,
+		ForceReplace:  req.ForceReplace,
+		ExpiresInDays: req.ExpiresInDays,
+	}
+
 	var key interface{}
 	err = utils.LogOperation("SSH 키 생성", func() error {
-		utils.LogServiceCall("KeyService", "GenerateSSHKeyPair", userID)
+		utils.LogServiceCall("KeyService", "GenerateSSHKeyPair", userID, opts.Name, opts.Algorithm, opts.Bits)
 		var generateErr error
-		key, generateErr = services.GenerateSSHKeyPair(userID)
+		key, generateErr = services.GenerateSSHKeyPair(userID, opts)
 		return generateErr
 	})
 
 	if err != nil {
 		utils.LogUserAction(userID, "생성", "SSH 키", false, err.Error())
-		return helpers.InternalServerErrorResponse(c, "Failed to generate key pair")
+		return utils.HandleServiceError(c, err, "SSH 키 생성")
 	}
 
 	utils.LogUserAction(userID, "생성", "SSH 키", true)
 	return helpers.SuccessWithMessageResponse(c, "SSH 키가 성공적으로 생성되었습니다", key)
 }
 
-// GetKey는 사용자의 SSH 키 쌍을 조회합니다.
+// ImportKey는 기존 개인키 또는 공개키를 가져와 등록합니다.
+// 개인키는 OpenSSH, PEM, PKCS#8, PPK 형식을 지원하며 암호화된 키는 password로 복호화합니다.
+// 공개키(authorized_keys, RFC 4716)만 등록하면 개인키 없이 배포 전용 키로 저장됩니다.
+func ImportKey(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	var req types.SSHKeyImportRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var key interface{}
+	err = utils.LogOperation("SSH 키 가져오기", func() error {
+		utils.LogServiceCall("KeyService", "ImportSSHKey", userID, req.Name, req.Format, req.Overwrite)
+		var importErr error
+		key, importErr = services.ImportSSHKey(userID, req)
+		return importErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "가져오기", "SSH 키", false, err.Error())
+		return utils.HandleServiceError(c, err, "SSH 키 가져오기")
+	}
+
+	utils.LogUserAction(userID, "가져오기", "SSH 키", true)
+	return helpers.SuccessWithMessageResponse(c, "SSH 키를 성공적으로 가져왔습니다", key)
+}
+
+// ValidateKey는 붙여넣은 공개키 또는 개인키를 저장하지 않고 검증합니다.
+// 파싱 오류와 정책 위반은 결과의 errors/warnings로 반환됩니다.
+func ValidateKey(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	var req types.SSHKeyValidateRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	utils.LogServiceCall("KeyService", "ValidateSSHKey", userID)
+	result := services.ValidateSSHKey(userID, req)
+
+	utils.LogUserAction(userID, "검증", "SSH 키", true, fmt.Sprintf("유효: %t", result.Valid))
+	return helpers.SuccessResponse(c, result)
+}
+
+// GetKeySecurity는 사용자의 특정 SSH 키 보안 평가 결과를 조회합니다.
+func GetKeySecurity(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	keyID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	utils.LogServiceCall("KeyService", "CheckKeySecurity", userID, keyID)
+	check, err := services.CheckKeySecurity(userID, keyID)
+	if err != nil {
+		utils.LogUserAction(userID, "보안 평가", "SSH 키", false, err.Error())
+		return utils.HandleServiceError(c, err, "SSH 키 보안 평가")
+	}
+
+	utils.LogUserAction(userID, "보안 평가", "SSH 키", true, fmt.Sprintf("점수 %d (%s)", check.Score, check.SecurityLevel))
+	return helpers.SuccessResponse(c, check)
+}
+
+// GetKeySecurityReport는 전체 SSH 키의 보안 평가 보고서를 조회합니다 (관리자 전용).
+// level 쿼리 파라미터(HIGH, MEDIUM, LOW)로 특정 등급의 키만 볼 수 있습니다.
+func GetKeySecurityReport(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	level := c.QueryParam("level")
+
+	var report interface{}
+	err = utils.LogOperation("SSH 키 보안 보고서 생성", func() error {
+		utils.LogServiceCall("KeyService", "GetKeySecurityReport", userID, level)
+		var reportErr error
+		report, reportErr = services.GetKeySecurityReport(level)
+		return reportErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "조회", "SSH 키 보안 보고서", false, err.Error())
+		return utils.HandleServiceError(c, err, "SSH 키 보안 보고서 생성")
+	}
+
+	utils.LogUserAction(userID, "조회", "SSH 키 보안 보고서", true)
+	return helpers.SuccessResponse(c, report)
+}
+
+// SearchKeysByFingerprint는 핑거프린트 또는 sshd 로그 줄로 키와 소유자를 찾습니다 (관리자 전용).
+// GET은 q 쿼리 파라미터, POST는 {"query": "..."} 본문을 사용합니다.
+func SearchKeysByFingerprint(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	var req types.KeyFingerprintSearchRequest
+	if c.Request().Method == http.MethodGet {
+		req.Query = c.QueryParam("q")
+	} else if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	utils.LogServiceCall("KeyService", "SearchKeysByFingerprint", userID)
+	result, err := services.SearchKeysByFingerprint(req.Query)
+	if err != nil {
+		utils.LogUserAction(userID, "검색", "SSH 키 핑거프린트", false, err.Error())
+		return utils.HandleServiceError(c, err, "핑거프린트 검색")
+	}
+
+	utils.LogUserAction(userID, "검색", "SSH 키 핑거프린트", true, fmt.Sprintf("일치 %d건", len(result.Matches)))
+	return helpers.SuccessResponse(c, result)
+}
+
+// ListKeys는 사용자의 SSH 키 목록을 조회합니다.
+func ListKeys(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	utils.LogServiceCall("KeyService", "ListKeysByUserID", userID)
+	keys, err := services.ListKeysByUserID(userID)
+	if err != nil {
+		utils.LogUserAction(userID, "조회", "SSH 키 목록", false, err.Error())
+		return helpers.InternalServerErrorResponse(c, "SSH 키 목록 조회 실패")
+	}
+
+	utils.LogUserAction(userID, "조회", "SSH 키 목록", true, fmt.Sprintf("총 %d개", len(keys)))
+	return helpers.ListResponse(c, keys, len(keys))
+}
+
+// GetKey는 사용자의 특정 SSH 키 쌍을 조회합니다.
 func GetKey(c echo.Context) error {
 	userID, err := utils.UserIDFromToken(c)
 	if err != nil {
 		return helpers.UnauthorizedResponse(c, "Invalid token")
 	}
 
-	utils.LogServiceCall("KeyService", "GetKeyByUserID", userID)
-	key, err := services.GetKeyByUserID(userID)
+	keyID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	utils.LogServiceCall("KeyService", "GetKeyByID", userID, keyID)
+	key, err := services.GetKeyByID(userID, keyID)
 	if err != nil {
 		utils.LogUserAction(userID, "조회", "SSH 키", false, err.Error())
 		return helpers.NotFoundResponse(c, err.Error())
 	}
 
-	utils.LogUserAction(userID, "조회", "SSH 키", true)
+	utils.LogUserAction(userID, "조회", "SSH 키", true, fmt.Sprintf("ID: %d", keyID))
 	return helpers.SuccessResponse(c, key)
 }
 
-// DeleteKey는 사용자의 SSH 키 쌍을 삭제합니다.
+// UpdateKey는 SSH 키의 이름이나 상태를 변경합니다.
+func UpdateKey(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	keyID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	var req types.SSHKeyUpdateRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var key interface{}
+	err = utils.LogOperation("SSH 키 수정", func() error {
+		utils.LogServiceCall("KeyService", "UpdateKey", userID, keyID)
+		var updateErr error
+		key, updateErr = services.UpdateKey(userID, keyID, req)
+		return updateErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "수정", "SSH 키", false, err.Error())
+		return utils.HandleServiceError(c, err, "SSH 키 수정")
+	}
+
+	utils.LogUserAction(userID, "수정", "SSH 키", true, fmt.Sprintf("ID: %d", keyID))
+	return helpers.SuccessWithMessageResponse(c, "SSH 키 정보가 업데이트되었습니다", key)
+}
+
+// ExportKey는 SSH 키를 지정한 형식 하나로 내보냅니다.
+// password를 지정하면 내보내는 개인키가 해당 패스프레이즈로 암호화되며,
+// download가 true이면 JSON 대신 파일 첨부(Content-Disposition)로 응답합니다.
+func ExportKey(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	keyID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	var req types.SSHKeyExportRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var exported *types.SSHKeyExportResponse
+	err = utils.LogOperation("SSH 키 내보내기", func() error {
+		utils.LogServiceCall("KeyService", "ExportKey", userID, keyID, req.Format)
+		var exportErr error
+		exported, exportErr = services.ExportKey(userID, keyID, req)
+		return exportErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "내보내기", "SSH 키", false, err.Error())
+		return utils.HandleServiceError(c, err, "SSH 키 내보내기")
+	}
+
+	utils.LogUserAction(userID, "내보내기", "SSH 키", true, fmt.Sprintf("ID: %d, 형식: %s", keyID, exported.Format))
+	if !exported.Encrypted && exported.MimeType != "text/plain" {
+		utils.LogSecurityEvent("암호화되지 않은 개인키 내보내기", userID, fmt.Sprintf("키 ID: %d, 형식: %s", keyID, exported.Format), "low")
+	}
+
+	// 파일 다운로드 요청 시 첨부 파일로 응답 (본문 download 또는 ?download=true)
+	if req.Download || c.QueryParam("download") == "true" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", exported.Filename))
+		c.Response().Header().Set("Cache-Control", "no-store")
+		return c.Blob(http.StatusOK, exported.MimeType, []byte(exported.Content))
+	}
+
+	return helpers.SuccessResponse(c, exported)
+}
+
+// RotateKey는 SSH 키를 즉시 새 키로 교체합니다.
+// 새 키를 기존 키가 배포된 서버에 모두 배포한 뒤 기존 키를 제거하고 폐기합니다.
+func RotateKey(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	keyID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	var result *types.KeyRotationResult
+	err = utils.LogOperation("SSH 키 교체", func() error {
+		utils.LogServiceCall("KeyService", "RotateKey", userID, keyID)
+		var rotateErr error
+		result, rotateErr = services.RotateKey(userID, keyID)
+		return rotateErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "교체", "SSH 키", false, err.Error())
+		return utils.HandleServiceError(c, err, "SSH 키 교체")
+	}
+
+	utils.LogUserAction(userID, "교체", "SSH 키", true, fmt.Sprintf("ID: %d, 상태: %s", keyID, result.Status))
+	utils.LogSecurityEvent("SSH 키 교체", userID, fmt.Sprintf("키 ID %d 교체 (상태: %s)", keyID, result.Status), "low")
+	return helpers.SuccessWithMessageResponse(c, "SSH 키가 교체되었습니다", result)
+}
+
+// GetKeyRotations는 SSH 키의 교체 기록을 조회합니다.
+func GetKeyRotations(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	keyID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	utils.LogServiceCall("KeyService", "GetKeyRotations", userID, keyID)
+	rotations, err := services.GetKeyRotations(userID, keyID)
+	if err != nil {
+		utils.LogUserAction(userID, "조회", "SSH 키 교체 기록", false, err.Error())
+		return utils.HandleServiceError(c, err, "SSH 키 교체 기록 조회")
+	}
+
+	utils.LogUserAction(userID, "조회", "SSH 키 교체 기록", true, fmt.Sprintf("총 %d건", len(rotations)))
+	return helpers.ListResponse(c, rotations, len(rotations))
+}
+
+// RunKeyExpiryCheck는 키 만료 검사(알림, 만료 키 교체)를 즉시 실행합니다 (관리자 전용).
+func RunKeyExpiryCheck(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	var summary *types.KeyExpiryCheckSummary
+	err = utils.LogOperation("SSH 키 만료 검사", func() error {
+		utils.LogServiceCall("KeyService", "RunKeyExpiryCheck", userID)
+		var checkErr error
+		summary, checkErr = services.RunKeyExpiryCheck()
+		return checkErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "실행", "SSH 키 만료 검사", false, err.Error())
+		return utils.HandleServiceError(c, err, "SSH 키 만료 검사")
+	}
+
+	utils.LogUserAction(userID, "실행", "SSH 키 만료 검사", true,
+		fmt.Sprintf("알림 %d, 교체 %d, 폐기 %d, 실패 %d", summary.Warned, summary.Rotated, summary.Revoked, summary.Failed))
+	return helpers.SuccessResponse(c, summary)
+}
+
+// DeleteKey는 사용자의 특정 SSH 키 쌍을 삭제합니다.
 func DeleteKey(c echo.Context) error {
 	userID, err := utils.UserIDFromToken(c)
 	if err != nil {
 		return helpers.UnauthorizedResponse(c, "Invalid token")
 	}
 
+	keyID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
 	err = utils.LogOperation("SSH 키 삭제", func() error {
-		utils.LogServiceCall("KeyService", "DeleteKeyByUserID", userID)
-		return services.DeleteKeyByUserID(userID)
+		utils.LogServiceCall("KeyService", "DeleteKeyByID", userID, keyID)
+		return services.DeleteKeyByID(userID, keyID)
 	})
 
 	if err != nil {
@@ -94,7 +429,7 @@ func DeleteKey(c echo.Context) error {
 		return helpers.NotFoundResponse(c, err.Error())
 	}
 
-	utils.LogUserAction(userID, "삭제", "SSH 키", true)
-	utils.LogSecurityEvent("SSH 키 삭제", userID, "사용자가 SSH 키를 삭제했습니다", "low")
+	utils.LogUserAction(userID, "삭제", "SSH 키", true, fmt.Sprintf("ID: %d", keyID))
+	utils.LogSecurityEvent("SSH 키 삭제", userID, fmt.Sprintf("사용자가 SSH 키를 삭제했습니다 (ID: %d)", keyID), "low")
 	return helpers.SuccessWithMessageResponse(c, "SSH 키가 성공적으로 삭제되었습니다", nil)
 }
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"fmt"
+	"ssh-key-manager/helpers"
+	"ssh-key-manager/services"
+	"ssh-key-manager/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetNotifications는 현재 사용자의 알림 목록을 조회합니다.
+// unread=true 쿼리 파라미터로 읽지 않은 알림만 볼 수 있습니다.
+func GetNotifications(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	unreadOnly := c.QueryParam("unread") == "true"
+
+	utils.LogServiceCall("NotificationService", "GetNotifications", userID, unreadOnly)
+	notifications, err := services.GetNotifications(userID, unreadOnly)
+	if err != nil {
+		utils.LogUserAction(userID, "조회", "알림", false, err.Error())
+		return helpers.InternalServerErrorResponse(c, "알림 조회 실패")
+	}
+
+	utils.LogUserAction(userID, "조회", "알림", true, fmt.Sprintf("총 %d건", len(notifications)))
+	return helpers.ListResponse(c, notifications, len(notifications))
+}
+
+// MarkNotificationRead는 알림을 읽음으로 표시합니다.
+func MarkNotificationRead(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	notificationID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	utils.LogServiceCall("NotificationService", "MarkNotificationRead", userID, notificationID)
+	if err := services.MarkNotificationRead(userID, notificationID); err != nil {
+		utils.LogUserAction(userID, "읽음 처리", "알림", false, err.Error())
+		return utils.HandleServiceError(c, err, "알림 읽음 처리")
+	}
+
+	utils.LogUserAction(userID, "읽음 처리", "알림", true, fmt.Sprintf("ID: %d", notificationID))
+	return helpers.SuccessWithMessageResponse(c, "알림을 읽음으로 표시했습니다", nil)
+}
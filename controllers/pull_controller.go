@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"ssh-key-manager/helpers"
+	"ssh-key-manager/services"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetHostAuthorizedKeys godoc
+// @Summary Get authorized_keys for a host account (pull mode)
+// @Description Return the authorized_keys content the manager expects for the account, signed with the user CA. Called by the AuthorizedKeysCommand helper with the host token
+// @Tags pull
+// @Produce  plain
+// @Param   host  path   string  true   "Server host"
+// @Param   user  query  string  true   "Account name (sshd %u)"
+// @Param   port  query  int     false  "SSH port (all ports if omitted)"
+// @Param   Authorization  header  string  true  "Bearer <host token>"
+// @Success 200 {string} string "authorized_keys content"
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /hosts/{host}/authorized-keys [get]
+func GetHostAuthorizedKeys(c echo.Context) error {
+	host := c.Param("host")
+	user := c.QueryParam("user")
+
+	port := 0
+	if p := c.QueryParam("port"); p != "" {
+		parsed, err := strconv.Atoi(p)
+		if err != nil || parsed <= 0 || parsed > 65535 {
+			return helpers.BadRequestResponse(c, "유효하지 않은 포트입니다")
+		}
+		port = parsed
+	}
+
+	token := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	pulled, err := services.GetPulledAuthorizedKeys(host, user, port, token, c.RealIP())
+	if err != nil {
+		if errors.Is(err, services.ErrPullUnauthorized) {
+			return helpers.UnauthorizedResponse(c, err.Error())
+		}
+		return utils.HandleServiceError(c, err, "authorized_keys 조회")
+	}
+
+	header := c.Response().Header()
+	header.Set(utils.PulledKeysIssuedAtHeader, strconv.FormatInt(pulled.IssuedAt, 10))
+	header.Set(utils.PulledKeysSignatureHeader, pulled.Signature)
+	header.Set(echo.HeaderCacheControl, "no-store")
+	return c.Blob(http.StatusOK, "text/plain; charset=utf-8", []byte(pulled.Content))
+}
+
+// RegisterPullHost godoc
+// @Summary Register a pull-mode host
+// @Description Register a host that fetches authorized_keys with AuthorizedKeysCommand and issue its host token. Registering again reissues the token (admin only)
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param   request  body   types.PullHostRegisterRequest  true  "Host to register"
+// @Security BearerAuth
+// @Success 201 {object} types.PullHostTokenResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/pull-hosts [post]
+func RegisterPullHost(c echo.Context) error {
+	adminID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	var req types.PullHostRegisterRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	utils.LogServiceCall("PullService", "RegisterPullHost", adminID, req.Host)
+	result, err := services.RegisterPullHost(adminID, req)
+	if err != nil {
+		utils.LogUserAction(adminID, "등록", "pull 호스트", false, err.Error())
+		return utils.HandleServiceError(c, err, "pull 호스트 등록")
+	}
+
+	utils.LogUserAction(adminID, "등록", "pull 호스트", true, fmt.Sprintf("호스트: %s", result.Host))
+	return helpers.CreatedResponse(c, "pull 호스트 토큰이 발급되었습니다. 토큰은 다시 확인할 수 없으니 안전하게 보관하세요", result)
+}
+
+// GetPullHosts godoc
+// @Summary List pull-mode hosts
+// @Description List hosts registered for pull mode with their last fetch time (admin only)
+// @Tags admin
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/pull-hosts [get]
+func GetPullHosts(c echo.Context) error {
+	adminID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	utils.LogServiceCall("PullService", "GetPullHosts", adminID)
+	hosts, err := services.GetPullHosts()
+	if err != nil {
+		utils.LogUserAction(adminID, "조회", "pull 호스트", false, err.Error())
+		return helpers.InternalServerErrorResponse(c, "pull 호스트 목록 조회 실패")
+	}
+
+	utils.LogUserAction(adminID, "조회", "pull 호스트", true, fmt.Sprintf("총 %d대", len(hosts)))
+	return helpers.ListResponse(c, hosts, len(hosts))
+}
+
+// DeletePullHost godoc
+// @Summary Delete a pull-mode host
+// @Description Remove a pull-mode host registration. Its host token stops working immediately (admin only)
+// @Tags admin
+// @Produce  json
+// @Param   id  path  int  true  "Pull host ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/pull-hosts/{id} [delete]
+func DeletePullHost(c echo.Context) error {
+	adminID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	pullHostID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	utils.LogServiceCall("PullService", "DeletePullHost", adminID, pullHostID)
+	if err := services.DeletePullHost(adminID, pullHostID); err != nil {
+		utils.LogUserAction(adminID, "삭제", "pull 호스트", false, err.Error())
+		return utils.HandleServiceError(c, err, "pull 호스트 삭제")
+	}
+
+	utils.LogUserAction(adminID, "삭제", "pull 호스트", true, fmt.Sprintf("ID: %d", pullHostID))
+	return helpers.SuccessWithMessageResponse(c, "pull 호스트가 삭제되었습니다", nil)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"ssh-key-manager/helpers"
 	"ssh-key-manager/services"
@@ -287,6 +288,71 @@ func DeployKeyToServers(c echo.Context) error {
 	return helpers.SuccessWithMessageResponse(c, "키 배포가 완료되었습니다", responseData)
 }
 
+// UndeployKeyFromServers godoc
+// @Summary Remove SSH key from servers
+// @Description Remove the user's SSH key from the authorized_keys of selected servers (revoked and deleted keys included)
+// @Tags servers
+// @Accept  json
+// @Produce  json
+// @Param   undeploy  body   types.KeyUndeployRequest  true  "Servers and key to remove"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /servers/undeploy [post]
+func UndeployKeyFromServers(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	var req types.KeyUndeployRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if len(req.ServerIDs) == 0 {
+		return helpers.BadRequestResponse(c, "키를 제거할 서버를 선택해주세요")
+	}
+
+	var results []types.DeploymentResult
+	err = utils.LogOperation("SSH 키 제거", func() error {
+		utils.LogServiceCall("ServerService", "UndeployKeyFromServers", userID, len(req.ServerIDs))
+		var undeployErr error
+		results, undeployErr = services.UndeployKeyFromServers(userID, req)
+		return undeployErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "제거", "SSH 키", false, err.Error())
+		return utils.HandleServiceError(c, err, "키 제거")
+	}
+
+	summary := types.DeploymentSummary{Total: len(results)}
+	for _, result := range results {
+		if result.Status == "success" {
+			summary.Success++
+		} else {
+			summary.Failed++
+		}
+	}
+
+	responseData := map[string]interface{}{
+		"results": results,
+		"summary": summary,
+	}
+
+	utils.LogUserAction(userID, "제거", "SSH 키", true,
+		fmt.Sprintf("총 %d개 서버 (성공: %d, 실패: %d)", summary.Total, summary.Success, summary.Failed))
+
+	if summary.Failed > 0 {
+		utils.LogSecurityEvent("키 제거 부분 실패", userID,
+			fmt.Sprintf("성공: %d, 실패: %d", summary.Success, summary.Failed), "medium")
+	}
+
+	return helpers.SuccessWithMessageResponse(c, "키 제거가 완료되었습니다", responseData)
+}
+
 // GetDeploymentHistory godoc
 // @Summary Get deployment history
 // @Description Get SSH key deployment history for the current user
@@ -338,40 +404,556 @@ func TestServerConnection(c echo.Context) error {
 		return helpers.BadRequestResponse(c, err.Error())
 	}
 
-	// 서버 정보 조회
-	utils.LogServiceCall("ServerService", "GetServerByID", userID, serverID)
-	server, err := services.GetServerByID(userID, serverID)
+	// 호스트 키 검증 및 연결 테스트 실행
+	var result *types.ConnectionTestResult
+	err = utils.LogOperation("서버 연결 테스트", func() error {
+		utils.LogServiceCall("ServerService", "TestServerConnection", userID, serverID)
+		var testErr error
+		result, testErr = services.TestServerConnection(userID, serverID)
+		return testErr
+	})
+
 	if err != nil {
 		utils.LogUserAction(userID, "테스트", "서버 연결", false, err.Error())
 		return helpers.NotFoundResponse(c, err.Error())
 	}
 
-	// 연결 테스트 실행
-	var result types.ConnectionTestResult
-	err = utils.LogOperation("서버 연결 테스트", func() error {
-		utils.LogServiceCall("ServerService", "TestRemoteServerConnection", userID, server.Host)
-		testErr := utils.TestRemoteServerConnection(server.Host, server.Port, server.Username)
-		if testErr != nil {
-			result = types.ConnectionTestResult{
-				Success: false,
-				Message: "연결 테스트 실패",
-				Error:   testErr.Error(),
-			}
-			return testErr
+	utils.LogUserAction(userID, "테스트", "서버 연결", result.Success, fmt.Sprintf("서버 ID: %d, 호스트 키: %s", serverID, result.HostKeyFingerprint))
+	return helpers.SuccessResponse(c, result)
+}
+
+// GetServerInfo godoc
+// @Summary Get remote server info
+// @Description Verify the pinned host key and read OS, kernel and uptime from the server
+// @Tags servers
+// @Produce  json
+// @Param   id   path      int  true  "Server ID"
+// @Security BearerAuth
+// @Success 200 {object} types.ServerInfoResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /servers/{id}/info [get]
+func GetServerInfo(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	serverID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	var info *types.ServerInfoResult
+	err = utils.LogOperation("서버 정보 조회", func() error {
+		utils.LogServiceCall("ServerService", "GetServerInfo", userID, serverID)
+		var infoErr error
+		info, infoErr = services.GetServerInfo(userID, serverID)
+		return infoErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "조회", "서버 정보", false, err.Error())
+		if errors.Is(err, services.ErrHostKeyChanged) {
+			return helpers.ConflictResponse(c, err.Error())
+		}
+		return utils.HandleServiceError(c, err, "서버 정보 조회")
+	}
+
+	utils.LogUserAction(userID, "조회", "서버 정보", true, fmt.Sprintf("서버 ID: %d", serverID))
+	return helpers.SuccessResponse(c, info)
+}
+
+// GetHostKeyChangedServers godoc
+// @Summary List servers with changed host keys
+// @Description List servers blocked because their host key no longer matches the pinned key (admin only)
+// @Tags admin
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/servers/host-key-changes [get]
+func GetHostKeyChangedServers(c echo.Context) error {
+	adminID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	utils.LogServiceCall("ServerService", "GetHostKeyChangedServers", adminID)
+	servers, err := services.GetHostKeyChangedServers()
+	if err != nil {
+		utils.LogUserAction(adminID, "조회", "호스트 키 변경 서버", false, err.Error())
+		return helpers.InternalServerErrorResponse(c, "호스트 키 변경 서버 조회 실패")
+	}
+
+	utils.LogUserAction(adminID, "조회", "호스트 키 변경 서버", true, fmt.Sprintf("총 %d대", len(servers)))
+	return helpers.ListResponse(c, servers, len(servers))
+}
+
+// AcceptServerHostKey godoc
+// @Summary Accept a changed host key
+// @Description Pin the newly presented host key after review and unblock the server (admin only)
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param   id       path   int                         true  "Server ID"
+// @Param   request  body   types.HostKeyAcceptRequest  true  "Reviewed fingerprint"
+// @Security BearerAuth
+// @Success 200 {object} types.ServerResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/servers/{id}/host-key/accept [post]
+func AcceptServerHostKey(c echo.Context) error {
+	adminID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	serverID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	var req types.HostKeyAcceptRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var server *types.ServerResponse
+	err = utils.LogOperation("호스트 키 변경 승인", func() error {
+		utils.LogServiceCall("ServerService", "AcceptServerHostKey", adminID, serverID, req.Fingerprint)
+		var acceptErr error
+		server, acceptErr = services.AcceptServerHostKey(adminID, serverID, req)
+		return acceptErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(adminID, "승인", "호스트 키 변경", false, err.Error())
+		return utils.HandleServiceError(c, err, "호스트 키 변경 승인")
+	}
+
+	utils.LogUserAction(adminID, "승인", "호스트 키 변경", true, fmt.Sprintf("서버 ID: %d", serverID))
+	utils.LogSecurityEvent("호스트 키 변경 승인", adminID, fmt.Sprintf("서버 ID %d, 새 호스트 키 %s", serverID, server.HostKeyFingerprint), "medium")
+	return helpers.SuccessWithMessageResponse(c, "새 호스트 키가 승인되었습니다", server)
+}
+
+// GetUnapprovedServers godoc
+// @Summary List servers waiting for account approval
+// @Description List user-registered server accounts that an admin has not approved yet (simulated servers excluded, admin only)
+// @Tags admin
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/servers/approvals [get]
+func GetUnapprovedServers(c echo.Context) error {
+	adminID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	utils.LogServiceCall("ServerService", "GetUnapprovedServers", adminID)
+	servers, err := services.GetUnapprovedServers()
+	if err != nil {
+		utils.LogUserAction(adminID, "조회", "미승인 서버", false, err.Error())
+		return helpers.InternalServerErrorResponse(c, "미승인 서버 조회 실패")
+	}
+
+	utils.LogUserAction(adminID, "조회", "미승인 서버", true, fmt.Sprintf("총 %d대", len(servers)))
+	return helpers.ListResponse(c, servers, len(servers))
+}
+
+// ApproveServer godoc
+// @Summary Approve a server account
+// @Description Approve a user-registered server account. Only approved accounts are served to pull hosts, combined across users for the same account, and used as certificate principals (admin only)
+// @Tags admin
+// @Produce  json
+// @Param   id  path  int  true  "Server ID"
+// @Security BearerAuth
+// @Success 200 {object} types.ServerResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/servers/{id}/approval [post]
+func ApproveServer(c echo.Context) error {
+	adminID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	serverID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	utils.LogServiceCall("ServerService", "ApproveServer", adminID, serverID)
+	server, err := services.ApproveServer(adminID, serverID)
+	if err != nil {
+		utils.LogUserAction(adminID, "승인", "서버 계정", false, err.Error())
+		return utils.HandleServiceError(c, err, "서버 계정 승인")
+	}
+
+	utils.LogUserAction(adminID, "승인", "서버 계정", true, fmt.Sprintf("서버 ID: %d", serverID))
+	return helpers.SuccessWithMessageResponse(c, "서버 계정이 승인되었습니다", server)
+}
+
+// RevokeServerApproval godoc
+// @Summary Revoke a server account approval
+// @Description Revoke the approval of a server account (admin only)
+// @Tags admin
+// @Produce  json
+// @Param   id  path  int  true  "Server ID"
+// @Security BearerAuth
+// @Success 200 {object} types.ServerResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/servers/{id}/approval [delete]
+func RevokeServerApproval(c echo.Context) error {
+	adminID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	serverID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	utils.LogServiceCall("ServerService", "RevokeServerApproval", adminID, serverID)
+	server, err := services.RevokeServerApproval(adminID, serverID)
+	if err != nil {
+		utils.LogUserAction(adminID, "승인 취소", "서버 계정", false, err.Error())
+		return utils.HandleServiceError(c, err, "서버 계정 승인 취소")
+	}
+
+	utils.LogUserAction(adminID, "승인 취소", "서버 계정", true, fmt.Sprintf("서버 ID: %d", serverID))
+	return helpers.SuccessWithMessageResponse(c, "서버 계정 승인이 취소되었습니다", server)
+}
+
+// GetServerSnapshots godoc
+// @Summary List authorized_keys snapshots
+// @Description List the authorized_keys snapshots taken before each deploy, removal and rollback on a server (newest first, without content)
+// @Tags servers
+// @Produce  json
+// @Param   id   path      int  true  "Server ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /servers/{id}/snapshots [get]
+func GetServerSnapshots(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	serverID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	var snapshots []types.AuthorizedKeysSnapshotResponse
+	err = utils.LogOperation("authorized_keys 스냅샷 목록 조회", func() error {
+		utils.LogServiceCall("SnapshotService", "GetServerSnapshots", userID, serverID)
+		var listErr error
+		snapshots, listErr = services.GetServerSnapshots(userID, serverID)
+		return listErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "조회", "authorized_keys 스냅샷", false, err.Error())
+		return utils.HandleServiceError(c, err, "스냅샷 목록 조회")
+	}
+
+	return helpers.ListResponse(c, snapshots, len(snapshots))
+}
+
+// GetServerSnapshot godoc
+// @Summary Get an authorized_keys snapshot
+// @Description Get one authorized_keys snapshot of a server including its content
+// @Tags servers
+// @Produce  json
+// @Param   id          path      int  true  "Server ID"
+// @Param   snapshotId  path      int  true  "Snapshot ID"
+// @Security BearerAuth
+// @Success 200 {object} types.AuthorizedKeysSnapshotResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /servers/{id}/snapshots/{snapshotId} [get]
+func GetServerSnapshot(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	serverID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+	snapshotID, err := utils.ParseUintParam(c, "snapshotId")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	var snapshot *types.AuthorizedKeysSnapshotResponse
+	err = utils.LogOperation("authorized_keys 스냅샷 조회", func() error {
+		utils.LogServiceCall("SnapshotService", "GetServerSnapshot", userID, serverID, snapshotID)
+		var getErr error
+		snapshot, getErr = services.GetServerSnapshot(userID, serverID, snapshotID)
+		return getErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "조회", "authorized_keys 스냅샷", false, err.Error())
+		return utils.HandleServiceError(c, err, "스냅샷 조회")
+	}
+
+	return helpers.SuccessResponse(c, snapshot)
+}
+
+// RollbackServerAuthorizedKeys godoc
+// @Summary Roll back authorized_keys to a snapshot
+// @Description Restore a server's authorized_keys to the chosen snapshot. The current content is snapshotted first so the rollback can be undone
+// @Tags servers
+// @Accept  json
+// @Produce  json
+// @Param   id       path   int                          true  "Server ID"
+// @Param   request  body   types.ServerRollbackRequest  true  "Snapshot to restore"
+// @Security BearerAuth
+// @Success 200 {object} types.ServerRollbackResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /servers/{id}/rollback [post]
+func RollbackServerAuthorizedKeys(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	serverID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
+
+	var req types.ServerRollbackRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var result *types.ServerRollbackResult
+	err = utils.LogOperation("authorized_keys 롤백", func() error {
+		utils.LogServiceCall("SnapshotService", "RollbackServerAuthorizedKeys", userID, serverID, req.SnapshotID)
+		var rollbackErr error
+		result, rollbackErr = services.RollbackServerAuthorizedKeys(userID, serverID, req)
+		return rollbackErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "롤백", "authorized_keys", false, err.Error())
+		if errors.Is(err, services.ErrHostKeyChanged) {
+			return helpers.ConflictResponse(c, err.Error())
 		}
+		return utils.HandleServiceError(c, err, "authorized_keys 롤백")
+	}
+
+	utils.LogUserAction(userID, "롤백", "authorized_keys", true, fmt.Sprintf("서버 ID: %d, 스냅샷 ID: %d", serverID, req.SnapshotID))
+	return helpers.SuccessWithMessageResponse(c, "authorized_keys가 스냅샷 내용으로 복원되었습니다", result)
+}
+
+// ReconcileServerAuthorizedKeys godoc
+// @Summary Reconcile authorized_keys to the managed keys
+// @Description Make a managed-exclusively server's authorized_keys exactly match the keys granted to its account, removing unmanaged keys after a snapshot. Use dry_run to preview on any server
+// @Tags servers
+// @Accept  json
+// @Produce  json
+// @Param   id       path   int                           true   "Server ID"
+// @Param   request  body   types.ServerReconcileRequest  false  "Preview only"
+// @Security BearerAuth
+// @Success 200 {object} types.ServerReconcileResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /servers/{id}/reconcile [post]
+func ReconcileServerAuthorizedKeys(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	serverID, err := utils.ParseUintParam(c, "id")
+	if err != nil {
+		return helpers.BadRequestResponse(c, err.Error())
+	}
 
-		result = types.ConnectionTestResult{
-			Success: true,
-			Message: "연결 테스트 성공",
+	var req types.ServerReconcileRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var result *types.ServerReconcileResult
+	err = utils.LogOperation("authorized_keys 정리", func() error {
+		utils.LogServiceCall("ReconcileService", "ReconcileServerAuthorizedKeys", userID, serverID, req.DryRun)
+		var reconcileErr error
+		result, reconcileErr = services.ReconcileServerAuthorizedKeys(userID, serverID, req)
+		return reconcileErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(userID, "정리", "authorized_keys", false, err.Error())
+		if errors.Is(err, services.ErrHostKeyChanged) {
+			return helpers.ConflictResponse(c, err.Error())
 		}
-		return nil
+		return utils.HandleServiceError(c, err, "authorized_keys 정리")
+	}
+
+	utils.LogUserAction(userID, "정리", "authorized_keys", true,
+		fmt.Sprintf("서버 ID: %d, 미리보기: %t, 유지: %d, 추가: %d, 제거: %d", serverID, result.DryRun, result.Kept, len(result.Added), len(result.Removed)))
+
+	message := "authorized_keys가 관리 중인 키와 일치합니다"
+	switch {
+	case result.DryRun && result.Changed:
+		message = "authorized_keys 정리 계획입니다 (변경하지 않음)"
+	case result.Changed:
+		message = "authorized_keys를 관리 중인 키와 일치시켰습니다"
+	}
+	return helpers.SuccessWithMessageResponse(c, message, result)
+}
+
+// GetUserDriftReports godoc
+// @Summary Get drift reports for my servers
+// @Description Get the latest authorized_keys drift report of each server registered by the user
+// @Tags servers
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /servers/drift [get]
+func GetUserDriftReports(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	utils.LogServiceCall("DriftService", "GetUserDriftReports", userID)
+	reports, err := services.GetUserDriftReports(userID)
+	if err != nil {
+		utils.LogUserAction(userID, "조회", "드리프트 검사 결과", false, err.Error())
+		return utils.HandleServiceError(c, err, "드리프트 검사 결과 조회")
+	}
+
+	utils.LogUserAction(userID, "조회", "드리프트 검사 결과", true, fmt.Sprintf("총 %d대", len(reports)))
+	return helpers.ListResponse(c, reports, len(reports))
+}
+
+// RunUserDriftScan godoc
+// @Summary Scan my servers for drift
+// @Description Compare the authorized_keys of the user's servers with the deployment history (all active servers if server_ids is empty)
+// @Tags servers
+// @Accept  json
+// @Produce  json
+// @Param   request  body   types.DriftScanRequest  false  "Servers to scan"
+// @Security BearerAuth
+// @Success 200 {object} types.DriftScanSummary
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /servers/drift/scan [post]
+func RunUserDriftScan(c echo.Context) error {
+	userID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	var req types.DriftScanRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var summary *types.DriftScanSummary
+	err = utils.LogOperation("드리프트 검사", func() error {
+		utils.LogServiceCall("DriftService", "RunUserDriftScan", userID, req.ServerIDs)
+		var scanErr error
+		summary, scanErr = services.RunUserDriftScan(userID, req)
+		return scanErr
 	})
 
 	if err != nil {
-		utils.LogUserAction(userID, "테스트", "서버 연결", false, fmt.Sprintf("%s:%d", server.Host, server.Port))
-	} else {
-		utils.LogUserAction(userID, "테스트", "서버 연결", true, fmt.Sprintf("%s:%d", server.Host, server.Port))
+		utils.LogUserAction(userID, "실행", "드리프트 검사", false, err.Error())
+		return utils.HandleServiceError(c, err, "드리프트 검사")
 	}
 
-	return helpers.SuccessResponse(c, result)
+	utils.LogUserAction(userID, "실행", "드리프트 검사", true,
+		fmt.Sprintf("총 %d대 (일치: %d, 드리프트: %d, 실패: %d)", summary.Total, summary.Clean, summary.Drift, summary.Failed))
+	return helpers.SuccessResponse(c, summary)
+}
+
+// GetDriftReports godoc
+// @Summary Get drift reports for all servers
+// @Description Get the latest authorized_keys drift report of every server (admin only)
+// @Tags admin
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/servers/drift [get]
+func GetDriftReports(c echo.Context) error {
+	adminID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	utils.LogServiceCall("DriftService", "GetDriftReports", adminID)
+	reports, err := services.GetDriftReports()
+	if err != nil {
+		utils.LogUserAction(adminID, "조회", "전체 드리프트 검사 결과", false, err.Error())
+		return helpers.InternalServerErrorResponse(c, "드리프트 검사 결과 조회 실패")
+	}
+
+	utils.LogUserAction(adminID, "조회", "전체 드리프트 검사 결과", true, fmt.Sprintf("총 %d대", len(reports)))
+	return helpers.ListResponse(c, reports, len(reports))
+}
+
+// RunDriftScan godoc
+// @Summary Scan servers for drift
+// @Description Compare the authorized_keys of servers with the deployment history now (admin only, all active servers if server_ids is empty)
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param   request  body   types.DriftScanRequest  false  "Servers to scan"
+// @Security BearerAuth
+// @Success 200 {object} types.DriftScanSummary
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/servers/drift/scan [post]
+func RunDriftScan(c echo.Context) error {
+	adminID, err := utils.UserIDFromToken(c)
+	if err != nil {
+		return helpers.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	var req types.DriftScanRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var summary *types.DriftScanSummary
+	err = utils.LogOperation("전체 드리프트 검사", func() error {
+		utils.LogServiceCall("DriftService", "RunDriftScan", adminID, req.ServerIDs)
+		var scanErr error
+		summary, scanErr = services.RunDriftScan(req)
+		return scanErr
+	})
+
+	if err != nil {
+		utils.LogUserAction(adminID, "실행", "전체 드리프트 검사", false, err.Error())
+		return utils.HandleServiceError(c, err, "드리프트 검사")
+	}
+
+	utils.LogUserAction(adminID, "실행", "전체 드리프트 검사", true,
+		fmt.Sprintf("총 %d대 (일치: %d, 드리프트: %d, 실패: %d)", summary.Total, summary.Clean, summary.Drift, summary.Failed))
+	if summary.Drift > 0 {
+		utils.LogSecurityEvent("authorized_keys 드리프트 감지", adminID,
+			fmt.Sprintf("드리프트 서버 %d대", summary.Drift), "medium")
+	}
+	return helpers.SuccessResponse(c, summary)
 }
@@ -70,6 +70,7 @@ func GetUserDetail(c echo.Context) error {
 		// 다른 사용자 정보 조회 시 민감한 정보 제거 (비관리자)
 		userDetail.HasSSHKey = userDetail.SSHKey != nil
 		userDetail.SSHKey = nil // 다른 사용자의 키 정보는 숨김
+		userDetail.SSHKeys = nil
 	}
 
 	return helpers.SuccessResponse(c, userDetail)
@@ -5,6 +5,8 @@ import (
 	"log"
 	"ssh-key-manager/config"
 	"ssh-key-manager/models"
+	"ssh-key-manager/utils"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/postgres"
@@ -35,7 +37,8 @@ func connectDatabase(cfg *config.Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// 고유 제약 위반 등을 gorm.ErrDuplicatedKey 같은 공통 오류로 변환
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, err
 	}
@@ -53,6 +56,11 @@ func (m *MigrationManager) RunMigrations() error {
 		return fmt.Errorf("테이블 마이그레이션 실패: %w", err)
 	}
 
+	// 기존 SSH 키 핑거프린트 백필
+	if err := m.backfillKeyFingerprints(); err != nil {
+		log.Printf("⚠️ 핑거프린트 백필 실패 (계속 진행): %v", err)
+	}
+
 	// 인덱스 생성
 	if err := m.createIndexes(); err != nil {
 		log.Printf("⚠️ 인덱스 생성 실패 (계속 진행): %v", err)
@@ -79,8 +87,16 @@ func (m *MigrationManager) migrateTables() error {
 		&models.SSHKey{},
 		&models.Server{},
 		&models.ServerKeyDeployment{},
+		&models.AuthorizedKeysSnapshot{},
+		&models.ServerDriftReport{},
+		&models.PullHost{},
+		&models.DeploymentJob{},
 		&models.Department{},
 		&models.DepartmentHistory{},
+		&models.KeyRotation{},
+		&models.Notification{},
+		&models.CertificateAuthority{},
+		&models.SSHCertificate{},
 	}
 
 	for _, model := range models {
@@ -93,40 +109,95 @@ func (m *MigrationManager) migrateTables() error {
 	return nil
 }
 
-// createIndexes는 성능 향상을 위한 인덱스를 생성합니다.
+// backfillKeyFingerprints는 핑거프린트가 비어있는 SSH 키의 SHA256 / MD5 핑거프린트를 채웁니다.
+// 삭제된 키도 로그 추적을 위해 함께 채웁니다.
+func (m *MigrationManager) backfillKeyFingerprints() error {
+	var keys []models.SSHKey
+	updated := 0
+
+	err := m.DB.Unscoped().Select("id", "public_key").
+		Where("fingerprint_sha256 IS NULL OR fingerprint_sha256 = ''").
+		FindInBatches(&keys, 100, func(tx *gorm.DB, batch int) error {
+			for _, key := range keys {
+				fingerprints, err := utils.ComputeFingerprints(key.PublicKey)
+				if err != nil {
+					log.Printf("⚠️ 핑거프린트 계산 실패 (키 ID: %d): %v", key.ID, err)
+					continue
+				}
+
+				// updated_at은 변경하지 않음
+				if err := m.DB.Unscoped().Model(&models.SSHKey{}).Where("id = ?", key.ID).UpdateColumns(map[string]interface{}{
+					"fingerprint_sha256": fingerprints.SHA256,
+					"fingerprint_md5":    fingerprints.MD5,
+				}).Error; err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	if updated > 0 {
+		log.Printf("   - SSH 키 핑거프린트 백필 완료: %d개", updated)
+	}
+	return nil
+}
+
+// createIndexes는 성능 향상을 위한 인덱스와 고유 인덱스를 생성합니다.
 func (m *MigrationManager) createIndexes() error {
 	indexes := []struct {
 		table   string
 		index   string
 		columns []string
+		unique  bool
+		where   string // 부분 인덱스 조건 (비어 있으면 전체 행)
 	}{
-		{"users", "idx_users_username", []string{"username"}},
-		{"users", "idx_users_role", []string{"role"}},
-		{"users", "idx_users_department_id", []string{"department_id"}},
-		{"ssh_keys", "idx_ssh_keys_user_id", []string{"user_id"}},
-		{"servers", "idx_servers_user_id", []string{"user_id"}},
-		{"servers", "idx_servers_host_port", []string{"host", "port"}},
-		{"server_key_deployments", "idx_deployments_server_id", []string{"server_id"}},
-		{"server_key_deployments", "idx_deployments_user_id", []string{"user_id"}},
-		{"departments", "idx_departments_code", []string{"code"}},
-		{"departments", "idx_departments_parent_id", []string{"parent_id"}},
-		{"department_histories", "idx_dept_history_user_id", []string{"user_id"}},
-		{"department_histories", "idx_dept_history_change_date", []string{"change_date"}},
+		{"users", "idx_users_username", []string{"username"}, false, ""},
+		{"users", "idx_users_role", []string{"role"}, false, ""},
+		{"users", "idx_users_department_id", []string{"department_id"}, false, ""},
+		{"ssh_keys", "idx_ssh_keys_user_id", []string{"user_id"}, false, ""},
+		// 키 이름은 사용자별로 고유 (삭제된 키 제외). 조회 후 생성 사이의 동시 요청도 DB에서 막음
+		{"ssh_keys", "uidx_ssh_keys_user_name", []string{"user_id", "name"}, true, "deleted_at IS NULL"},
+		{"servers", "idx_servers_user_id", []string{"user_id"}, false, ""},
+		{"servers", "idx_servers_host_port", []string{"host", "port"}, false, ""},
+		{"server_key_deployments", "idx_deployments_server_id", []string{"server_id"}, false, ""},
+		{"server_key_deployments", "idx_deployments_user_id", []string{"user_id"}, false, ""},
+		{"departments", "idx_departments_code", []string{"code"}, false, ""},
+		{"departments", "idx_departments_parent_id", []string{"parent_id"}, false, ""},
+		{"department_histories", "idx_dept_history_user_id", []string{"user_id"}, false, ""},
+		{"department_histories", "idx_dept_history_change_date", []string{"change_date"}, false, ""},
 	}
 
 	for _, idx := range indexes {
-		if err := m.createIndexIfNotExists(idx.table, idx.index, idx.columns); err != nil {
+		if err := m.createIndexIfNotExists(idx.table, idx.index, idx.columns, idx.unique, idx.where); err != nil {
 			log.Printf("⚠️ 인덱스 생성 실패 %s.%s: %v", idx.table, idx.index, err)
+			if idx.unique {
+				log.Printf("⚠️ %s 테이블에 중복된 값(%s)이 있으면 정리한 뒤 다시 시작해야 고유 인덱스가 생성됩니다",
+					idx.table, strings.Join(idx.columns, ", "))
+			}
 		} else {
 			log.Printf("   - 인덱스 생성: %s.%s", idx.table, idx.index)
 		}
 	}
 
+	// 고유 인덱스로 대체된 이전 인덱스 제거 (고유 인덱스가 생성된 경우에만)
+	var uniqueExists bool
+	if err := m.DB.Raw(`SELECT EXISTS (SELECT 1 FROM pg_indexes WHERE tablename = ? AND indexname = ?)`,
+		"ssh_keys", "uidx_ssh_keys_user_name").Scan(&uniqueExists).Error; err == nil && uniqueExists {
+		if err := m.DB.Exec("DROP INDEX IF EXISTS idx_ssh_keys_user_name").Error; err != nil {
+			log.Printf("⚠️ 이전 인덱스 제거 실패 ssh_keys.idx_ssh_keys_user_name: %v", err)
+		}
+	}
+
 	return nil
 }
 
 // createIndexIfNotExists는 인덱스가 존재하지 않으면 생성합니다.
-func (m *MigrationManager) createIndexIfNotExists(table, indexName string, columns []string) error {
+// unique이면 고유 인덱스로, where가 있으면 해당 조건의 부분 인덱스로 생성합니다.
+func (m *MigrationManager) createIndexIfNotExists(table, indexName string, columns []string, unique bool, where string) error {
 	// PostgreSQL에서 인덱스 존재 여부 확인
 	var exists bool
 	query := `
@@ -144,17 +215,20 @@ func (m *MigrationManager) createIndexIfNotExists(table, indexName string, colum
 	}
 
 	// 인덱스 생성
-	columnsStr := fmt.Sprintf("(%s)", fmt.Sprintf("\"%s\"", columns[0]))
-	if len(columns) > 1 {
-		var quoted []string
-		for _, col := range columns {
-			quoted = append(quoted, fmt.Sprintf("\"%s\"", col))
-		}
-		columnsStr = fmt.Sprintf("(%s)", fmt.Sprintf("%v", quoted))
+	quoted := make([]string, 0, len(columns))
+	for _, col := range columns {
+		quoted = append(quoted, fmt.Sprintf("\"%s\"", col))
 	}
 
-	createSQL := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s %s",
-		indexName, table, columnsStr)
+	kind := "INDEX"
+	if unique {
+		kind = "UNIQUE INDEX"
+	}
+	createSQL := fmt.Sprintf("CREATE %s IF NOT EXISTS %s ON %s (%s)",
+		kind, indexName, table, strings.Join(quoted, ", "))
+	if where != "" {
+		createSQL += " WHERE " + where
+	}
 
 	return m.DB.Exec(createSQL).Error
 }
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 인증 기관(CA) 종류입니다.
+const (
+	CATypeUser = "user" // 사용자 공개키 서명 (sshd TrustedUserCAKeys)
+	CATypeHost = "host" // 서버 호스트 키 서명 (known_hosts @cert-authority)
+)
+
+// CertificateAuthority는 SSH 인증서 서명용 CA 키를 저장하는 모델입니다.
+// 개인키는 SSHKey와 동일하게 마스터 키로 래핑된 데이터 키로 암호화되어 저장됩니다.
+type CertificateAuthority struct {
+	gorm.Model
+	Type        string `gorm:"not null;size:10;index"` // CA 종류 (user, host)
+	Algorithm   string `gorm:"not null;size:20"`       // 알고리즘 (ED25519)
+	PublicKey   string `gorm:"type:text;not null"`     // CA 공개키 (authorized_keys 형식)
+	PrivateKey  string `gorm:"type:text;not null"`     // CA 개인키 (OpenSSH 형식, 암호화)
+	Fingerprint string `gorm:"size:64;index"`          // CA 공개키 SHA256 핑거프린트
+	Active      bool   `gorm:"not null;default:true"`  // 서명에 사용 중인 CA 여부
+
+	WrappedDataKey string `gorm:"type:text" json:"-"`     // 마스터 키로 래핑된 데이터 키 (base64)
+	MasterKeyID    string `gorm:"size:64;index" json:"-"` // 데이터 키를 래핑한 마스터 키 ID
+}
+
+// SSHCertificate는 발급한 SSH 인증서 기록입니다 (감사 및 폐기용).
+type SSHCertificate struct {
+	gorm.Model
+	CAID        uint       `gorm:"not null;index"`       // 서명한 CA ID
+	CertType    string     `gorm:"not null;size:10"`     // 인증서 종류 (user, host)
+	Serial      uint64     `gorm:"not null;uniqueIndex"` // 인증서 일련번호
+	KeyID       string     `gorm:"not null;size:255"`    // 인증서 Key ID (sshd 로그에 기록됨)
+	UserID      uint       `gorm:"not null;index"`       // 발급 요청 사용자 ID
+	SSHKeyID    *uint      `gorm:"index"`                // 서명한 사용자 키 ID (사용자 인증서)
+	ServerID    *uint      `gorm:"index"`                // 서명한 서버 ID (호스트 인증서)
+	Principals  string     `gorm:"type:text;not null"`   // 쉼표로 구분된 principal 목록
+	Fingerprint string     `gorm:"size:64;index"`        // 서명된 공개키 SHA256 핑거프린트
+	ValidAfter  time.Time  `gorm:"not null"`             // 유효 시작 시각
+	ValidBefore time.Time  `gorm:"not null;index"`       // 유효 종료 시각
+	Certificate string     `gorm:"type:text;not null"`   // 인증서 (*-cert.pub 형식)
+	RevokedAt   *time.Time `gorm:"index"`                // 폐기 시각
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
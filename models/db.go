@@ -45,42 +45,167 @@ type User struct {
 	ChangedHistories    []DepartmentHistory `gorm:"foreignKey:ChangedBy"`
 }
 
+// SSH 키 상태 값입니다.
+const (
+	KeyStatusActive  = "active"  // 사용 중 (배포 가능)
+	KeyStatusRevoked = "revoked" // 폐기됨 (배포 불가)
+)
+
+// SSH 키 출처 값입니다.
+const (
+	KeySourceGenerated      = "generated"       // 서버에서 생성
+	KeySourceImported       = "imported"        // 개인키를 가져옴
+	KeySourceImportedPublic = "imported-public" // 공개키만 가져옴 (개인키는 사용자/하드웨어 보관)
+)
+
 // SSHKey는 SSH 키 정보를 저장하는 모델입니다.
+// 한 사용자가 이름이 다른 여러 개의 키를 보유할 수 있습니다.
 type SSHKey struct {
 	gorm.Model
-	UserID     uint   `gorm:"not null;index"`                                // 인덱스 추가로 성능 향상
-	Algorithm  string `gorm:"not null;default:'RSA'"`                        // 알고리즘 (RSA, ECDSA 등)
-	Bits       int    `gorm:"not null;default:4096"`                         // 키 크기
-	PrivateKey string `gorm:"type:text;not null"`                            // PEM 형식 개인키
-	PublicKey  string `gorm:"type:text;not null"`                            // SSH 공개키 (authorized_keys 형식)
-	PEM        string `gorm:"type:text;not null"`                            // PEM 형식 개인키 (PrivateKey와 동일)
-	PPK        string `gorm:"type:text;not null"`                            // PuTTY 형식 개인키
-	User       User   `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // 외래키 제약조건 추가
+	UserID              uint   `gorm:"not null;index"`                      // 인덱스 추가로 성능 향상
+	Name                string `gorm:"not null;size:100;default:'default'"` // 키 이름 (사용자별로 고유, 예: laptop, ci-runner)
+	Comment             string `gorm:"size:255"`                            // 공개키 코멘트
+	Status              string `gorm:"not null;default:'active';index"`     // 키 상태 (active, revoked)
+	Algorithm           string `gorm:"not null;default:'RSA'"`              // 알고리즘 (RSA, ED25519, ECDSA-P256/P384/P521)
+	Bits                int    `gorm:"not null;default:4096"`               // 키 크기
+	PrivateKey          string `gorm:"type:text;not null"`                  // OpenSSH 형식 개인키
+	PublicKey           string `gorm:"type:text;not null"`                  // SSH 공개키 (authorized_keys 형식)
+	PEM                 string `gorm:"type:text;not null"`                  // PEM 형식 개인키 (RSA: PKCS#1, ECDSA: SEC1, Ed25519: PKCS#8)
+	PPK                 string `gorm:"type:text;not null"`                  // PuTTY 형식 개인키
+	PassphraseProtected bool   `gorm:"not null;default:false"`              // 개인키(OpenSSH, PPK)가 사용자 패스프레이즈로 암호화되어 있는지 여부
+	Source              string `gorm:"not null;default:'generated'"`        // 키 출처 (generated, imported, imported-public)
+	FingerprintSHA256   string `gorm:"size:64;index"`                       // SHA256:base64 핑거프린트 (ssh-keygen -l, sshd 로그 형식)
+	FingerprintMD5      string `gorm:"size:64;index"`                       // MD5 핑거프린트 (aa:bb:..., 레거시 도구 호환)
+
+	// 만료 / 교체 정보
+	ExpiresAt        *time.Time `gorm:"index"` // 만료 시각 (NULL이면 만료 없음)
+	ExpiryNotifiedAt *time.Time `gorm:""`      // 만료 예정 알림을 보낸 시각 (중복 알림 방지)
+	ReplacedByID     *uint      `gorm:"index"` // 교체로 생성된 새 키 ID
+
+	// 개인키 암호화 정보 (PrivateKey, PEM, PPK는 데이터 키로 암호화되어 저장됨)
+	WrappedDataKey string `gorm:"type:text" json:"-"`     // 마스터 키로 래핑된 데이터 키 (base64)
+	MasterKeyID    string `gorm:"size:64;index" json:"-"` // 데이터 키를 래핑한 마스터 키 ID
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // 외래키 제약조건 추가
 }
 
 // Server는 원격 서버 정보를 저장하는 모델입니다.
 type Server struct {
 	gorm.Model
-	UserID      uint   `gorm:"not null;index"`                                // 서버를 등록한 사용자 ID
-	Name        string `gorm:"not null"`                                      // 서버 이름 (별칭)
-	Host        string `gorm:"not null"`                                      // 서버 IP 또는 호스트명
-	Port        int    `gorm:"not null;default:22"`                           // SSH 포트 (기본: 22)
-	Username    string `gorm:"not null"`                                      // SSH 접속 계정
-	Description string `gorm:"type:text"`                                     // 서버 설명
-	Status      string `gorm:"not null;default:'active'"`                     // 서버 상태 (active, inactive)
-	User        User   `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // 외래키 제약조건
+	UserID      uint   `gorm:"not null;index"`            // 서버를 등록한 사용자 ID
+	Name        string `gorm:"not null"`                  // 서버 이름 (별칭)
+	Host        string `gorm:"not null"`                  // 서버 IP 또는 호스트명
+	Port        int    `gorm:"not null;default:22"`       // SSH 포트 (기본: 22)
+	Username    string `gorm:"not null"`                  // SSH 접속 계정
+	Description string `gorm:"type:text"`                 // 서버 설명
+	Status      string `gorm:"not null;default:'active'"` // 서버 상태 (active, inactive, host_key_changed)
+
+	// 키 배포 방식 (ssh: 원격 셸, sftp: SFTP 파일 수정, local: 이 서버의 파일, simulated: 메모리 모의 배포, pull: 서버가 가져감)
+	DeployMethod string `gorm:"not null;size:20;default:'ssh'"`
+
+	// 독점 관리 모드 (authorized_keys를 관리 중인 키와 정확히 일치시키고, 그 외 키는 백업 후 제거)
+	ManagedExclusively bool `gorm:"not null;default:false"`
+
+	// 관리자 계정 승인 (승인된 서버 계정만 pull 배포, 계정 단위 키 합산, 인증서 principal에 사용)
+	// 호스트, 포트, 계정, 배포 방식이 바뀌면 승인이 해제됩니다.
+	ApprovedBy *uint      `gorm:"index"` // 승인한 관리자 ID
+	ApprovedAt *time.Time // 승인 시각
+
+	// 호스트 키 고정 (TOFU: 연결 테스트에서 처음 기록하고 이후 접속마다 검증, 기록 전에는 접속하지 않음)
+	HostKeyType               string     `gorm:"size:50"` // 기록된 호스트 키 종류 (ssh-ed25519 등)
+	HostKeyFingerprint        string     `gorm:"size:64"` // 기록된 호스트 키 SHA256 핑거프린트
+	HostKeyPinnedAt           *time.Time // 호스트 키 기록 시각
+	PendingHostKeyType        string     `gorm:"size:50"` // 변경 감지된 호스트 키 종류 (관리자 승인 대기)
+	PendingHostKeyFingerprint string     `gorm:"size:64"` // 변경 감지된 호스트 키 SHA256 핑거프린트
+	HostKeyChangedAt          *time.Time `gorm:"index"`   // 호스트 키 변경 감지 시각
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // 외래키 제약조건
 }
 
+// 서버 상태 값입니다.
+const (
+	ServerStatusActive         = "active"           // 사용 중
+	ServerStatusInactive       = "inactive"         // 사용 중지
+	ServerStatusHostKeyChanged = "host_key_changed" // 호스트 키 변경 감지 (관리자 승인 전까지 접속 차단)
+)
+
+// 서버 키 배포 방식 값입니다.
+const (
+	DeployMethodSSH       = "ssh"       // 원격 셸 명령으로 authorized_keys 수정 (기본)
+	DeployMethodSFTP      = "sftp"      // SFTP로 authorized_keys 파일 수정 (셸이 제한된 서버)
+	DeployMethodLocal     = "local"     // 관리 도구가 실행 중인 서버의 authorized_keys 수정
+	DeployMethodSimulated = "simulated" // 실제 접속 없이 메모리에서 모의 배포 (테스트, 모의 실행)
+	DeployMethodPull      = "pull"      // 서버가 AuthorizedKeysCommand로 키를 가져감 (원격 접속 없음)
+)
+
 // ServerKeyDeployment는 서버별 키 배포 기록을 저장하는 모델입니다.
 type ServerKeyDeployment struct {
 	gorm.Model
 	ServerID   uint            `gorm:"not null;index"`                                  // 서버 ID
 	SSHKeyID   uint            `gorm:"not null;index"`                                  // SSH 키 ID
 	UserID     uint            `gorm:"not null;index"`                                  // 사용자 ID
+	Action     string          `gorm:"not null;size:20;default:'deploy'"`               // 작업 종류 (deploy, remove)
 	Status     string          `gorm:"not null;default:'pending'"`                      // 배포 상태 (pending, success, failed)
 	DeployedAt *gorm.DeletedAt `gorm:"index"`                                           // 배포 완료 시간
 	ErrorMsg   string          `gorm:"type:text"`                                       // 오류 메시지 (실패시)
+	SnapshotID *uint           `gorm:"index"`                                           // 작업 직전 authorized_keys 스냅샷 ID
 	Server     Server          `gorm:"foreignKey:ServerID;constraint:OnDelete:CASCADE"` // 외래키 제약조건
 	SSHKey     SSHKey          `gorm:"foreignKey:SSHKeyID;constraint:OnDelete:CASCADE"` // 외래키 제약조건
 	User       User            `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`   // 외래키 제약조건
 }
+
+// 배포 기록 작업 종류입니다.
+const (
+	DeploymentActionDeploy = "deploy" // authorized_keys에 키 추가
+	DeploymentActionRemove = "remove" // authorized_keys에서 키 제거
+)
+
+// AuthorizedKeysSnapshot은 키 배포/제거/롤백 직전의 서버 authorized_keys 내용을 저장하는 모델입니다.
+// 잘못된 변경으로 접속이 막히기 전 상태로 되돌릴 수 있도록 서버에도 같은 내용의 백업 파일을 남깁니다.
+type AuthorizedKeysSnapshot struct {
+	gorm.Model
+	ServerID   uint   `gorm:"not null;index"`                                  // 서버 ID
+	UserID     uint   `gorm:"not null;index"`                                  // 작업한 사용자 ID
+	Action     string `gorm:"not null;size:20"`                                // 스냅샷을 만든 작업 (deploy, remove, rollback, reconcile)
+	Content    string `gorm:"type:text"`                                       // authorized_keys 내용 (파일이 없었으면 빈 값)
+	Checksum   string `gorm:"size:64"`                                         // 내용의 SHA256 (hex)
+	KeyCount   int    `gorm:"not null;default:0"`                              // 키 줄 수 (주석, 빈 줄 제외)
+	RemotePath string `gorm:"size:255"`                                        // 서버에 남긴 백업 파일 경로 (원격은 홈 디렉토리 기준)
+	Server     Server `gorm:"foreignKey:ServerID;constraint:OnDelete:CASCADE"` // 외래키 제약조건
+}
+
+// 스냅샷 작업 종류입니다 (배포/제거는 DeploymentAction 값 사용).
+const (
+	SnapshotActionRollback  = "rollback"  // 롤백 직전 상태 (롤백을 되돌릴 때 사용)
+	SnapshotActionReconcile = "reconcile" // 독점 관리 모드 정리 직전 상태
+)
+
+// 키 교체 사유 값입니다.
+const (
+	RotationReasonExpired = "expired" // 만료로 인한 자동 교체
+	RotationReasonManual  = "manual"  // 사용자 요청
+)
+
+// 키 교체 상태 값입니다.
+const (
+	RotationStatusCompleted = "completed" // 새 키 배포와 기존 키 제거 모두 성공
+	RotationStatusPartial   = "partial"   // 일부 서버에서 배포 또는 제거 실패 (기존 키 활성 유지, 스케줄러가 재시도)
+	RotationStatusFailed    = "failed"    // 새 키를 만들지 못함
+	RotationStatusRevoked   = "revoked"   // 새 키 없이 기존 키만 폐기 (공개키만 등록된 키 등)
+)
+
+// KeyRotation은 키 교체(만료 처리) 기록을 저장하는 모델입니다.
+// 서버별 배포/제거 결과는 ServerKeyDeployment에 함께 기록됩니다.
+type KeyRotation struct {
+	gorm.Model
+	UserID        uint   `gorm:"not null;index"`     // 키 소유자 ID
+	OldKeyID      uint   `gorm:"not null;index"`     // 교체된 키 ID
+	NewKeyID      *uint  `gorm:"index"`              // 새로 생성된 키 ID (폐기만 한 경우 NULL)
+	Reason        string `gorm:"not null;size:20"`   // 교체 사유 (expired, manual)
+	Status        string `gorm:"not null;size:20"`   // 결과 (completed, partial, failed, revoked)
+	DeployedCount int    `gorm:"not null;default:0"` // 새 키 배포 성공 서버 수
+	RemovedCount  int    `gorm:"not null;default:0"` // 기존 키 제거 성공 서버 수
+	FailedCount   int    `gorm:"not null;default:0"` // 배포 또는 제거에 실패한 서버 수
+	ErrorMsg      string `gorm:"type:text"`          // 오류 요약
+	User          User   `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
@@ -16,6 +16,9 @@ type Department struct {
 	Level       int    `gorm:"not null;default:1"`    // 부서 레벨 (1: 최상위, 2: 2단계 등)
 	IsActive    bool   `gorm:"not null;default:true"` // 활성 상태
 
+	// 키 정책
+	MaxKeyAgeDays int `gorm:"not null;default:0"` // 최대 키 사용 기간 (일, 0이면 상위 부서 또는 조직 기본값 사용)
+
 	// 관계 정의
 	Parent   *Department  `gorm:"foreignKey:ParentID"`     // 상위 부서
 	Children []Department `gorm:"foreignKey:ParentID"`     // 하위 부서들
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 드리프트 검사 결과 상태입니다.
+const (
+	DriftStatusClean = "clean" // 배포 기록과 일치
+	DriftStatusDrift = "drift" // 배포 기록과 다름
+	DriftStatusError = "error" // 검사 실패 (접속 불가 등)
+)
+
+// ServerDriftReport는 서버의 authorized_keys를 배포 기록과 비교한 드리프트 검사 결과입니다.
+// 검사할 때마다 새 행을 추가하며, 서버별 최신 행이 현재 상태입니다.
+type ServerDriftReport struct {
+	gorm.Model
+	ServerID     uint      `gorm:"not null;index"`     // 서버 ID
+	ScannedAt    time.Time `gorm:"not null;index"`     // 검사 시각
+	Status       string    `gorm:"not null;size:20"`   // 결과 (clean, drift, error)
+	MissingCount int       `gorm:"not null;default:0"` // 배포되어 있어야 하지만 없는 키 수
+	StaleCount   int       `gorm:"not null;default:0"` // 제거되었어야 하지만 남아 있는 관리 키 수
+	ForeignCount int       `gorm:"not null;default:0"` // 배포 기록에 없는 외부 키 수
+	Details      string    `gorm:"type:text"`          // 항목별 결과 (JSON)
+	ErrorMsg     string    `gorm:"type:text"`          // 오류 메시지 (검사 실패시)
+
+	Server Server `gorm:"foreignKey:ServerID;constraint:OnDelete:CASCADE"`
+}
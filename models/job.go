@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 비동기 배포 작업 종류입니다.
+const (
+	DeploymentJobTypeDeploy   = "deploy_key"   // 선택한 서버에 키 배포
+	DeploymentJobTypeUndeploy = "undeploy_key" // 선택한 서버에서 키 제거
+)
+
+// 비동기 배포 작업 상태입니다 (types.ProgressResponse 상태 값과 같음).
+const (
+	JobStatusPending   = "PENDING"   // 실행 대기
+	JobStatusRunning   = "RUNNING"   // 실행 중
+	JobStatusCompleted = "COMPLETED" // 모든 서버 처리 완료 (서버별 실패 포함)
+	JobStatusFailed    = "FAILED"    // 작업 자체 실패 (키/서버 조회 실패 등)
+	JobStatusCancelled = "CANCELLED" // 사용자 취소
+)
+
+// DeploymentJob은 백그라운드에서 실행되는 키 배포(제거) 작업입니다.
+// 서버 하나를 처리할 때마다 결과를 저장하므로, 재시작 후에는 결과가 없는 서버부터 이어서 실행합니다.
+type DeploymentJob struct {
+	gorm.Model
+	UserID          uint       `gorm:"not null;index"`         // 작업 요청 사용자 ID
+	Type            string     `gorm:"not null;size:30"`       // 작업 종류 (deploy_key, undeploy_key)
+	Status          string     `gorm:"not null;size:20;index"` // 작업 상태
+	Priority        int        `gorm:"not null;default:0"`     // 우선순위 (클수록 먼저 실행)
+	Request         string     `gorm:"type:text;not null"`     // 작업 요청 (JSON)
+	Total           int        `gorm:"not null;default:0"`     // 대상 서버 수
+	Done            int        `gorm:"not null;default:0"`     // 처리한 서버 수
+	Succeeded       int        `gorm:"not null;default:0"`     // 성공한 서버 수
+	Failed          int        `gorm:"not null;default:0"`     // 실패한 서버 수
+	Results         string     `gorm:"type:text"`              // 서버별 결과 (JSON)
+	ErrorMsg        string     `gorm:"type:text"`              // 작업 실패 사유
+	CancelRequested bool       `gorm:"not null;default:false"` // 취소 요청 여부
+	StartedAt       *time.Time `gorm:""`                       // 실행 시작 시간
+	CompletedAt     *time.Time `gorm:""`                       // 종료 시간 (완료, 실패, 취소)
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // 외래키 제약조건
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 알림 종류입니다.
+const (
+	NotificationTypeInfo    = "INFO"
+	NotificationTypeWarning = "WARNING"
+	NotificationTypeError   = "ERROR"
+	NotificationTypeSuccess = "SUCCESS"
+)
+
+// Notification은 사용자에게 전달할 알림을 저장하는 모델입니다.
+type Notification struct {
+	gorm.Model
+	UserID  uint       `gorm:"not null;index"`   // 수신 사용자 ID
+	Type    string     `gorm:"not null;size:20"` // 알림 종류 (INFO, WARNING, ERROR, SUCCESS)
+	Title   string     `gorm:"not null;size:200"`
+	Message string     `gorm:"type:text"`
+	ReadAt  *time.Time `gorm:"index"` // 읽은 시각 (NULL이면 읽지 않음)
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PullHost는 sshd AuthorizedKeysCommand로 authorized_keys를 가져가는(pull 방식) 호스트입니다.
+// 호스트 토큰은 발급할 때 한 번만 보여주고 SHA256 해시만 저장합니다.
+type PullHost struct {
+	gorm.Model
+	Host         string     `gorm:"not null;size:255;uniqueIndex"` // 서버 호스트 (Server.Host와 같은 값)
+	Description  string     `gorm:"type:text"`                     // 설명
+	TokenHash    string     `gorm:"not null;size:64;uniqueIndex"`  // 호스트 토큰 SHA256 (hex)
+	TokenPrefix  string     `gorm:"not null;size:16"`              // 토큰 앞부분 (식별용)
+	CreatedBy    uint       `gorm:"not null;index"`                // 등록한 관리자 ID
+	LastPulledAt *time.Time // 마지막으로 가져간 시각
+	LastPullAddr string     `gorm:"size:64"` // 마지막으로 가져간 요청 주소
+}
@@ -436,7 +436,9 @@ const UI = {
             console.log('🔄 네비게이션 업데이트:', AppState.currentUser);
             this.updateNavigation(); // 로그인 시 네비게이션 업데이트
             this.showView('keys'); // 키 뷰로 가면서 자동 로드됨
+            DeploymentEvents.connect(); // 배포 진행 상황 알림
         } else {
+            DeploymentEvents.disconnect();
             this.showLogin();
         }
     },
@@ -630,6 +632,13 @@ const UI = {
 // 키 관리
 // ===============================
 const KeyManager = {
+    currentKeyId: null,
+
+    // 키 관련 엔드포인트는 래핑된 응답을 그대로 반환하므로 data를 꺼냄
+    unwrap(response) {
+        return response && response.data !== undefined ? response.data : response;
+    },
+
     // 로그인 후 키 자동 로드
     async autoLoadKeys() {
         console.log('🔍 키 자동 로드 시도');
@@ -643,13 +652,15 @@ const KeyManager = {
     },
 
     async create() {
-        if (!confirm('SSH 키를 생성하시겠습니까?\n기존 키가 있다면 새 키로 교체됩니다.')) {
+        const name = prompt('새 SSH 키 이름을 입력하세요 (예: laptop, ci-runner)\n비워두면 자동으로 이름이 지정됩니다.', '');
+        if (name === null) {
             return;
         }
 
         try {
             Utils.setLoading(true, '키 생성 중...');
-            const keyData = await API.request('/keys', 'POST');
+            const keyData = this.unwrap(await API.request('/keys', 'POST', { name: name.trim() }));
+            this.currentKeyId = keyData.ID || keyData.id || null;
             this.displayKeys(keyData);
             Utils.showToast('SSH 키가 성공적으로 생성되었습니다!', 'success');
         } catch (error) {
@@ -662,8 +673,18 @@ const KeyManager = {
     async view() {
         try {
             Utils.setLoading(true, '키 조회 중...');
-            const keyData = await API.request('/keys');
-            this.displayKeys(keyData);
+            const list = this.unwrap(await API.request('/keys'));
+            const keys = (list && list.items) || [];
+            if (keys.length === 0) {
+                const error = new Error('키를 찾을 수 없습니다');
+                error.status = 404;
+                throw error;
+            }
+
+            // 가장 최근 키의 상세 정보 조회
+            this.currentKeyId = keys[0].id;
+            const keyData = this.unwrap(await API.request(`/keys/${this.currentKeyId}`));
+            this.displayKeys(keyData, keys.length);
             console.log('✅ 키 로드 성공');
         } catch (error) {
             this.hideKeys();
@@ -681,13 +702,18 @@ const KeyManager = {
     },
 
     async delete() {
-        if (!confirm('정말로 SSH 키를 삭제하시겠습니까?\n이 작업은 되돌릴 수 없습니다.')) {
+        if (!this.currentKeyId) {
+            Utils.showToast('삭제할 키가 없습니다', 'warning');
+            return;
+        }
+        if (!confirm('정말로 현재 SSH 키를 삭제하시겠습니까?\n이 작업은 되돌릴 수 없습니다.')) {
             return;
         }
 
         try {
             Utils.setLoading(true, '키 삭제 중...');
-            await API.request('/keys', 'DELETE');
+            await API.request(`/keys/${this.currentKeyId}`, 'DELETE');
+            this.currentKeyId = null;
             this.hideKeys();
             Utils.showToast('SSH 키가 성공적으로 삭제되었습니다', 'success');
         } catch (error) {
@@ -697,13 +723,14 @@ const KeyManager = {
         }
     },
 
-    displayKeys(keyData) {
+    displayKeys(keyData, keyCount = 1) {
         console.log('📋 키 데이터 수신:', keyData); // 디버깅용
         
         // API 응답 구조 정규화
         const normalizedData = this.normalizeKeyData(keyData);
         
-        UI.elements.keyInfo.textContent = `Algorithm: ${normalizedData.algorithm} / Bits: ${normalizedData.bits}`;
+        const countInfo = keyCount > 1 ? ` (보유 키 ${keyCount}개 중 최신)` : '';
+        UI.elements.keyInfo.textContent = `Name: ${normalizedData.name} / Algorithm: ${normalizedData.algorithm} / Bits: ${normalizedData.bits}${countInfo}`;
         
         // 키 데이터 설정
         const keyTypes = {
@@ -740,6 +767,7 @@ const KeyManager = {
         
         // 다양한 API 응답 형태를 표준화
         const normalized = {
+            name: keyData.Name || keyData.name || '',
             algorithm: keyData.Algorithm || keyData.algorithm || 'RSA',
             bits: keyData.Bits || keyData.bits || keyData.key_size || 2048,
             publicKey: keyData.PublicKey || keyData.public_key || keyData.publicKey || keyData.pub || '',
@@ -955,6 +983,83 @@ const ProfileManager = {
     }
 };
 
+// ===============================
+// 배포 이벤트 스트림 (SSE)
+// ===============================
+// EventSource는 Authorization 헤더를 보낼 수 없으므로 접속할 때마다 60초짜리 스트림 토큰을 받아 쿼리로 전달
+const DeploymentEvents = {
+    source: null,
+    retryTimer: null,
+    retryDelay: 2000,
+
+    async connect() {
+        this.disconnect();
+        if (!AppState.jwtToken) return;
+
+        try {
+            const data = await API.request('/jobs/events/token', 'POST');
+            if (!AppState.jwtToken) return;
+
+            this.source = new EventSource(`${API_BASE_URL}/jobs/events?token=${encodeURIComponent(data.token)}`);
+            this.source.onopen = () => {
+                this.retryDelay = 2000;
+                console.log('📡 배포 이벤트 스트림 연결됨');
+            };
+            ['installed', 'removed', 'failed', 'job_finished'].forEach(type => {
+                this.source.addEventListener(type, (e) => this.handleEvent(type, JSON.parse(e.data)));
+            });
+            // 연결이 끊기면 토큰이 만료되었을 수 있으므로 새 토큰으로 다시 연결
+            this.source.onerror = () => {
+                this.disconnect();
+                this.scheduleReconnect();
+            };
+        } catch (error) {
+            console.log('ℹ️ 배포 이벤트 스트림 연결 실패:', error.message);
+            this.scheduleReconnect();
+        }
+    },
+
+    scheduleReconnect() {
+        if (!AppState.jwtToken || this.retryTimer) return;
+        this.retryTimer = setTimeout(() => {
+            this.retryTimer = null;
+            this.connect();
+        }, this.retryDelay);
+        this.retryDelay = Math.min(this.retryDelay * 2, 60000);
+    },
+
+    disconnect() {
+        if (this.source) {
+            this.source.close();
+            this.source = null;
+        }
+        if (this.retryTimer) {
+            clearTimeout(this.retryTimer);
+            this.retryTimer = null;
+        }
+    },
+
+    handleEvent(type, event) {
+        const server = Utils.escapeHtml(event.server_name || '');
+        switch (type) {
+            case 'installed':
+                Utils.showToast(`${server}: 키 배포 완료`, 'success');
+                break;
+            case 'removed':
+                Utils.showToast(`${server}: 키 제거 완료`, 'success');
+                break;
+            case 'failed':
+                Utils.showToast(`${server}: ${Utils.escapeHtml(event.error_message || '작업 실패')}`, 'error', 5000);
+                break;
+            case 'job_finished':
+                if (event.job) {
+                    Utils.showToast(`배포 작업 #${event.job_id} 종료: ${Utils.escapeHtml(event.job.message || event.job.status)}`, 'info', 5000);
+                }
+                break;
+        }
+    }
+};
+
 // ===============================
 // 애플리케이션 초기화
 // ===============================
@@ -998,4 +1103,5 @@ window.Utils = Utils;
 window.UI = UI;
 window.KeyManager = KeyManager;
 window.UserManager = UserManager;
+window.DeploymentEvents = DeploymentEvents;
 window.ProfileManager = ProfileManager;
\ No newline at end of file
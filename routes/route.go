@@ -13,9 +13,16 @@ import (
 )
 
 // SetupRoutes는 모든 라우팅을 설정합니다.
+// 백그라운드 작업과 원격 접속 설정은 애플리케이션 시작 시 services.StartBackgroundServices로 따로 시작합니다.
 func SetupRoutes(e *echo.Echo) error {
 	// 미들웨어 설정
-	e.Use(middleware.Logger())
+	// 쿼리 문자열(이벤트 스트림의 ?token= 등)이 로그에 남지 않도록 uri 대신 path만 기록
+	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
+		Format: `{"time":"${time_rfc3339_nano}","id":"${id}","remote_ip":"${remote_ip}",` +
+			`"host":"${host}","method":"${method}","path":"${path}","user_agent":"${user_agent}",` +
+			`"status":${status},"error":"${error}","latency":${latency},"latency_human":"${latency_human}"` +
+			`,"bytes_in":${bytes_in},"bytes_out":${bytes_out}}` + "\n",
+	}))
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
@@ -57,6 +64,13 @@ func setupPublicRoutes(e *echo.Echo) {
 	api.POST("/register", controllers.Register)
 	api.POST("/login", controllers.Login)
 
+	// SSH 인증서 CA 공개키 (서버 TrustedUserCAKeys 설정용)
+	api.GET("/ca/user", controllers.GetUserCAPublicKey)
+	api.GET("/ca/host", controllers.GetHostCAPublicKey) // 클라이언트 known_hosts @cert-authority 설정용
+
+	// pull 방식 authorized_keys (sshd AuthorizedKeysCommand, 호스트 토큰으로 인증)
+	api.GET("/hosts/:host/authorized-keys", controllers.GetHostAuthorizedKeys)
+
 	// 헬스체크
 	api.GET("/health", func(c echo.Context) error {
 		return c.JSON(200, map[string]string{
@@ -80,9 +94,28 @@ func setupAuthenticatedRoutes(e *echo.Echo, jwtConfig echojwt.Config) {
 
 	// SSH 키 관리 API
 	keys := auth.Group("/keys")
-	keys.POST("", controllers.CreateKey)   // 키 생성/재생성
-	keys.GET("", controllers.GetKey)       // 키 조회
-	keys.DELETE("", controllers.DeleteKey) // 키 삭제
+	keys.POST("", controllers.CreateKey)                    // 키 생성 (같은 이름은 force_replace로 교체)
+	keys.GET("", controllers.ListKeys)                      // 키 목록 조회
+	keys.POST("/import", controllers.ImportKey)             // 기존 개인키/공개키 가져오기
+	keys.POST("/validate", controllers.ValidateKey)         // 키 검증 (저장하지 않음)
+	keys.GET("/:id", controllers.GetKey)                    // 키 상세 조회
+	keys.PUT("/:id", controllers.UpdateKey)                 // 키 이름/상태 수정
+	keys.DELETE("/:id", controllers.DeleteKey)              // 키 삭제
+	keys.POST("/:id/export", controllers.ExportKey)         // 키 내보내기 (패스프레이즈 암호화 지원)
+	keys.GET("/:id/security", controllers.GetKeySecurity)   // 키 보안 평가
+	keys.POST("/:id/rotate", controllers.RotateKey)         // 키 즉시 교체 (새 키 배포 후 기존 키 제거)
+	keys.GET("/:id/rotations", controllers.GetKeyRotations) // 키 교체 기록
+
+	// SSH 인증서 API (사용자 CA 서명)
+	certificates := auth.Group("/certificates")
+	certificates.POST("/user", controllers.IssueUserCertificate)     // 사용자 인증서 발급
+	certificates.GET("", controllers.ListCertificates)               // 발급받은 인증서 목록
+	certificates.GET("/known_hosts", controllers.DownloadKnownHosts) // 호스트 CA known_hosts 다운로드
+
+	// 알림 API
+	notifications := auth.Group("/notifications")
+	notifications.GET("", controllers.GetNotifications)              // 알림 목록 (unread=true로 읽지 않은 알림만)
+	notifications.PUT("/:id/read", controllers.MarkNotificationRead) // 알림 읽음 처리
 
 	// 개별 사용자 관리 API (본인만 접근 가능)
 	users := auth.Group("/users")
@@ -91,14 +124,36 @@ func setupAuthenticatedRoutes(e *echo.Echo, jwtConfig echojwt.Config) {
 
 	// 서버 관리 API
 	servers := auth.Group("/servers")
-	servers.POST("", controllers.CreateServer)                    // 서버 등록
-	servers.GET("", controllers.GetServers)                       // 서버 목록
-	servers.GET("/:id", controllers.GetServer)                    // 서버 상세
-	servers.PUT("/:id", controllers.UpdateServer)                 // 서버 수정
-	servers.DELETE("/:id", controllers.DeleteServer)              // 서버 삭제
-	servers.POST("/:id/test", controllers.TestServerConnection)   // 서버 연결 테스트
-	servers.POST("/deploy", controllers.DeployKeyToServers)       // 키 배포
-	servers.GET("/deployments", controllers.GetDeploymentHistory) // 배포 기록
+	servers.POST("", controllers.CreateServer)                                // 서버 등록
+	servers.GET("", controllers.GetServers)                                   // 서버 목록
+	servers.GET("/:id", controllers.GetServer)                                // 서버 상세
+	servers.PUT("/:id", controllers.UpdateServer)                             // 서버 수정
+	servers.DELETE("/:id", controllers.DeleteServer)                          // 서버 삭제
+	servers.POST("/:id/test", controllers.TestServerConnection)               // 서버 연결 테스트
+	servers.GET("/:id/info", controllers.GetServerInfo)                       // 서버 정보 조회
+	servers.GET("/:id/snapshots", controllers.GetServerSnapshots)             // authorized_keys 스냅샷 목록
+	servers.GET("/:id/snapshots/:snapshotId", controllers.GetServerSnapshot)  // authorized_keys 스냅샷 상세 (내용 포함)
+	servers.POST("/:id/rollback", controllers.RollbackServerAuthorizedKeys)   // authorized_keys를 스냅샷으로 복원
+	servers.POST("/:id/reconcile", controllers.ReconcileServerAuthorizedKeys) // authorized_keys를 관리 중인 키와 일치 (독점 관리 모드)
+	servers.POST("/deploy", controllers.DeployKeyToServers)                   // 키 배포
+	servers.POST("/undeploy", controllers.UndeployKeyFromServers)             // 키 제거
+	servers.GET("/deployments", controllers.GetDeploymentHistory)             // 배포 기록
+	servers.GET("/drift", controllers.GetUserDriftReports)                    // 서버별 최신 드리프트 검사 결과
+	servers.POST("/drift/scan", controllers.RunUserDriftScan)                 // 드리프트 검사 실행
+
+	// 비동기 배포 작업 API (서버가 많은 배포/제거)
+	jobs := auth.Group("/jobs")
+	jobs.POST("", controllers.SubmitDeploymentJob)                    // 배포 작업 등록 (deploy_key, undeploy_key)
+	jobs.GET("", controllers.GetDeploymentJobs)                       // 최근 배포 작업 목록
+	jobs.GET("/:id", controllers.GetDeploymentJob)                    // 진행 상황 및 서버별 결과
+	jobs.POST("/:id/cancel", controllers.CancelDeploymentJob)         // 배포 작업 취소
+	jobs.POST("/events/token", controllers.IssueDeploymentEventToken) // 이벤트 스트림 접속용 단기 토큰 (60초)
+
+	// 배포 이벤트 스트림 (SSE, EventSource는 헤더를 보낼 수 없으므로 token 쿼리의 단기 토큰도 허용)
+	events := api.Group("/jobs")
+	events.Use(controllers.EventStreamAuth(jwtConfig))
+	events.GET("/events", controllers.StreamDeploymentEvents)        // 사용자의 모든 배포 이벤트 스트림
+	events.GET("/:id/events", controllers.StreamDeploymentJobEvents) // 작업 이벤트 스트림 (작업 종료 시 끝남)
 }
 
 // setupAdminRoutes는 관리자 전용 라우트를 설정합니다.
@@ -115,6 +170,37 @@ func setupAdminRoutes(e *echo.Echo, jwtConfig echojwt.Config) {
 	admin.PUT("/users/:id/role", controllers.UpdateUserRole) // 사용자 권한 변경
 	admin.DELETE("/users/:id", controllers.DeleteUser)       // 사용자 삭제
 
+	// 핑거프린트로 키 소유자 검색 (sshd 로그 줄 입력 가능)
+	admin.GET("/keys/fingerprint", controllers.SearchKeysByFingerprint)
+	admin.POST("/keys/fingerprint", controllers.SearchKeysByFingerprint)
+
+	// 전체 SSH 키 보안 평가 보고서 (약하거나 오래된 키 찾기)
+	admin.GET("/keys/security-report", controllers.GetKeySecurityReport)
+
+	// 키 만료 검사 즉시 실행 (만료 예정 알림, 만료 키 교체)
+	admin.POST("/keys/expiry-check", controllers.RunKeyExpiryCheck)
+
+	// 서버 호스트 키 서명 (호스트 인증서 발급)
+	admin.POST("/servers/:id/host-certificate", controllers.SignServerHostCertificate)
+
+	// 호스트 키 변경 검토 및 승인
+	admin.GET("/servers/host-key-changes", controllers.GetHostKeyChangedServers)
+	admin.POST("/servers/:id/host-key/accept", controllers.AcceptServerHostKey)
+
+	// 사용자가 등록한 서버 계정 승인 (pull 배포, 계정 단위 키 합산, 인증서 principal 대상)
+	admin.GET("/servers/approvals", controllers.GetUnapprovedServers)
+	admin.POST("/servers/:id/approval", controllers.ApproveServer)
+	admin.DELETE("/servers/:id/approval", controllers.RevokeServerApproval)
+
+	// authorized_keys 드리프트 검사 결과 조회 및 즉시 실행
+	admin.GET("/servers/drift", controllers.GetDriftReports)
+	admin.POST("/servers/drift/scan", controllers.RunDriftScan)
+
+	// pull 방식 호스트 등록 및 토큰 발급
+	admin.POST("/pull-hosts", controllers.RegisterPullHost)
+	admin.GET("/pull-hosts", controllers.GetPullHosts)
+	admin.DELETE("/pull-hosts/:id", controllers.DeletePullHost)
+
 	// 사용자 목록 조회도 관리자 전용으로 이동
 	admin.GET("/users-list", controllers.GetUsers) // 기본 사용자 목록 (관리자용)
 }
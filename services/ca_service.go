@@ -0,0 +1,244 @@
+package services
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// CA 최초 생성이 동시에 두 번 일어나지 않도록 직렬화합니다.
+var caMu sync.Mutex
+
+// GetCAPublicKey는 CA 공개키를 반환합니다. CA가 없으면 새로 생성합니다.
+func GetCAPublicKey(caType string) (*types.CAPublicKeyResponse, error) {
+	ca, _, err := getCertificateAuthority(caType)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &types.CAPublicKeyResponse{
+		Type:        ca.Type,
+		Algorithm:   ca.Algorithm,
+		PublicKey:   ca.PublicKey,
+		Fingerprint: ca.Fingerprint,
+		CreatedAt:   ca.CreatedAt,
+	}
+	switch ca.Type {
+	case models.CATypeUser:
+		response.Usage = "서버의 /etc/ssh/user_ca.pub에 저장하고 sshd_config에 'TrustedUserCAKeys /etc/ssh/user_ca.pub'를 추가하세요"
+	case models.CATypeHost:
+		response.Usage = "클라이언트의 ~/.ssh/known_hosts에 '@cert-authority * <공개키>' 줄을 추가하세요"
+	}
+	return response, nil
+}
+
+// getCertificateAuthority는 활성 CA와 복호화된 서명 키를 반환합니다.
+// 해당 종류의 CA가 아직 없으면 Ed25519 CA를 생성하여 저장합니다.
+func getCertificateAuthority(caType string) (*models.CertificateAuthority, crypto.Signer, error) {
+	if caType != models.CATypeUser && caType != models.CATypeHost {
+		return nil, nil, fmt.Errorf("지원하지 않는 CA 종류입니다: %s (user, host)", caType)
+	}
+
+	caMu.Lock()
+	defer caMu.Unlock()
+
+	var ca models.CertificateAuthority
+	err := models.DB.Where("type = ? AND active = ?", caType, true).Order("id DESC").First(&ca).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("❌ CA 조회 실패: %v", err)
+			return nil, nil, err
+		}
+		created, createErr := createCertificateAuthority(caType)
+		if createErr != nil {
+			return nil, nil, createErr
+		}
+		ca = *created
+	}
+
+	if err := openCAKey(&ca); err != nil {
+		return nil, nil, err
+	}
+
+	signer, err := utils.ParsePrivateKey([]byte(ca.PrivateKey), "")
+	if err != nil {
+		log.Printf("❌ CA 개인키 파싱 실패 (CA ID: %d): %v", ca.ID, err)
+		return nil, nil, errors.New("CA 개인키를 읽을 수 없습니다")
+	}
+	ca.PrivateKey = ""
+
+	return &ca, signer, nil
+}
+
+// createCertificateAuthority는 새 Ed25519 CA 키를 생성하여 암호화 후 저장합니다.
+func createCertificateAuthority(caType string) (*models.CertificateAuthority, error) {
+	log.Printf("🏛️ %s CA 키 생성 중 (Ed25519)...", caType)
+
+	privateKey, err := utils.GenerateKeyPair(utils.KeyAlgorithmEd25519, 256)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := fmt.Sprintf("ssh-key-manager-%s-ca", caType)
+	opensshKey, err := utils.EncodePrivateKeyToOpenSSH(privateKey, comment)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := utils.GeneratePublicKeyWithUserComment(privateKey, comment)
+	if err != nil {
+		return nil, err
+	}
+	fingerprints, err := utils.ComputeFingerprints(string(publicKey))
+	if err != nil {
+		return nil, err
+	}
+
+	ca := &models.CertificateAuthority{
+		Type:        caType,
+		Algorithm:   utils.KeyAlgorithmEd25519,
+		PublicKey:   strings.TrimSpace(string(publicKey)),
+		PrivateKey:  string(opensshKey),
+		Fingerprint: fingerprints.SHA256,
+		Active:      true,
+	}
+
+	// 암호문을 행 ID에 묶으므로 개인키 없이 행을 먼저 만든 뒤 같은 트랜잭션에서 암호화한 값을 저장
+	privateKeyPEM := ca.PrivateKey
+	ca.PrivateKey = ""
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(ca).Error; err != nil {
+			return err
+		}
+		ca.PrivateKey = privateKeyPEM
+		if err := sealCAKey(ca); err != nil {
+			return err
+		}
+		return tx.Save(ca).Error
+	})
+	if err != nil {
+		log.Printf("❌ CA 저장 실패: %v", err)
+		return nil, err
+	}
+
+	log.Printf("✅ %s CA 생성 완료 (ID: %d, 핑거프린트: %s)", caType, ca.ID, ca.Fingerprint)
+	return ca, nil
+}
+
+// caKeyContext는 CA 개인키 암호문을 CA 행에 묶는 AAD입니다.
+func caKeyContext(ca *models.CertificateAuthority) string {
+	return utils.EnvelopeContext("certificate_authorities", ca.ID, "private_key")
+}
+
+// sealCAKey는 CA 개인키를 새 데이터 키로 암호화합니다 (저장된 행에만 사용).
+func sealCAKey(ca *models.CertificateAuthority) error {
+	if ca.ID == 0 {
+		return errors.New("저장되지 않은 CA는 암호화할 수 없습니다")
+	}
+
+	ring, err := getMasterKeyRing()
+	if err != nil {
+		log.Printf("❌ 마스터 키 로드 실패: %v", err)
+		return errors.New("CA 개인키 암호화용 마스터 키가 설정되지 않았습니다. 관리자에게 문의하세요")
+	}
+
+	dataKey, wrapped, err := ring.GenerateDataKey()
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := utils.EncryptWithDataKey(dataKey, ca.PrivateKey, caKeyContext(ca))
+	if err != nil {
+		return fmt.Errorf("CA 개인키 암호화 실패: %w", err)
+	}
+
+	ca.PrivateKey = ciphertext
+	ca.WrappedDataKey = wrapped
+	ca.MasterKeyID = ring.ActiveKeyID()
+	return nil
+}
+
+// openCAKey는 암호화된 CA 개인키를 복호화합니다.
+func openCAKey(ca *models.CertificateAuthority) error {
+	ring, err := getMasterKeyRing()
+	if err != nil {
+		log.Printf("❌ 마스터 키 로드 실패: %v", err)
+		return errors.New("CA 개인키 복호화용 마스터 키가 설정되지 않았습니다. 관리자에게 문의하세요")
+	}
+
+	dataKey, err := ring.UnwrapDataKey(ca.WrappedDataKey, ca.MasterKeyID)
+	if err != nil {
+		log.Printf("❌ 데이터 키 언래핑 실패 (CA ID: %d): %v", ca.ID, err)
+		return errors.New("CA 개인키를 복호화할 수 없습니다")
+	}
+
+	plaintext, err := utils.DecryptWithDataKey(dataKey, ca.PrivateKey, caKeyContext(ca))
+	if err != nil {
+		if errors.Is(err, utils.ErrEnvelopeNotEncrypted) {
+			log.Printf("🚨 암호화되지 않은 CA 개인키가 저장되어 있습니다 (CA ID: %d). 사용하지 않습니다", ca.ID)
+			utils.LogSecurityEvent("암호화되지 않은 CA 개인키 감지", 0, fmt.Sprintf("CA ID %d", ca.ID), "high")
+		} else {
+			log.Printf("❌ CA 개인키 복호화 실패 (CA ID: %d): %v", ca.ID, err)
+		}
+		return errors.New("CA 개인키를 복호화할 수 없습니다")
+	}
+
+	ca.PrivateKey = plaintext
+	return nil
+}
+
+// rewrapCertificateAuthorities는 CA 개인키의 데이터 키를 활성 마스터 키로 다시 래핑합니다.
+func rewrapCertificateAuthorities(ring *utils.MasterKeyRing, dryRun bool, result *KeyRewrapResult) error {
+	var authorities []models.CertificateAuthority
+	if err := models.DB.Unscoped().Order("id").Find(&authorities).Error; err != nil {
+		return err
+	}
+
+	for _, ca := range authorities {
+		result.Total++
+		if ca.MasterKeyID == ring.ActiveKeyID() {
+			result.AlreadyDone++
+			continue
+		}
+
+		updates, err := rewrapCertificateAuthority(ring, &ca)
+		if err != nil {
+			result.Failed++
+			log.Printf("❌ CA 재래핑 실패 (CA ID: %d): %v", ca.ID, err)
+			continue
+		}
+
+		result.Rewrapped++
+		if dryRun {
+			continue
+		}
+		if err := models.DB.Unscoped().Model(&models.CertificateAuthority{}).Where("id = ?", ca.ID).UpdateColumns(updates).Error; err != nil {
+			result.Failed++
+			log.Printf("❌ CA 저장 실패 (CA ID: %d): %v", ca.ID, err)
+		}
+	}
+	return nil
+}
+
+// rewrapCertificateAuthority는 CA 한 행의 재래핑 변경 사항을 만듭니다.
+func rewrapCertificateAuthority(ring *utils.MasterKeyRing, ca *models.CertificateAuthority) (map[string]interface{}, error) {
+	dataKey, err := ring.UnwrapDataKey(ca.WrappedDataKey, ca.MasterKeyID)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := ring.WrapDataKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"wrapped_data_key": wrapped,
+		"master_key_id":    ring.ActiveKeyID(),
+	}, nil
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"ssh-key-manager/config"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// IssueUserCertificate는 사용자의 공개키를 사용자 CA로 서명하여 단기 인증서를 발급합니다.
+// principal은 사용자가 등록한 서버의 접속 계정(Server.Username)과 소속 부서 코드로 정해지며,
+// 서버는 TrustedUserCAKeys와 AuthorizedPrincipalsFile로 접속을 허용합니다.
+func IssueUserCertificate(userID uint, req types.UserCertificateRequest) (*types.CertificateResponse, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := models.DB.Preload("Department").First(&user, userID).Error; err != nil {
+		return nil, errors.New("사용자를 찾을 수 없습니다")
+	}
+
+	// 배포와 동일한 규칙으로 키 선택 (활성, 만료되지 않은 키)
+	key, err := resolveDeploymentKey(userID, req.SSHKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("공개키 형식이 올바르지 않습니다: %v", err)
+	}
+
+	principals, err := userCertificatePrincipals(&user, req.ServerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 유효 기간 결정 (키 만료 시각을 넘지 않음)
+	validFor := cfg.CertDefaultTTL
+	if strings.TrimSpace(req.ValidFor) != "" {
+		validFor, err = time.ParseDuration(strings.TrimSpace(req.ValidFor))
+		if err != nil || validFor <= 0 {
+			return nil, fmt.Errorf("유효하지 않은 인증서 유효 기간입니다: %s (예: 1h, 8h)", req.ValidFor)
+		}
+	}
+	if validFor > cfg.CertMaxTTL {
+		return nil, fmt.Errorf("인증서 유효 기간은 최대 %s까지 가능합니다", cfg.CertMaxTTL)
+	}
+
+	now := time.Now()
+	validAfter := now.Add(-utils.CertificateClockSkew)
+	validBefore := now.Add(validFor)
+	if key.ExpiresAt != nil && key.ExpiresAt.Before(validBefore) {
+		validBefore = *key.ExpiresAt
+	}
+
+	ca, caSigner, err := getCertificateAuthority(models.CATypeUser)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := utils.NewCertificateSerial()
+	if err != nil {
+		return nil, err
+	}
+	keyID := fmt.Sprintf("%s:%s", user.Username, key.Name)
+
+	cert, err := utils.SignCertificate(caSigner, publicKey, utils.CertificateOptions{
+		CertType:    ssh.UserCert,
+		KeyID:       keyID,
+		Serial:      serial,
+		Principals:  principals,
+		ValidAfter:  validAfter,
+		ValidBefore: validBefore,
+		Extensions:  utils.DefaultUserCertificateExtensions(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	record := models.SSHCertificate{
+		CAID:        ca.ID,
+		CertType:    models.CATypeUser,
+		Serial:      serial,
+		KeyID:       keyID,
+		UserID:      userID,
+		SSHKeyID:    &key.ID,
+		Principals:  strings.Join(principals, ","),
+		Fingerprint: keyFingerprint(key),
+		ValidAfter:  validAfter,
+		ValidBefore: validBefore,
+		Certificate: utils.FormatCertificate(cert, key.Comment),
+	}
+	if err := models.DB.Create(&record).Error; err != nil {
+		log.Printf("❌ 인증서 기록 저장 실패: %v", err)
+		return nil, errors.New("인증서 발급 중 오류가 발생했습니다")
+	}
+
+	log.Printf("📜 사용자 인증서 발급 완료 (사용자: %s, 키: %s, 일련번호: %d, principal: %s, 만료: %s)",
+		user.Username, key.Name, serial, record.Principals, validBefore.Format("2006-01-02 15:04"))
+
+	response := types.ToCertificateResponse(record)
+	response.CAPublicKey = ca.PublicKey
+	response.CAFingerprint = ca.Fingerprint
+	return &response, nil
+}
+
+// ListUserCertificates는 사용자가 발급받은 인증서를 최신순으로 조회합니다.
+func ListUserCertificates(userID uint) ([]types.CertificateResponse, error) {
+	var certs []models.SSHCertificate
+	if err := models.DB.Where("user_id = ? AND cert_type = ?", userID, models.CATypeUser).
+		Order("created_at DESC").Limit(100).Find(&certs).Error; err != nil {
+		log.Printf("❌ 인증서 목록 조회 실패: %v", err)
+		return nil, err
+	}
+
+	responses := make([]types.CertificateResponse, 0, len(certs))
+	for _, cert := range certs {
+		responses = append(responses, types.ToCertificateResponse(cert))
+	}
+	return responses, nil
+}
+
+// userCertificatePrincipals는 사용자 인증서에 넣을 principal 목록을 만듭니다.
+// 관리자가 승인한 서버의 접속 계정(serverIDs가 있으면 해당 서버만)과 소속 부서 코드를 포함합니다.
+// 사용자가 임의로 입력한 계정(root 등)으로 인증서를 받지 않도록 승인 전 서버와 simulated 서버는 사용하지 않습니다.
+func userCertificatePrincipals(user *models.User, serverIDs []uint) ([]string, error) {
+	query := models.DB.Model(&models.Server{}).
+		Where("user_id = ? AND status = ? AND approved_at IS NOT NULL AND deploy_method <> ?",
+			user.ID, models.ServerStatusActive, models.DeployMethodSimulated)
+	if len(serverIDs) > 0 {
+		query = query.Where("id IN ?", serverIDs)
+	}
+
+	var servers []models.Server
+	if err := query.Select("id", "username").Find(&servers).Error; err != nil {
+		return nil, err
+	}
+	if len(serverIDs) > 0 && len(servers) != len(uniqueUints(serverIDs)) {
+		return nil, errors.New("선택된 서버를 찾을 수 없습니다 (관리자 승인을 받은 서버만 선택할 수 있습니다)")
+	}
+
+	seen := make(map[string]bool)
+	var principals []string
+	add := func(principal string) {
+		principal = strings.TrimSpace(principal)
+		if principal != "" && !seen[principal] {
+			seen[principal] = true
+			principals = append(principals, principal)
+		}
+	}
+
+	for _, server := range servers {
+		add(server.Username)
+	}
+	sort.Strings(principals)
+
+	if user.Department != nil && user.Department.IsActive {
+		add(user.Department.Code)
+	}
+
+	if len(principals) == 0 {
+		return nil, errors.New("인증서에 넣을 principal이 없습니다. 서버 계정 승인을 요청하거나 부서를 선택해주세요")
+	}
+	return principals, nil
+}
+
+// uniqueUints는 중복을 제거한 ID 목록을 반환합니다.
+func uniqueUints(values []uint) []uint {
+	seen := make(map[uint]bool, len(values))
+	result := make([]uint, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
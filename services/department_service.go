@@ -2,6 +2,7 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"ssh-key-manager/models"
 	"ssh-key-manager/types"
@@ -25,14 +26,19 @@ func CreateDepartment(req types.DepartmentCreateRequest) (*types.DepartmentRespo
 		level = parentDept.Level + 1
 	}
 
+	if err := validateMaxKeyAgeDays(req.MaxKeyAgeDays); err != nil {
+		return nil, err
+	}
+
 	// 부서 생성
 	department := models.Department{
-		Code:        strings.TrimSpace(req.Code),
-		Name:        strings.TrimSpace(req.Name),
-		Description: strings.TrimSpace(req.Description),
-		ParentID:    req.ParentID,
-		Level:       level,
-		IsActive:    true,
+		Code:          strings.TrimSpace(req.Code),
+		Name:          strings.TrimSpace(req.Name),
+		Description:   strings.TrimSpace(req.Description),
+		ParentID:      req.ParentID,
+		Level:         level,
+		IsActive:      true,
+		MaxKeyAgeDays: req.MaxKeyAgeDays,
 	}
 
 	if err := models.DB.Create(&department).Error; err != nil {
@@ -193,6 +199,13 @@ func UpdateDepartment(deptID uint, req types.DepartmentUpdateRequest) (*types.De
 		updates["is_active"] = *req.IsActive
 	}
 
+	if req.MaxKeyAgeDays != nil && *req.MaxKeyAgeDays != department.MaxKeyAgeDays {
+		if err := validateMaxKeyAgeDays(*req.MaxKeyAgeDays); err != nil {
+			return nil, err
+		}
+		updates["max_key_age_days"] = *req.MaxKeyAgeDays
+	}
+
 	// 업데이트 실행
 	if len(updates) > 0 {
 		if err := models.DB.Model(&department).Updates(updates).Error; err != nil {
@@ -408,3 +421,14 @@ func GetUserDepartmentHistory(userID uint) ([]types.DepartmentHistoryResponse, e
 	log.Printf("✅ 부서 변경 이력 조회 완료: %d건", len(responses))
 	return responses, nil
 }
+
+// validateMaxKeyAgeDays는 부서 최대 키 사용 기간을 검증합니다 (0은 상위 부서/조직 기본값 사용).
+func validateMaxKeyAgeDays(days int) error {
+	if days < 0 {
+		return errors.New("최대 키 사용 기간은 0 이상이어야 합니다")
+	}
+	if days > maxKeyLifetimeDays {
+		return fmt.Errorf("최대 키 사용 기간은 최대 %d일까지 가능합니다", maxKeyLifetimeDays)
+	}
+	return nil
+}
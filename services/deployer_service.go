@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"ssh-key-manager/config"
+	"ssh-key-manager/models"
+	"ssh-key-manager/utils"
+	"strings"
+)
+
+// serverDeployer는 서버의 배포 방식에 맞는 Deployer를 만듭니다.
+// pull 방식은 접속하지 않는 pullDeployer를 사용합니다.
+// 원격 방식(ssh, sftp)은 serverSSHTarget으로 기록된 호스트 키를 검증하며, 기록된 키가 없으면 접속하지 않습니다.
+func serverDeployer(server *models.Server) (utils.Deployer, error) {
+	target := utils.DeployTarget{
+		Method: server.DeployMethod,
+		SSH: utils.SSHTarget{
+			Host:     server.Host,
+			Port:     server.Port,
+			Username: server.Username,
+		},
+	}
+
+	switch server.DeployMethod {
+	case models.DeployMethodPull:
+		// 서버가 가져가므로 접속 대상이 필요 없음
+		return &pullDeployer{server: server}, nil
+	case models.DeployMethodLocal:
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		target.HomePath = cfg.SSHHomePath
+	case models.DeployMethodSimulated:
+	default:
+		sshTarget, err := serverSSHTarget(server)
+		if err != nil {
+			return nil, err
+		}
+		target.SSH = sshTarget
+	}
+
+	return utils.NewDeployer(target)
+}
+
+// normalizeDeployMethod는 요청한 배포 방식을 검증합니다. 비어있으면 ssh를 사용합니다.
+// local 방식은 관리 도구가 실행 중인 서버의 authorized_keys를 수정하므로 관리자만 지정할 수 있습니다.
+func normalizeDeployMethod(userID uint, method string) (string, error) {
+	method = strings.ToLower(strings.TrimSpace(method))
+	switch method {
+	case "":
+		return models.DeployMethodSSH, nil
+	case models.DeployMethodSSH, models.DeployMethodSFTP, models.DeployMethodSimulated, models.DeployMethodPull:
+		return method, nil
+	case models.DeployMethodLocal:
+		if !IsUserAdmin(userID) {
+			return "", errors.New("local 배포 방식을 지정할 권한이 없습니다 (관리자 전용)")
+		}
+		return method, nil
+	}
+	return "", fmt.Errorf("지원하지 않는 배포 방식입니다: %s (ssh, sftp, local, simulated, pull)", method)
+}
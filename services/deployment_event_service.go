@@ -0,0 +1,129 @@
+package services
+
+import (
+	"log"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// deploymentEventBuffer는 구독자별 이벤트 버퍼 크기입니다.
+// 버퍼가 가득 찰 만큼 느린 구독자는 연결을 끊고, 다시 연결할 때 현재 진행 상황부터 받게 합니다.
+const deploymentEventBuffer = 256
+
+// deploymentSubscriber는 배포 이벤트 스트림 구독자입니다.
+type deploymentSubscriber struct {
+	userID uint
+	jobID  uint // 0이면 사용자의 모든 배포 이벤트
+	events chan types.DeploymentEvent
+}
+
+var (
+	deploymentEventSeq      atomic.Uint64
+	deploymentSubscribersMu sync.Mutex
+	deploymentSubscribers   = map[*deploymentSubscriber]struct{}{}
+)
+
+// SubscribeDeploymentEvents는 사용자의 배포 이벤트를 구독합니다. jobID가 0이 아니면 해당 작업의 이벤트만 받습니다.
+// 반환된 채널이 닫히면 구독이 끊긴 것이며(처리가 늦어 이벤트 유실), 사용이 끝나면 취소 함수를 호출해야 합니다.
+func SubscribeDeploymentEvents(userID, jobID uint) (<-chan types.DeploymentEvent, func()) {
+	subscriber := &deploymentSubscriber{
+		userID: userID,
+		jobID:  jobID,
+		events: make(chan types.DeploymentEvent, deploymentEventBuffer),
+	}
+
+	deploymentSubscribersMu.Lock()
+	deploymentSubscribers[subscriber] = struct{}{}
+	deploymentSubscribersMu.Unlock()
+
+	unsubscribe := func() {
+		deploymentSubscribersMu.Lock()
+		defer deploymentSubscribersMu.Unlock()
+		if _, ok := deploymentSubscribers[subscriber]; ok {
+			delete(deploymentSubscribers, subscriber)
+			close(subscriber.events)
+		}
+	}
+	return subscriber.events, unsubscribe
+}
+
+// publishDeploymentEvent는 배포 이벤트를 사용자의 구독자들에게 보냅니다.
+func publishDeploymentEvent(userID uint, event types.DeploymentEvent) {
+	event.ID = deploymentEventSeq.Add(1)
+	event.Time = time.Now()
+
+	deploymentSubscribersMu.Lock()
+	defer deploymentSubscribersMu.Unlock()
+
+	for subscriber := range deploymentSubscribers {
+		if subscriber.userID != userID || (subscriber.jobID != 0 && subscriber.jobID != event.JobID) {
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+			log.Printf("⚠️ 배포 이벤트 구독자 처리 지연으로 연결 종료 (사용자 ID: %d)", userID)
+			delete(deploymentSubscribers, subscriber)
+			close(subscriber.events)
+		}
+	}
+}
+
+// publishServerConnecting은 서버 작업 시작 이벤트를 보냅니다.
+func publishServerConnecting(userID, jobID uint, server models.Server, attempt int) {
+	publishDeploymentEvent(userID, types.DeploymentEvent{
+		Event: types.DeploymentEventConnecting,
+		JobID: jobID,
+		DeploymentResult: &types.DeploymentResult{
+			ServerID:   server.ID,
+			ServerName: server.Name,
+			Status:     "pending",
+		},
+		Attempt: attempt,
+	})
+}
+
+// publishServerRetried는 일시적 오류로 서버 작업을 다시 시도할 때 이벤트를 보냅니다.
+func publishServerRetried(userID, jobID uint, server models.Server, attempt int, err error) {
+	publishDeploymentEvent(userID, types.DeploymentEvent{
+		Event: types.DeploymentEventRetried,
+		JobID: jobID,
+		DeploymentResult: &types.DeploymentResult{
+			ServerID:     server.ID,
+			ServerName:   server.Name,
+			Status:       "pending",
+			ErrorMessage: err.Error(),
+		},
+		Attempt: attempt,
+	})
+}
+
+// publishServerResult는 서버 작업 결과 이벤트(installed, removed, failed)를 보냅니다.
+func publishServerResult(userID, jobID uint, action string, result types.DeploymentResult) {
+	event := types.DeploymentEventFailed
+	if result.Status == "success" {
+		event = types.DeploymentEventInstalled
+		if action == models.DeploymentActionRemove {
+			event = types.DeploymentEventRemoved
+		}
+	}
+
+	publishDeploymentEvent(userID, types.DeploymentEvent{
+		Event:            event,
+		JobID:            jobID,
+		DeploymentResult: &result,
+	})
+}
+
+// publishJobEvent는 작업 시작/종료 이벤트를 작업 진행 상황과 함께 보냅니다.
+func publishJobEvent(job *models.DeploymentJob, event string) {
+	progress := types.ToDeploymentJobProgress(*job)
+	publishDeploymentEvent(job.UserID, types.DeploymentEvent{
+		Event: event,
+		JobID: job.ID,
+		Job:   &progress,
+	})
+}
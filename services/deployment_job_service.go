@@ -0,0 +1,536 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/config"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// deploymentJobPollInterval은 깨우는 신호가 없을 때 대기 작업을 다시 확인하는 주기입니다.
+const deploymentJobPollInterval = 30 * time.Second
+
+var (
+	deploymentJobWorkersOnce sync.Once
+	deploymentJobWake        = make(chan struct{}, 1)
+
+	// 실행 중인 작업의 취소 함수 (작업 ID별)
+	runningJobsMu sync.Mutex
+	runningJobs   = map[uint]context.CancelFunc{}
+)
+
+// ErrDeploymentJobFinished는 이미 끝난 작업을 취소하려 했음을 나타냅니다.
+var ErrDeploymentJobFinished = errors.New("이미 끝난 배포 작업입니다")
+
+// StartDeploymentJobWorkers는 비동기 배포 작업을 실행하는 작업자들을 시작합니다.
+// 이전 실행에서 RUNNING 상태로 남은 작업은 대기 상태로 되돌려, 결과가 없는 서버부터 이어서 실행합니다.
+// 여러 번 호출해도 한 번만 시작됩니다.
+func StartDeploymentJobWorkers() {
+	deploymentJobWorkersOnce.Do(func() {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Printf("⚠️ 설정 로드 실패, 배포 작업자를 시작하지 않습니다: %v", err)
+			return
+		}
+
+		if err := resumeInterruptedDeploymentJobs(); err != nil {
+			log.Printf("⚠️ 중단된 배포 작업 복구 실패: %v", err)
+		}
+
+		log.Printf("⏰ 배포 작업자 %d개 시작", cfg.DeployJobWorkers)
+		for i := 0; i < cfg.DeployJobWorkers; i++ {
+			go deploymentJobWorker()
+		}
+		wakeDeploymentJobWorkers()
+	})
+}
+
+// SubmitDeploymentJob은 키 배포(제거) 작업을 등록하고 바로 반환합니다. 작업은 배포 작업자가 실행합니다.
+// Type은 deploy_key(parameters: KeyDeploymentRequest) 또는 undeploy_key(parameters: KeyUndeployRequest)입니다.
+func SubmitDeploymentJob(userID uint, req types.TaskRequest) (*types.ProgressResponse, error) {
+	request, serverCount, err := prepareDeploymentJobRequest(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	job := models.DeploymentJob{
+		UserID:   userID,
+		Type:     req.Type,
+		Status:   models.JobStatusPending,
+		Priority: req.Priority,
+		Request:  request,
+		Total:    serverCount,
+	}
+	if err := models.DB.Create(&job).Error; err != nil {
+		log.Printf("❌ 배포 작업 등록 실패: %v", err)
+		return nil, errors.New("배포 작업 등록 중 오류가 발생했습니다")
+	}
+
+	log.Printf("📥 배포 작업 등록: ID %d (%s, 서버 %d대, 사용자 ID: %d)", job.ID, job.Type, job.Total, userID)
+	wakeDeploymentJobWorkers()
+
+	progress := types.ToDeploymentJobProgress(job)
+	return &progress, nil
+}
+
+// GetUserDeploymentJobs는 사용자의 최근 배포 작업 목록을 조회합니다.
+func GetUserDeploymentJobs(userID uint) ([]types.ProgressResponse, error) {
+	var jobs []models.DeploymentJob
+	if err := models.DB.Where("user_id = ?", userID).Order("id DESC").Limit(50).Find(&jobs).Error; err != nil {
+		log.Printf("❌ 배포 작업 목록 조회 실패: %v", err)
+		return nil, err
+	}
+
+	responses := make([]types.ProgressResponse, 0, len(jobs))
+	for _, job := range jobs {
+		responses = append(responses, types.ToDeploymentJobProgress(job))
+	}
+	return responses, nil
+}
+
+// GetDeploymentJob은 배포 작업의 진행 상황과 서버별 결과를 조회합니다.
+func GetDeploymentJob(userID, jobID uint) (*types.ProgressResponse, error) {
+	job, err := findUserDeploymentJob(userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	progress := types.ToDeploymentJobProgress(*job)
+	return &progress, nil
+}
+
+// CancelDeploymentJob은 배포 작업을 취소합니다.
+// 대기 중인 작업은 바로 취소되고, 실행 중인 작업은 진행 중인 서버 작업을 중단한 뒤 남은 서버를 처리하지 않습니다.
+func CancelDeploymentJob(userID, jobID uint) (*types.ProgressResponse, error) {
+	job, err := findUserDeploymentJob(userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case models.JobStatusPending:
+		now := time.Now()
+		result := models.DB.Model(&models.DeploymentJob{}).
+			Where("id = ? AND status = ?", job.ID, models.JobStatusPending).
+			Updates(map[string]interface{}{
+				"status":           models.JobStatusCancelled,
+				"cancel_requested": true,
+				"completed_at":     now,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			// 그 사이 작업자가 실행을 시작함
+			return CancelDeploymentJob(userID, jobID)
+		}
+		log.Printf("🛑 대기 중인 배포 작업 취소: ID %d", job.ID)
+		job.Status = models.JobStatusCancelled
+		job.CompletedAt = &now
+		publishJobEvent(job, types.DeploymentEventJobFinished)
+
+	case models.JobStatusRunning:
+		if err := models.DB.Model(job).UpdateColumn("cancel_requested", true).Error; err != nil {
+			return nil, err
+		}
+		runningJobsMu.Lock()
+		cancel, ok := runningJobs[job.ID]
+		runningJobsMu.Unlock()
+		if ok {
+			cancel()
+		}
+		log.Printf("🛑 실행 중인 배포 작업 취소 요청: ID %d", job.ID)
+
+	default:
+		return nil, fmt.Errorf("%w (상태: %s)", ErrDeploymentJobFinished, job.Status)
+	}
+
+	return GetDeploymentJob(userID, jobID)
+}
+
+// prepareDeploymentJobRequest는 작업 요청 파라미터를 검증하고 저장할 요청(JSON)과 대상 서버 수를 반환합니다.
+// 배포할 키와 대상 서버는 등록 시점에 확정하여 저장합니다.
+func prepareDeploymentJobRequest(userID uint, req types.TaskRequest) (string, int, error) {
+	raw, err := json.Marshal(req.Parameters)
+	if err != nil {
+		return "", 0, errors.New("유효하지 않은 작업 파라미터입니다")
+	}
+
+	var request interface{}
+	var serverIDs []uint
+	switch req.Type {
+	case models.DeploymentJobTypeDeploy:
+		var deployReq types.KeyDeploymentRequest
+		if err := json.Unmarshal(raw, &deployReq); err != nil {
+			return "", 0, fmt.Errorf("유효하지 않은 작업 파라미터입니다: %v", err)
+		}
+		if len(deployReq.ServerIDs) == 0 {
+			return "", 0, errors.New("배포할 서버를 선택해주세요")
+		}
+		sshKey, err := resolveDeploymentKey(userID, deployReq.SSHKeyID)
+		if err != nil {
+			return "", 0, err
+		}
+		deployReq.SSHKeyID = sshKey.ID
+		if deployReq.ServerIDs, err = userServerIDs(userID, deployReq.ServerIDs); err != nil {
+			return "", 0, err
+		}
+		serverIDs = deployReq.ServerIDs
+		request = deployReq
+
+	case models.DeploymentJobTypeUndeploy:
+		var undeployReq types.KeyUndeployRequest
+		if err := json.Unmarshal(raw, &undeployReq); err != nil {
+			return "", 0, fmt.Errorf("유효하지 않은 작업 파라미터입니다: %v", err)
+		}
+		if len(undeployReq.ServerIDs) == 0 {
+			return "", 0, errors.New("키를 제거할 서버를 선택해주세요")
+		}
+		sshKey, err := resolveUndeployKey(userID, undeployReq.SSHKeyID)
+		if err != nil {
+			return "", 0, err
+		}
+		undeployReq.SSHKeyID = sshKey.ID
+		if undeployReq.ServerIDs, err = userServerIDs(userID, undeployReq.ServerIDs); err != nil {
+			return "", 0, err
+		}
+		serverIDs = undeployReq.ServerIDs
+		request = undeployReq
+
+	default:
+		return "", 0, fmt.Errorf("지원하지 않는 작업 종류입니다: %s (%s, %s)", req.Type,
+			models.DeploymentJobTypeDeploy, models.DeploymentJobTypeUndeploy)
+	}
+
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(encoded), len(serverIDs), nil
+}
+
+// userServerIDs는 요청한 서버 중 사용자 소유 서버의 ID를 중복 없이 ID 순서로 반환합니다.
+func userServerIDs(userID uint, serverIDs []uint) ([]uint, error) {
+	var owned []uint
+	if err := models.DB.Model(&models.Server{}).
+		Where("id IN ? AND user_id = ?", serverIDs, userID).
+		Order("id").Pluck("id", &owned).Error; err != nil {
+		return nil, err
+	}
+	if len(owned) == 0 {
+		return nil, errors.New("선택된 서버를 찾을 수 없습니다")
+	}
+	return owned, nil
+}
+
+// findUserDeploymentJob은 사용자의 배포 작업을 조회합니다.
+func findUserDeploymentJob(userID, jobID uint) (*models.DeploymentJob, error) {
+	var job models.DeploymentJob
+	if err := models.DB.Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("배포 작업을 찾을 수 없습니다")
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// resumeInterruptedDeploymentJobs는 서버 재시작으로 중단된 작업을 다시 실행하도록 되돌립니다.
+// 취소 요청 중에 중단된 작업은 취소로 마무리합니다.
+func resumeInterruptedDeploymentJobs() error {
+	now := time.Now()
+	cancelled := models.DB.Model(&models.DeploymentJob{}).
+		Where("status = ? AND cancel_requested = ?", models.JobStatusRunning, true).
+		Updates(map[string]interface{}{"status": models.JobStatusCancelled, "completed_at": now})
+	if cancelled.Error != nil {
+		return cancelled.Error
+	}
+
+	resumed := models.DB.Model(&models.DeploymentJob{}).
+		Where("status = ?", models.JobStatusRunning).
+		Update("status", models.JobStatusPending)
+	if resumed.Error != nil {
+		return resumed.Error
+	}
+
+	if resumed.RowsAffected > 0 || cancelled.RowsAffected > 0 {
+		log.Printf("🔁 중단된 배포 작업 복구: 재실행 %d개, 취소 %d개", resumed.RowsAffected, cancelled.RowsAffected)
+	}
+	return nil
+}
+
+// wakeDeploymentJobWorkers는 대기 중인 작업자에게 새 작업이 있음을 알립니다.
+func wakeDeploymentJobWorkers() {
+	select {
+	case deploymentJobWake <- struct{}{}:
+	default:
+	}
+}
+
+// deploymentJobWorker는 대기 작업을 하나씩 가져와 실행합니다.
+func deploymentJobWorker() {
+	ticker := time.NewTicker(deploymentJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := claimDeploymentJob()
+		if err != nil {
+			log.Printf("❌ 배포 작업 조회 실패: %v", err)
+		}
+		if job != nil {
+			// 다른 대기 작업이 있을 수 있으므로 다른 작업자도 깨움
+			wakeDeploymentJobWorkers()
+			runDeploymentJob(job)
+			continue
+		}
+
+		select {
+		case <-deploymentJobWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimDeploymentJob은 우선순위가 가장 높은 대기 작업을 실행 상태로 바꾸고 반환합니다.
+// 여러 작업자가 같은 작업을 가져가지 않도록 상태 조건부로 갱신합니다.
+func claimDeploymentJob() (*models.DeploymentJob, error) {
+	for {
+		var job models.DeploymentJob
+		err := models.DB.Where("status = ?", models.JobStatusPending).
+			Order("priority DESC, id").First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		result := models.DB.Model(&models.DeploymentJob{}).
+			Where("id = ? AND status = ?", job.ID, models.JobStatusPending).
+			Updates(map[string]interface{}{
+				"status":     models.JobStatusRunning,
+				"started_at": gorm.Expr("COALESCE(started_at, ?)", now),
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status = models.JobStatusRunning
+			if job.StartedAt == nil {
+				job.StartedAt = &now
+			}
+			return &job, nil
+		}
+	}
+}
+
+// runDeploymentJob은 작업의 남은 서버를 차례로 처리하고, 서버마다 진행 상황을 저장합니다.
+func runDeploymentJob(job *models.DeploymentJob) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runningJobsMu.Lock()
+	runningJobs[job.ID] = cancel
+	runningJobsMu.Unlock()
+	defer func() {
+		runningJobsMu.Lock()
+		delete(runningJobs, job.ID)
+		runningJobsMu.Unlock()
+		cancel()
+	}()
+
+	// 취소 함수를 등록하기 전에 들어온 취소 요청 확인
+	var cancelRequested bool
+	models.DB.Model(&models.DeploymentJob{}).Where("id = ?", job.ID).Select("cancel_requested").Scan(&cancelRequested)
+	if cancelRequested {
+		cancel()
+	}
+
+	log.Printf("🚀 배포 작업 실행: ID %d (%s, 서버 %d/%d대 처리됨)", job.ID, job.Type, job.Done, job.Total)
+	publishJobEvent(job, types.DeploymentEventJobStarted)
+
+	var results []types.DeploymentResult
+	if job.Results != "" {
+		if err := json.Unmarshal([]byte(job.Results), &results); err != nil {
+			finishDeploymentJob(job, models.JobStatusFailed, fmt.Sprintf("저장된 작업 결과를 읽을 수 없습니다: %v", err))
+			return
+		}
+	}
+	processed := make(map[uint]bool, len(results))
+	for _, result := range results {
+		processed[result.ServerID] = true
+	}
+
+	process, serverIDs, options, err := deploymentJobRunner(job)
+	if err != nil {
+		finishDeploymentJob(job, models.JobStatusFailed, err.Error())
+		return
+	}
+
+	// 남은 서버 조회 (작업 등록 후 삭제된 서버는 실패로 기록)
+	var pendingIDs []uint
+	for _, serverID := range serverIDs {
+		if !processed[serverID] {
+			pendingIDs = append(pendingIDs, serverID)
+		}
+	}
+	var servers []models.Server
+	if len(pendingIDs) > 0 {
+		if err := models.DB.Where("id IN ? AND user_id = ?", pendingIDs, job.UserID).Order("id").Find(&servers).Error; err != nil {
+			finishDeploymentJob(job, models.JobStatusFailed, err.Error())
+			return
+		}
+	}
+	found := make(map[uint]bool, len(servers))
+	for _, server := range servers {
+		found[server.ID] = true
+	}
+	action := deploymentJobAction(job)
+	for _, serverID := range pendingIDs {
+		if found[serverID] {
+			continue
+		}
+		result := types.DeploymentResult{ServerID: serverID, Status: "failed", ErrorMessage: "서버를 찾을 수 없습니다"}
+		results = append(results, result)
+		saveDeploymentJobProgress(job, results)
+		publishServerResult(job.UserID, job.ID, action, result)
+	}
+
+	// 남은 서버를 동시에 처리 (전체 기한은 배포 옵션의 Timeout, 재시작 후에는 다시 적용)
+	runCtx, runCancel := deploymentContext(ctx, options)
+	defer runCancel()
+	runServerDeployments(runCtx, job.UserID, job.ID, action, servers,
+		process,
+		func(index int, result types.DeploymentResult) {
+			results = append(results, result)
+			saveDeploymentJobProgress(job, results)
+		})
+
+	if ctx.Err() != nil && len(results) < len(serverIDs) {
+		finishDeploymentJob(job, models.JobStatusCancelled, "")
+		return
+	}
+	finishDeploymentJob(job, models.JobStatusCompleted, "")
+}
+
+// deploymentJobRunner는 작업 요청을 읽어 서버 한 대를 처리하는 함수, 대상 서버 ID 목록, 배포 옵션을 반환합니다.
+// 배포할 키는 실행 시점에 다시 확인하므로, 그 사이 폐기된 키는 배포하지 않습니다.
+func deploymentJobRunner(job *models.DeploymentJob) (func(ctx context.Context, server models.Server) types.DeploymentResult, []uint, types.DeploymentOptions, error) {
+	switch job.Type {
+	case models.DeploymentJobTypeDeploy:
+		var req types.KeyDeploymentRequest
+		if err := json.Unmarshal([]byte(job.Request), &req); err != nil {
+			return nil, nil, types.DeploymentOptions{}, fmt.Errorf("작업 요청을 읽을 수 없습니다: %v", err)
+		}
+		sshKey, err := resolveDeploymentKey(job.UserID, req.SSHKeyID)
+		if err != nil {
+			return nil, nil, types.DeploymentOptions{}, err
+		}
+		process := func(ctx context.Context, server models.Server) types.DeploymentResult {
+			return deployKeyToServer(ctx, job.UserID, sshKey, server, req.Options)
+		}
+		return process, req.ServerIDs, req.Options, nil
+
+	case models.DeploymentJobTypeUndeploy:
+		var req types.KeyUndeployRequest
+		if err := json.Unmarshal([]byte(job.Request), &req); err != nil {
+			return nil, nil, types.DeploymentOptions{}, fmt.Errorf("작업 요청을 읽을 수 없습니다: %v", err)
+		}
+		sshKey, err := resolveUndeployKey(job.UserID, req.SSHKeyID)
+		if err != nil {
+			return nil, nil, types.DeploymentOptions{}, err
+		}
+		process := func(ctx context.Context, server models.Server) types.DeploymentResult {
+			return undeployKeyFromServer(ctx, sshKey, server)
+		}
+		return process, req.ServerIDs, types.DeploymentOptions{}, nil
+	}
+
+	return nil, nil, types.DeploymentOptions{}, fmt.Errorf("지원하지 않는 작업 종류입니다: %s", job.Type)
+}
+
+// saveDeploymentJobProgress는 지금까지의 서버별 결과와 진행 수를 저장합니다.
+func saveDeploymentJobProgress(job *models.DeploymentJob, results []types.DeploymentResult) {
+	encoded, err := json.Marshal(results)
+	if err != nil {
+		log.Printf("❌ 배포 작업 결과 변환 실패 (ID %d): %v", job.ID, err)
+		return
+	}
+
+	job.Results = string(encoded)
+	job.Done = len(results)
+	job.Succeeded, job.Failed = 0, 0
+	for _, result := range results {
+		if result.Status == "success" {
+			job.Succeeded++
+		} else {
+			job.Failed++
+		}
+	}
+
+	if err := models.DB.Model(job).Select("results", "done", "succeeded", "failed").Updates(job).Error; err != nil {
+		log.Printf("❌ 배포 작업 진행 상황 저장 실패 (ID %d): %v", job.ID, err)
+	}
+}
+
+// finishDeploymentJob은 작업을 끝난 상태로 저장하고 사용자에게 알립니다.
+func finishDeploymentJob(job *models.DeploymentJob, status, errorMsg string) {
+	now := time.Now()
+	job.Status = status
+	job.ErrorMsg = errorMsg
+	job.CompletedAt = &now
+	if err := models.DB.Model(job).Select("status", "error_msg", "completed_at").Updates(job).Error; err != nil {
+		log.Printf("❌ 배포 작업 상태 저장 실패 (ID %d): %v", job.ID, err)
+	}
+	publishJobEvent(job, types.DeploymentEventJobFinished)
+
+	action := "배포"
+	if job.Type == models.DeploymentJobTypeUndeploy {
+		action = "제거"
+	}
+
+	switch status {
+	case models.JobStatusCompleted:
+		log.Printf("🎯 배포 작업 완료: ID %d (성공 %d/%d)", job.ID, job.Succeeded, job.Total)
+		notificationType := models.NotificationTypeSuccess
+		if job.Failed > 0 {
+			notificationType = models.NotificationTypeWarning
+		}
+		notifyUser(job.UserID, notificationType, fmt.Sprintf("키 %s 작업 완료", action),
+			fmt.Sprintf("작업 %d: 서버 %d대 중 성공 %d대, 실패 %d대", job.ID, job.Total, job.Succeeded, job.Failed))
+	case models.JobStatusCancelled:
+		log.Printf("🛑 배포 작업 취소됨: ID %d (%d/%d대 처리)", job.ID, job.Done, job.Total)
+	default:
+		log.Printf("❌ 배포 작업 실패: ID %d: %s", job.ID, errorMsg)
+		notifyUser(job.UserID, models.NotificationTypeError, fmt.Sprintf("키 %s 작업 실패", action),
+			fmt.Sprintf("작업 %d: %s", job.ID, errorMsg))
+	}
+}
+
+// deploymentJobKey는 배포 작업 ID를 컨텍스트에 담는 키입니다 (재시도 이벤트에 작업 ID를 붙일 때 사용).
+type deploymentJobKey struct{}
+
+// withDeploymentJobID는 배포 작업 ID를 담은 컨텍스트를 반환합니다.
+func withDeploymentJobID(ctx context.Context, jobID uint) context.Context {
+	return context.WithValue(ctx, deploymentJobKey{}, jobID)
+}
+
+// deploymentJobIDFromContext는 컨텍스트의 배포 작업 ID를 반환합니다 (없으면 0).
+func deploymentJobIDFromContext(ctx context.Context) uint {
+	jobID, _ := ctx.Value(deploymentJobKey{}).(uint)
+	return jobID
+}
+
+// deploymentJobAction은 작업 종류에 해당하는 배포 기록 작업(deploy, remove)을 반환합니다.
+func deploymentJobAction(job *models.DeploymentJob) string {
+	if job.Type == models.DeploymentJobTypeUndeploy {
+		return models.DeploymentActionRemove
+	}
+	return models.DeploymentActionDeploy
+}
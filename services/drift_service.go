@@ -0,0 +1,329 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/config"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var driftSchedulerOnce sync.Once
+
+// StartDriftScanScheduler는 드리프트 검사를 주기적으로 실행하는 백그라운드 작업을 시작합니다.
+// DRIFT_SCAN_INTERVAL이 0이면 시작하지 않으며, 여러 번 호출해도 한 번만 시작됩니다.
+func StartDriftScanScheduler() {
+	driftSchedulerOnce.Do(func() {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Printf("⚠️ 설정 로드 실패, 드리프트 검사 스케줄러를 시작하지 않습니다: %v", err)
+			return
+		}
+		if cfg.DriftScanInterval <= 0 {
+			log.Printf("📋 드리프트 검사 스케줄러 비활성화됨 (DRIFT_SCAN_INTERVAL=0)")
+			return
+		}
+
+		log.Printf("⏰ 드리프트 검사 스케줄러 시작 (주기: %s)", cfg.DriftScanInterval)
+
+		go func() {
+			ticker := time.NewTicker(cfg.DriftScanInterval)
+			defer ticker.Stop()
+
+			for {
+				// 서버 시작 직후가 아닌 첫 주기부터 검사
+				<-ticker.C
+				if _, err := RunDriftScan(types.DriftScanRequest{}); err != nil {
+					log.Printf("❌ 드리프트 검사 실패: %v", err)
+				}
+			}
+		}()
+	})
+}
+
+// RunDriftScan은 서버들의 authorized_keys를 배포 기록과 비교하고 결과를 저장합니다 (관리자, 스케줄러용).
+// 서버를 지정하지 않으면 사용 중인(active, host_key_changed) 모든 서버를 검사합니다.
+func RunDriftScan(req types.DriftScanRequest) (*types.DriftScanSummary, error) {
+	if models.DB == nil {
+		return nil, errors.New("데이터베이스가 초기화되지 않았습니다")
+	}
+
+	query := models.DB.Model(&models.Server{})
+	if len(req.ServerIDs) > 0 {
+		query = query.Where("id IN ?", req.ServerIDs)
+	} else {
+		query = query.Where("status <> ?", models.ServerStatusInactive)
+	}
+	return scanServersForDrift(query)
+}
+
+// RunUserDriftScan은 사용자가 등록한 서버들의 드리프트를 검사합니다.
+func RunUserDriftScan(userID uint, req types.DriftScanRequest) (*types.DriftScanSummary, error) {
+	query := models.DB.Model(&models.Server{}).Where("user_id = ?", userID)
+	if len(req.ServerIDs) > 0 {
+		query = query.Where("id IN ?", req.ServerIDs)
+	} else {
+		query = query.Where("status <> ?", models.ServerStatusInactive)
+	}
+	return scanServersForDrift(query)
+}
+
+// GetDriftReports는 서버별 최신 드리프트 검사 결과를 조회합니다 (관리자 전용).
+func GetDriftReports() ([]types.ServerDriftReportResponse, error) {
+	return latestDriftReports(0)
+}
+
+// GetUserDriftReports는 사용자 서버의 최신 드리프트 검사 결과를 조회합니다.
+func GetUserDriftReports(userID uint) ([]types.ServerDriftReportResponse, error) {
+	return latestDriftReports(userID)
+}
+
+// latestDriftReports는 서버별 최신 검사 결과를 조회합니다. userID가 0이면 모든 서버를 조회합니다.
+func latestDriftReports(userID uint) ([]types.ServerDriftReportResponse, error) {
+	latest := models.DB.Model(&models.ServerDriftReport{}).Select("MAX(id)").Group("server_id")
+	query := models.DB.Preload("Server").Where("id IN (?)", latest)
+	if userID != 0 {
+		query = query.Where("server_id IN (?)", models.DB.Model(&models.Server{}).Select("id").Where("user_id = ?", userID))
+	}
+
+	var reports []models.ServerDriftReport
+	if err := query.Order("server_id").Find(&reports).Error; err != nil {
+		log.Printf("❌ 드리프트 검사 결과 조회 실패: %v", err)
+		return nil, err
+	}
+
+	responses := make([]types.ServerDriftReportResponse, 0, len(reports))
+	for _, report := range reports {
+		responses = append(responses, types.ToServerDriftReportResponse(report))
+	}
+	return responses, nil
+}
+
+// scanServersForDrift는 조회된 서버들을 순서대로 검사하고 요약을 반환합니다.
+func scanServersForDrift(query *gorm.DB) (*types.DriftScanSummary, error) {
+	var servers []models.Server
+	if err := query.Order("id").Find(&servers).Error; err != nil {
+		log.Printf("❌ 드리프트 검사 대상 서버 조회 실패: %v", err)
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("검사할 서버를 찾을 수 없습니다")
+	}
+
+	summary := &types.DriftScanSummary{ScannedAt: time.Now(), Total: len(servers)}
+	log.Printf("🔍 드리프트 검사 시작 (서버 %d대)", len(servers))
+
+	for i := range servers {
+		report := scanServerDrift(&servers[i])
+		switch report.Status {
+		case models.DriftStatusClean:
+			summary.Clean++
+		case models.DriftStatusDrift:
+			summary.Drift++
+		default:
+			summary.Failed++
+		}
+		report.Server = servers[i]
+		summary.Reports = append(summary.Reports, types.ToServerDriftReportResponse(*report))
+
+		// 독점 관리 서버는 드리프트를 바로 정리
+		if report.Status == models.DriftStatusDrift && servers[i].ManagedExclusively {
+			enforceManagedServer(&servers[i])
+		}
+	}
+
+	log.Printf("🎯 드리프트 검사 완료: 일치 %d, 드리프트 %d, 실패 %d", summary.Clean, summary.Drift, summary.Failed)
+	return summary, nil
+}
+
+// scanServerDrift는 서버 하나의 authorized_keys를 읽어 배포 기록과 비교하고 결과를 저장합니다.
+func scanServerDrift(server *models.Server) *models.ServerDriftReport {
+	report := &models.ServerDriftReport{ServerID: server.ID, ScannedAt: time.Now()}
+
+	entries, err := detectServerDrift(server)
+	if err != nil {
+		report.Status = models.DriftStatusError
+		report.ErrorMsg = err.Error()
+		log.Printf("❌ 드리프트 검사 실패 [%s]: %v", server.Name, err)
+	} else {
+		report.Status = models.DriftStatusClean
+		for _, entry := range entries {
+			switch entry.Kind {
+			case types.DriftKindMissing:
+				report.MissingCount++
+			case types.DriftKindStale:
+				report.StaleCount++
+			default:
+				report.ForeignCount++
+			}
+		}
+		if len(entries) > 0 {
+			report.Status = models.DriftStatusDrift
+			details, _ := json.Marshal(entries)
+			report.Details = string(details)
+			log.Printf("⚠️ 드리프트 감지 [%s]: 누락 %d, 미제거 %d, 외부 키 %d", server.Name, report.MissingCount, report.StaleCount, report.ForeignCount)
+		}
+	}
+
+	// 새로 드리프트가 생긴 경우에만 서버 소유자에게 알림
+	var previous models.ServerDriftReport
+	hadDrift := models.DB.Where("server_id = ?", server.ID).Order("id DESC").First(&previous).Error == nil &&
+		previous.Status == models.DriftStatusDrift
+	if report.Status == models.DriftStatusDrift && !hadDrift {
+		notifyUser(server.UserID, models.NotificationTypeWarning, "서버 authorized_keys 드리프트 감지",
+			fmt.Sprintf("서버 '%s'의 authorized_keys가 배포 기록과 다릅니다 (누락 %d, 미제거 %d, 외부 키 %d).",
+				server.Name, report.MissingCount, report.StaleCount, report.ForeignCount))
+	}
+
+	if err := models.DB.Create(report).Error; err != nil {
+		log.Printf("❌ 드리프트 검사 결과 저장 실패 [%s]: %v", server.Name, err)
+	}
+	return report
+}
+
+// detectServerDrift는 서버의 authorized_keys와 배포 기록의 차이를 찾습니다.
+//   - missing: 마지막 성공 기록이 배포이고 사용 가능한 키인데 서버에 없음
+//   - stale: 마지막 성공 기록이 제거이거나, 키가 폐기/만료/삭제되었는데 서버에 남아 있음
+//   - foreign: 이 계정에 배포한 기록이 없는 키 (등록된 다른 키이면 키 정보를 함께 표시)
+func detectServerDrift(server *models.Server) ([]types.DriftEntry, error) {
+	deployer, err := serverDeployer(server)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := deployer.List(context.Background())
+	if err != nil {
+		return nil, checkRemoteError(server, err)
+	}
+	present, invalid := utils.ParseAuthorizedKeyLines(lines)
+
+	// 키별 마지막 성공 작업 (같은 계정을 가리키는 서버 등록의 배포 기록을 순서대로 적용)
+	serverIDs, err := accountServerIDs(server)
+	if err != nil {
+		return nil, err
+	}
+	lastAction, err := lastDeploymentActions(serverIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 서버에 있는 키와 배포 기록의 키를 함께 조회 (삭제된 키 포함)
+	fingerprints := make([]string, 0, len(present))
+	for _, line := range present {
+		fingerprints = append(fingerprints, line.Fingerprint)
+	}
+	historyKeyIDs := make([]uint, 0, len(lastAction))
+	for keyID := range lastAction {
+		historyKeyIDs = append(historyKeyIDs, keyID)
+	}
+	var keys []models.SSHKey
+	if len(fingerprints) > 0 || len(historyKeyIDs) > 0 {
+		if err := models.DB.Unscoped().
+			Where("id IN ? OR fingerprint_sha256 IN ?", append(historyKeyIDs, 0), append(fingerprints, "")).
+			Find(&keys).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	keysByFingerprint := make(map[string]*models.SSHKey)
+	for i := range keys {
+		key := &keys[i]
+		if key.FingerprintSHA256 == "" {
+			if fp, err := utils.ComputeFingerprints(key.PublicKey); err == nil {
+				key.FingerprintSHA256 = fp.SHA256
+			}
+		}
+		// 같은 공개키가 여러 행이면 이 서버에 기록이 있는 행을 우선
+		if existing, ok := keysByFingerprint[key.FingerprintSHA256]; !ok || (lastAction[existing.ID] == "" && lastAction[key.ID] != "") {
+			keysByFingerprint[key.FingerprintSHA256] = key
+		}
+	}
+
+	// 관리 도구 자신의 접속 키는 독점 관리 모드에서도 유지하므로 드리프트로 보지 않음 (planReconcile과 같음)
+	managerKeys := make(map[string]bool)
+	for _, fingerprint := range utils.ManagerKeyFingerprints() {
+		managerKeys[fingerprint] = true
+	}
+
+	var entries []types.DriftEntry
+	presentSet := make(map[string]bool)
+	for _, line := range present {
+		presentSet[line.Fingerprint] = true
+		if managerKeys[line.Fingerprint] {
+			continue
+		}
+
+		entry := types.DriftEntry{Fingerprint: line.Fingerprint, Algorithm: line.Algorithm, Comment: line.Comment}
+		key, known := keysByFingerprint[line.Fingerprint]
+		if known {
+			entry.KeyID = &key.ID
+			entry.KeyName = key.Name
+			entry.KeyOwnerID = &key.UserID
+		}
+
+		switch {
+		case !known:
+			entry.Kind = types.DriftKindForeign
+			entry.Reason = "등록되지 않은 키"
+		case lastAction[key.ID] == "":
+			entry.Kind = types.DriftKindForeign
+			entry.Reason = "이 계정에 배포한 기록이 없는 등록된 키"
+		case lastAction[key.ID] == models.DeploymentActionRemove:
+			entry.Kind = types.DriftKindStale
+			entry.Reason = "배포 기록상 제거된 키"
+		default:
+			if reason := unusableKeyReason(key, now); reason != "" {
+				entry.Kind = types.DriftKindStale
+				entry.Reason = reason
+			}
+		}
+		if entry.Kind != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	for _, line := range invalid {
+		if len(line) > 80 {
+			line = line[:80] + "..."
+		}
+		entries = append(entries, types.DriftEntry{Kind: types.DriftKindForeign, Comment: line, Reason: "해석할 수 없는 키 줄"})
+	}
+
+	for i := range keys {
+		key := &keys[i]
+		if lastAction[key.ID] != models.DeploymentActionDeploy || presentSet[key.FingerprintSHA256] || unusableKeyReason(key, now) != "" {
+			continue
+		}
+		entries = append(entries, types.DriftEntry{
+			Kind:        types.DriftKindMissing,
+			Fingerprint: key.FingerprintSHA256,
+			Algorithm:   key.Algorithm,
+			KeyID:       &key.ID,
+			KeyName:     key.Name,
+			KeyOwnerID:  &key.UserID,
+			Reason:      "배포 기록상 있어야 하는 키",
+		})
+	}
+	return entries, nil
+}
+
+// unusableKeyReason은 키가 서버에 남아 있으면 안 되는 이유(삭제, 폐기, 만료)를 반환합니다. 사용 가능하면 빈 값입니다.
+func unusableKeyReason(key *models.SSHKey, now time.Time) string {
+	switch {
+	case key.DeletedAt.Valid:
+		return "삭제된 키"
+	case key.Status != models.KeyStatusActive:
+		return "폐기된 키"
+	case isKeyExpired(key, now):
+		return "만료된 키"
+	}
+	return ""
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/config"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// 호스트 키 조회 시 접속 타임아웃
+const hostKeyScanTimeout = 10 * time.Second
+
+// SignServerHostKey는 등록된 서버의 호스트 키를 호스트 CA로 서명합니다 (관리자 전용).
+// 요청에 호스트 공개키가 없으면 서버에 접속하여 호스트 키를 조회하고, 기록된(고정된) 호스트 키와 일치하는 키만 서명합니다.
+// 서명 후 관리 도구의 known_hosts를 갱신하여 이후 접속 시 인증서로 서버를 검증합니다.
+func SignServerHostKey(adminUserID, serverID uint, req types.HostCertificateRequest) (*types.HostCertificateResponse, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var server models.Server
+	if err := models.DB.First(&server, serverID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("서버를 찾을 수 없습니다")
+		}
+		return nil, err
+	}
+
+	// 호스트 키 결정
+	var hostKey ssh.PublicKey
+	source := "request"
+	if strings.TrimSpace(req.HostPublicKey) != "" {
+		hostKey, _, _, err = utils.ParsePublicKeyAnyFormat([]byte(strings.TrimSpace(req.HostPublicKey)))
+		if err != nil {
+			return nil, err
+		}
+		if _, isCert := hostKey.(*ssh.Certificate); isCert {
+			return nil, errors.New("인증서가 아닌 호스트 공개키(ssh_host_*_key.pub)를 입력해주세요")
+		}
+	} else {
+		// 조회한 키는 인증되지 않았으므로(중간자가 자신의 키를 제시할 수 있음) 기록된 호스트 키와 일치할 때만 서명
+		if server.HostKeyFingerprint == "" || server.Status == models.ServerStatusHostKeyChanged {
+			return nil, errors.New("기록된 호스트 키가 없거나 변경 승인 대기 중인 서버입니다. 서버에서 확인한 호스트 공개키(host_public_key)를 입력해주세요")
+		}
+		source = "scan"
+		log.Printf("🔍 호스트 키 조회 중: %s:%d", server.Host, server.Port)
+		keys, err := utils.FetchHostKeys(server.Host, server.Port, hostKeyScanTimeout)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if ssh.FingerprintSHA256(key) == server.HostKeyFingerprint {
+				hostKey = key
+				break
+			}
+		}
+		if hostKey == nil {
+			log.Printf("🚨 조회한 호스트 키가 기록된 키와 다릅니다: %s:%d (기록된 키: %s)", server.Host, server.Port, server.HostKeyFingerprint)
+			return nil, fmt.Errorf("조회한 호스트 키가 기록된 키(%s)와 일치하지 않아 서명할 권한이 없습니다. 서버에서 확인한 호스트 공개키(host_public_key)를 입력해주세요", server.HostKeyFingerprint)
+		}
+	}
+
+	principals, err := hostCertificatePrincipals(server, req.Principals)
+	if err != nil {
+		return nil, err
+	}
+
+	validFor := cfg.HostCertTTL
+	if strings.TrimSpace(req.ValidFor) != "" {
+		validFor, err = time.ParseDuration(strings.TrimSpace(req.ValidFor))
+		if err != nil || validFor <= 0 {
+			return nil, fmt.Errorf("유효하지 않은 인증서 유효 기간입니다: %s (예: 720h, 8760h)", req.ValidFor)
+		}
+	}
+	if validFor > cfg.HostCertMaxTTL {
+		return nil, fmt.Errorf("유효하지 않은 인증서 유효 기간입니다: 호스트 인증서는 최대 %s까지 가능합니다", cfg.HostCertMaxTTL)
+	}
+
+	ca, caSigner, err := getCertificateAuthority(models.CATypeHost)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := utils.NewCertificateSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	validAfter := now.Add(-utils.CertificateClockSkew)
+	validBefore := now.Add(validFor)
+	keyID := fmt.Sprintf("host:%s:%d", server.Host, server.Port)
+
+	cert, err := utils.SignCertificate(caSigner, hostKey, utils.CertificateOptions{
+		CertType:    ssh.HostCert,
+		KeyID:       keyID,
+		Serial:      serial,
+		Principals:  principals,
+		ValidAfter:  validAfter,
+		ValidBefore: validBefore,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	record := models.SSHCertificate{
+		CAID:        ca.ID,
+		CertType:    models.CATypeHost,
+		Serial:      serial,
+		KeyID:       keyID,
+		UserID:      adminUserID,
+		ServerID:    &server.ID,
+		Principals:  strings.Join(principals, ","),
+		Fingerprint: ssh.FingerprintSHA256(hostKey),
+		ValidAfter:  validAfter,
+		ValidBefore: validBefore,
+		Certificate: utils.FormatCertificate(cert, server.Host),
+	}
+	if err := models.DB.Create(&record).Error; err != nil {
+		log.Printf("❌ 호스트 인증서 기록 저장 실패: %v", err)
+		return nil, errors.New("호스트 인증서 발급 중 오류가 발생했습니다")
+	}
+
+	// 관리자가 직접 입력한 호스트 키는 확인된 키이므로, 기록된 키가 없으면 이 키를 기록
+	if server.HostKeyFingerprint == "" && source == "request" {
+		if err := pinServerHostKey(&server, hostKey); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}
+
+	log.Printf("📜 호스트 인증서 발급 완료 (서버: %s, 호스트 키: %s, 일련번호: %d, principal: %s)",
+		server.Name, record.Fingerprint, serial, record.Principals)
+
+	if err := RefreshManagerKnownHosts(); err != nil {
+		log.Printf("⚠️ 관리용 known_hosts 갱신 실패: %v", err)
+	}
+
+	certPath := hostCertificatePath(hostKey)
+	response := &types.HostCertificateResponse{
+		CertificateResponse: types.ToCertificateResponse(record),
+		HostKeySource:       source,
+		Instructions: []string{
+			fmt.Sprintf("인증서를 서버의 %s 파일로 저장하세요", certPath),
+			fmt.Sprintf("sshd_config에 'HostCertificate %s'를 추가하고 sshd를 다시 시작하세요", certPath),
+			"클라이언트는 GET /api/certificates/known_hosts로 받은 @cert-authority 줄을 ~/.ssh/known_hosts에 추가하세요",
+		},
+	}
+	response.CAPublicKey = ca.PublicKey
+	response.CAFingerprint = ca.Fingerprint
+	return response, nil
+}
+
+// GenerateKnownHosts는 호스트 CA를 신뢰하는 known_hosts 파일 내용을 만듭니다.
+// 기본적으로 유효한 호스트 인증서가 발급된 서버에만 CA를 적용하며, allHosts가 true면 모든 호스트(*)에 적용합니다.
+func GenerateKnownHosts(allHosts bool) (string, error) {
+	ca, _, err := getCertificateAuthority(models.CATypeHost)
+	if err != nil {
+		return "", err
+	}
+
+	var patterns []string
+	if !allHosts {
+		patterns, err = signedHostPatterns()
+		if err != nil {
+			return "", err
+		}
+		if len(patterns) == 0 {
+			return "", errors.New("호스트 인증서가 발급된 서버를 찾을 수 없습니다. 서버 호스트 키를 먼저 서명하거나 scope=all을 사용하세요")
+		}
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "# SSH Key Manager known_hosts (생성: %s)\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&content, "# 호스트 CA: %s\n", ca.Fingerprint)
+	content.WriteString(utils.FormatCertAuthorityLine(patterns, ca.PublicKey) + "\n")
+	return content.String(), nil
+}
+
+// RefreshManagerKnownHosts는 관리 도구가 원격 서버에 접속할 때 사용하는 known_hosts 파일을 갱신합니다.
+// 호스트 인증서가 발급된 서버는 CA로 검증하고, 나머지 서버는 연결 테스트에서 기록된 호스트 키로 검증합니다.
+func RefreshManagerKnownHosts() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(cfg.KnownHostsFile) == "" {
+		log.Printf("📋 관리용 known_hosts 비활성화됨 (SSH_KNOWN_HOSTS_FILE 미설정, 호스트 키를 검증하지 않습니다)")
+		return nil
+	}
+
+	var certAuthorityLine string
+	patterns, err := signedHostPatterns()
+	if err != nil {
+		return err
+	}
+	if len(patterns) > 0 {
+		ca, _, err := getCertificateAuthority(models.CATypeHost)
+		if err != nil {
+			return err
+		}
+		certAuthorityLine = utils.FormatCertAuthorityLine(patterns, ca.PublicKey)
+	}
+
+	if err := utils.WriteKnownHostsFile(cfg.KnownHostsFile, certAuthorityLine); err != nil {
+		return err
+	}
+
+	log.Printf("🔐 관리용 known_hosts 갱신 완료: %s (CA 적용 서버: %d대)", cfg.KnownHostsFile, len(patterns))
+	return nil
+}
+
+// signedHostPatterns는 유효한 호스트 인증서가 있는 서버의 known_hosts 패턴 목록을 반환합니다.
+func signedHostPatterns() ([]string, error) {
+	var servers []models.Server
+	err := models.DB.Model(&models.Server{}).
+		Where("id IN (?)", models.DB.Model(&models.SSHCertificate{}).
+			Select("server_id").
+			Where("cert_type = ? AND revoked_at IS NULL AND valid_before > ?", models.CATypeHost, time.Now())).
+		Order("id").Find(&servers).Error
+	if err != nil {
+		log.Printf("❌ 호스트 인증서 서버 조회 실패: %v", err)
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var patterns []string
+	for _, server := range servers {
+		pattern := utils.KnownHostsPattern(server.Host, server.Port)
+		if !seen[pattern] {
+			seen[pattern] = true
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns, nil
+}
+
+// hostCertificatePrincipals는 호스트 인증서 principal 목록을 만듭니다 (서버 호스트 + 추가 호스트명).
+func hostCertificatePrincipals(server models.Server, extra []string) ([]string, error) {
+	principals := []string{server.Host}
+	seen := map[string]bool{server.Host: true}
+
+	for _, name := range extra {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if strings.ContainsAny(name, " ,*?!") {
+			return nil, fmt.Errorf("유효하지 않은 호스트명입니다: %s", name)
+		}
+		seen[name] = true
+		principals = append(principals, name)
+	}
+	return principals, nil
+}
+
+// hostCertificatePath는 호스트 키 종류에 맞는 서버의 인증서 파일 경로를 반환합니다.
+func hostCertificatePath(hostKey ssh.PublicKey) string {
+	name := "rsa"
+	switch hostKey.Type() {
+	case ssh.KeyAlgoED25519:
+		name = "ed25519"
+	case ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521:
+		name = "ecdsa"
+	}
+	return fmt.Sprintf("/etc/ssh/ssh_host_%s_key-cert.pub", name)
+}
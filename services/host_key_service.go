@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// ErrHostKeyChanged는 서버가 기록된 호스트 키와 다른 키를 제시했을 때 반환됩니다.
+// 중간자 공격일 수 있으므로 관리자가 새 키를 승인하기 전까지 해당 서버에 접속하지 않습니다.
+var ErrHostKeyChanged = errors.New("서버 호스트 키가 변경되었습니다. 관리자 승인 전까지 접속할 수 없습니다")
+
+// ErrHostKeyNotPinned는 호스트 키가 기록되지 않은 서버에 접속하려 할 때 반환됩니다.
+// 호스트 키는 연결 테스트(TestServerConnection)나 호스트 인증서 서명처럼 명시적인 확인 과정에서만 기록합니다.
+var ErrHostKeyNotPinned = errors.New("호스트 키가 기록되지 않은 서버입니다. 연결 테스트로 호스트 키를 확인하기 전에는 접속할 권한이 없습니다")
+
+// serverSSHTarget은 서버에 접속할 SSH 대상을 만듭니다.
+// 호스트 키 변경으로 차단된 서버와 호스트 키가 기록되지 않은 서버는 접속하지 않습니다.
+// 기록된 키는 SSH 핸드셰이크 중에 검증되며, 불일치는 checkRemoteError로 처리합니다.
+func serverSSHTarget(server *models.Server) (utils.SSHTarget, error) {
+	if server.Status == models.ServerStatusHostKeyChanged {
+		return utils.SSHTarget{}, fmt.Errorf("%w (서버: %s)", ErrHostKeyChanged, server.Name)
+	}
+	if server.HostKeyFingerprint == "" {
+		return utils.SSHTarget{}, fmt.Errorf("%w (서버: %s)", ErrHostKeyNotPinned, server.Name)
+	}
+
+	return utils.SSHTarget{
+		Host:               server.Host,
+		Port:               server.Port,
+		Username:           server.Username,
+		HostKeyType:        server.HostKeyType,
+		HostKeyFingerprint: server.HostKeyFingerprint,
+	}, nil
+}
+
+// pinHostKeyOnFirstTest는 연결 테스트에서 원격 방식 서버에 기록된 호스트 키가 없으면
+// 서버가 제시한 키를 기록하고(첫 접속 신뢰, TOFU) true를 반환합니다.
+func pinHostKeyOnFirstTest(server *models.Server) (bool, error) {
+	switch server.DeployMethod {
+	case models.DeployMethodPull, models.DeployMethodLocal, models.DeployMethodSimulated:
+		return false, nil
+	}
+	if server.HostKeyFingerprint != "" || server.Status == models.ServerStatusHostKeyChanged {
+		return false, nil
+	}
+
+	keys, err := utils.FetchHostKeys(server.Host, server.Port, hostKeyScanTimeout)
+	if err != nil {
+		return false, err
+	}
+	if err := pinServerHostKey(server, utils.PreferredHostKey(keys)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkRemoteError는 원격 작업 오류가 호스트 키 불일치이면 서버를 host_key_changed 상태로 바꾸고
+// 보안 이벤트를 남긴 뒤 ErrHostKeyChanged로 감싸 반환합니다. 다른 오류는 그대로 반환합니다.
+func checkRemoteError(server *models.Server, err error) error {
+	var sshErr *utils.SSHError
+	if !errors.As(err, &sshErr) || sshErr.Kind != utils.SSHErrorHostKeyMismatch || sshErr.PresentedHostKey == nil {
+		return err
+	}
+
+	markHostKeyChanged(server, sshErr.PresentedHostKey)
+	return fmt.Errorf("%w (서버: %s, 기록된 키: %s, 현재 키: %s)",
+		ErrHostKeyChanged, server.Name, server.HostKeyFingerprint, ssh.FingerprintSHA256(sshErr.PresentedHostKey))
+}
+
+// pinServerHostKey는 서버의 호스트 키를 처음으로 기록합니다.
+func pinServerHostKey(server *models.Server, hostKey ssh.PublicKey) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"host_key_type":        hostKey.Type(),
+		"host_key_fingerprint": ssh.FingerprintSHA256(hostKey),
+		"host_key_pinned_at":   now,
+	}
+	if err := models.DB.Model(server).Updates(updates).Error; err != nil {
+		log.Printf("❌ 호스트 키 기록 실패 (서버 ID: %d): %v", server.ID, err)
+		return errors.New("호스트 키 기록 중 오류가 발생했습니다")
+	}
+
+	server.HostKeyType = hostKey.Type()
+	server.HostKeyFingerprint = ssh.FingerprintSHA256(hostKey)
+	server.HostKeyPinnedAt = &now
+	log.Printf("📌 호스트 키 기록: %s (%s:%d, %s %s)", server.Name, server.Host, server.Port, server.HostKeyType, server.HostKeyFingerprint)
+	return nil
+}
+
+// markHostKeyChanged는 호스트 키 변경을 기록하고 서버를 차단 상태로 바꿉니다.
+func markHostKeyChanged(server *models.Server, presented ssh.PublicKey) {
+	now := time.Now()
+	fingerprint := ssh.FingerprintSHA256(presented)
+	updates := map[string]interface{}{
+		"status":                       models.ServerStatusHostKeyChanged,
+		"pending_host_key_type":        presented.Type(),
+		"pending_host_key_fingerprint": fingerprint,
+		"host_key_changed_at":          now,
+	}
+	if err := models.DB.Model(server).Updates(updates).Error; err != nil {
+		log.Printf("❌ 호스트 키 변경 상태 저장 실패 (서버 ID: %d): %v", server.ID, err)
+	}
+
+	server.Status = models.ServerStatusHostKeyChanged
+	server.PendingHostKeyType = presented.Type()
+	server.PendingHostKeyFingerprint = fingerprint
+	server.HostKeyChangedAt = &now
+
+	details := fmt.Sprintf("서버 %s (%s:%d, ID %d) 기록된 키 %s %s, 현재 키 %s %s",
+		server.Name, server.Host, server.Port, server.ID, server.HostKeyType, server.HostKeyFingerprint, presented.Type(), fingerprint)
+	utils.LogSecurityEvent("호스트 키 변경 감지", server.UserID, details, "high")
+
+	notifyUser(server.UserID, models.NotificationTypeError, "서버 호스트 키 변경 감지",
+		fmt.Sprintf("서버 '%s'(%s:%d)의 호스트 키가 기록된 키와 다릅니다. 중간자 공격일 수 있어 관리자가 확인하기 전까지 배포와 접속이 중단됩니다. (현재 키: %s)",
+			server.Name, server.Host, server.Port, fingerprint))
+}
+
+// GetHostKeyChangedServers는 호스트 키 변경이 감지되어 승인을 기다리는 서버 목록을 조회합니다 (관리자 전용).
+func GetHostKeyChangedServers() ([]types.ServerResponse, error) {
+	var servers []models.Server
+	if err := models.DB.Where("status = ?", models.ServerStatusHostKeyChanged).
+		Order("host_key_changed_at DESC").Find(&servers).Error; err != nil {
+		log.Printf("❌ 호스트 키 변경 서버 조회 실패: %v", err)
+		return nil, err
+	}
+
+	responses := make([]types.ServerResponse, 0, len(servers))
+	for _, server := range servers {
+		responses = append(responses, types.ToServerResponse(server))
+	}
+	return responses, nil
+}
+
+// AcceptServerHostKey는 변경된 호스트 키를 승인하여 새 키로 기록하고 서버를 다시 사용 가능하게 합니다 (관리자 전용).
+// 요청한 핑거프린트가 감지된 키와 다르면(검토 이후 다시 바뀐 경우 등) 승인하지 않습니다.
+func AcceptServerHostKey(adminUserID, serverID uint, req types.HostKeyAcceptRequest) (*types.ServerResponse, error) {
+	var server models.Server
+	if err := models.DB.First(&server, serverID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("서버를 찾을 수 없습니다")
+		}
+		return nil, err
+	}
+
+	if server.Status != models.ServerStatusHostKeyChanged || server.PendingHostKeyFingerprint == "" {
+		return nil, errors.New("승인을 기다리는 호스트 키 변경을 찾을 수 없습니다")
+	}
+
+	fingerprint := strings.TrimSpace(req.Fingerprint)
+	if fingerprint == "" {
+		return nil, errors.New("승인할 호스트 키 핑거프린트를 입력해주세요")
+	}
+	if fingerprint != server.PendingHostKeyFingerprint {
+		return nil, fmt.Errorf("승인할 호스트 키 핑거프린트가 올바르지 않습니다 (감지된 키: %s)", server.PendingHostKeyFingerprint)
+	}
+
+	previous := server.HostKeyFingerprint
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":                       models.ServerStatusActive,
+		"host_key_type":                server.PendingHostKeyType,
+		"host_key_fingerprint":         server.PendingHostKeyFingerprint,
+		"host_key_pinned_at":           now,
+		"pending_host_key_type":        "",
+		"pending_host_key_fingerprint": "",
+		"host_key_changed_at":          nil,
+	}
+	if err := models.DB.Model(&server).Updates(updates).Error; err != nil {
+		log.Printf("❌ 호스트 키 승인 실패 (서버 ID: %d): %v", server.ID, err)
+		return nil, errors.New("호스트 키 승인 중 오류가 발생했습니다")
+	}
+	models.DB.First(&server, serverID)
+
+	log.Printf("✅ 호스트 키 변경 승인: %s (이전 키: %s, 새 키: %s, 관리자 ID: %d)", server.Name, previous, server.HostKeyFingerprint, adminUserID)
+
+	notifyUser(server.UserID, models.NotificationTypeInfo, "서버 호스트 키 변경 승인",
+		fmt.Sprintf("관리자가 서버 '%s'의 새 호스트 키(%s)를 승인했습니다. 배포와 접속이 다시 가능합니다.", server.Name, server.HostKeyFingerprint))
+
+	response := types.ToServerResponse(server)
+	return &response, nil
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/config"
+	"ssh-key-manager/models"
+	"ssh-key-manager/utils"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	masterKeyRing   *utils.MasterKeyRing
+	masterKeyRingMu sync.Mutex
+)
+
+// KeyRewrapResult는 마스터 키 교체 후 재래핑 작업 결과입니다.
+type KeyRewrapResult struct {
+	ActiveKeyID string `json:"active_key_id"`
+	Total       int    `json:"total"`
+	Rewrapped   int    `json:"rewrapped"`    // 이전 마스터 키에서 활성 마스터 키로 재래핑된 행
+	Encrypted   int    `json:"encrypted"`    // 평문으로 저장되어 있다가 새로 암호화된 행
+	Plaintext   int    `json:"plaintext"`    // 평문으로 남아 있는 행 (평문 암호화를 요청하지 않은 경우)
+	AlreadyDone int    `json:"already_done"` // 이미 활성 마스터 키로 래핑된 행
+	Failed      int    `json:"failed"`
+}
+
+// getMasterKeyRing은 설정에서 마스터 키 링을 로드합니다.
+// 로드에 성공한 키 링만 보관하므로, 설정을 고친 뒤에는 재시작 없이 다시 시도합니다.
+func getMasterKeyRing() (*utils.MasterKeyRing, error) {
+	masterKeyRingMu.Lock()
+	defer masterKeyRingMu.Unlock()
+
+	if masterKeyRing != nil {
+		return masterKeyRing, nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	ring, err := utils.LoadMasterKeyRing(cfg.MasterKeyFile, cfg.MasterKey, cfg.MasterKeyPrevious)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔐 마스터 키 로드 완료 (활성 키: %s, 전체 %d개)", ring.ActiveKeyID(), len(ring.KeyIDs()))
+	masterKeyRing = ring
+	return masterKeyRing, nil
+}
+
+// sshKeyEncryptedFields는 SSH 키의 암호화 대상 컬럼과 필드입니다.
+func sshKeyEncryptedFields(key *models.SSHKey) map[string]*string {
+	return map[string]*string{
+		"private_key": &key.PrivateKey,
+		"pem":         &key.PEM,
+		"ppk":         &key.PPK,
+	}
+}
+
+// sealKeyMaterial은 새 데이터 키를 만들어 개인키 필드(PrivateKey, PEM, PPK)를 암호화합니다.
+// 암호문을 행 ID에 묶으므로 저장된 행(ID가 있는 키)에만 사용할 수 있습니다.
+func sealKeyMaterial(key *models.SSHKey) error {
+	if key.ID == 0 {
+		return errors.New("저장되지 않은 키는 암호화할 수 없습니다")
+	}
+
+	ring, err := getMasterKeyRing()
+	if err != nil {
+		log.Printf("❌ 마스터 키 로드 실패: %v", err)
+		return errors.New("개인키 암호화용 마스터 키가 설정되지 않았습니다. 관리자에게 문의하세요")
+	}
+
+	dataKey, wrapped, err := ring.GenerateDataKey()
+	if err != nil {
+		return err
+	}
+
+	if err := encryptKeyFields(key, dataKey); err != nil {
+		return err
+	}
+
+	key.WrappedDataKey = wrapped
+	key.MasterKeyID = ring.ActiveKeyID()
+	return nil
+}
+
+// openKeyMaterial은 암호화된 개인키 필드를 복호화합니다.
+// 암호화되지 않은 개인키(데이터 키가 없거나 접두사 없는 값)는 변조일 수 있으므로 사용하지 않습니다.
+func openKeyMaterial(key *models.SSHKey) error {
+	fields := sshKeyEncryptedFields(key)
+
+	if key.WrappedDataKey == "" {
+		if hasKeyMaterial(key) {
+			log.Printf("🚨 암호화되지 않은 개인키가 저장되어 있습니다 (키 ID: %d). 사용하지 않습니다", key.ID)
+			utils.LogSecurityEvent("암호화되지 않은 개인키 감지", key.UserID, fmt.Sprintf("키 ID %d", key.ID), "high")
+			return errors.New("개인키가 암호화되어 있지 않아 사용할 수 없습니다. 관리자에게 문의하세요")
+		}
+		return nil
+	}
+
+	ring, err := getMasterKeyRing()
+	if err != nil {
+		log.Printf("❌ 마스터 키 로드 실패: %v", err)
+		return errors.New("개인키 복호화용 마스터 키가 설정되지 않았습니다. 관리자에게 문의하세요")
+	}
+
+	dataKey, err := ring.UnwrapDataKey(key.WrappedDataKey, key.MasterKeyID)
+	if err != nil {
+		log.Printf("❌ 데이터 키 언래핑 실패 (키 ID: %d): %v", key.ID, err)
+		return errors.New("개인키를 복호화할 수 없습니다")
+	}
+
+	for column, field := range fields {
+		plaintext, err := utils.DecryptWithDataKey(dataKey, *field, utils.EnvelopeContext("ssh_keys", key.ID, column))
+		if err != nil {
+			if errors.Is(err, utils.ErrEnvelopeNotEncrypted) {
+				log.Printf("🚨 암호화되지 않은 개인키 값이 저장되어 있습니다 (키 ID: %d, 컬럼: %s). 사용하지 않습니다", key.ID, column)
+				utils.LogSecurityEvent("암호화되지 않은 개인키 감지", key.UserID, fmt.Sprintf("키 ID %d, 컬럼 %s", key.ID, column), "high")
+			} else {
+				log.Printf("❌ 개인키 복호화 실패 (키 ID: %d, 컬럼: %s): %v", key.ID, column, err)
+			}
+			return errors.New("개인키를 복호화할 수 없습니다")
+		}
+		*field = plaintext
+	}
+
+	return nil
+}
+
+// hasKeyMaterial은 개인키 필드 중 값이 있는 것이 있는지 확인합니다.
+func hasKeyMaterial(key *models.SSHKey) bool {
+	for _, field := range sshKeyEncryptedFields(key) {
+		if *field != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptKeyFields는 개인키 필드를 주어진 데이터 키로 암호화합니다 (행 ID와 컬럼 이름을 AAD로 사용).
+func encryptKeyFields(key *models.SSHKey, dataKey []byte) error {
+	for column, field := range sshKeyEncryptedFields(key) {
+		ciphertext, err := utils.EncryptWithDataKey(dataKey, *field, utils.EnvelopeContext("ssh_keys", key.ID, column))
+		if err != nil {
+			return fmt.Errorf("개인키 암호화 실패: %w", err)
+		}
+		*field = ciphertext
+	}
+	return nil
+}
+
+// MigrateKeyEncryption은 시작 시 마스터 키를 확인하고, 이전 마스터 키로 래핑된 데이터 키를 활성 키로 재래핑합니다.
+// 평문으로 남은 개인키는 변조일 수 있으므로 시작할 때 암호화하지 않고 경고만 남깁니다.
+// 업그레이드 후 한 번 rewrap-keys -encrypt-plaintext로 명시적으로 변환해야 합니다.
+// 마스터 키가 없거나 재래핑하지 못한 행이 있으면 오류를 반환합니다 (해당 개인키는 사용할 수 없음).
+func MigrateKeyEncryption() error {
+	if _, err := getMasterKeyRing(); err != nil {
+		return fmt.Errorf("개인키 암호화용 마스터 키를 로드할 수 없습니다 (.env의 MASTER_KEY 또는 MASTER_KEY_FILE을 확인하세요): %w", err)
+	}
+
+	result, err := RewrapAllKeys(false, false)
+	if err != nil {
+		return err
+	}
+	if result.Plaintext > 0 {
+		log.Printf("🚨 암호화되지 않은 개인키가 %d건 있습니다. 사용되지 않으며, 확인 후 rewrap-keys -encrypt-plaintext로 암호화하세요", result.Plaintext)
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("개인키 %d건을 현재 마스터 키로 변환하지 못했습니다 (로그 확인 후 rewrap-keys로 다시 실행하세요)", result.Failed)
+	}
+	return nil
+}
+
+// RewrapAllKeys는 모든 SSH 키와 CA 키의 데이터 키를 활성 마스터 키로 다시 래핑합니다.
+// 마스터 키 교체 시 새 키를 활성 키로, 기존 키를 이전 키로 등록한 뒤 실행합니다.
+// 개인키 본문은 다시 암호화하지 않고 데이터 키만 재래핑합니다.
+// encryptPlaintext가 true이면 평문으로 남은 행을 행과 컬럼에 묶어 새로 암호화합니다 (업그레이드 후 한 번만 사용).
+func RewrapAllKeys(dryRun, encryptPlaintext bool) (*KeyRewrapResult, error) {
+	ring, err := getMasterKeyRing()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &KeyRewrapResult{ActiveKeyID: ring.ActiveKeyID()}
+	log.Printf("🔄 마스터 키 재래핑 시작 (활성 키: %s, dry-run: %t, 평문 암호화: %t)", result.ActiveKeyID, dryRun, encryptPlaintext)
+
+	var keys []models.SSHKey
+	batchErr := models.DB.Unscoped().Order("id").FindInBatches(&keys, 100, func(tx *gorm.DB, batch int) error {
+		for i := range keys {
+			key := &keys[i]
+			result.Total++
+
+			updates, action, err := rewrapKey(ring, key, encryptPlaintext)
+			if err != nil {
+				result.Failed++
+				log.Printf("❌ 키 재래핑 실패 (키 ID: %d): %v", key.ID, err)
+				continue
+			}
+
+			switch action {
+			case "skip":
+				result.AlreadyDone++
+				continue
+			case "plaintext":
+				result.Plaintext++
+				log.Printf("⚠️ 암호화되지 않은 개인키입니다 (키 ID: %d). 평문 암호화를 요청하지 않아 건너뜁니다", key.ID)
+				continue
+			case "rewrap":
+				result.Rewrapped++
+			case "encrypt":
+				result.Encrypted++
+			}
+
+			if dryRun {
+				continue
+			}
+			// updated_at은 변경하지 않음
+			if err := models.DB.Unscoped().Model(&models.SSHKey{}).Where("id = ?", key.ID).UpdateColumns(updates).Error; err != nil {
+				result.Failed++
+				log.Printf("❌ 키 저장 실패 (키 ID: %d): %v", key.ID, err)
+			}
+		}
+		return nil
+	}).Error
+	if batchErr != nil {
+		return result, batchErr
+	}
+
+	// SSH 인증서 CA 개인키도 함께 재래핑
+	if err := rewrapCertificateAuthorities(ring, dryRun, result); err != nil {
+		return result, err
+	}
+
+	log.Printf("✅ 마스터 키 재래핑 완료 (전체: %d, 재래핑: %d, 신규 암호화: %d, 평문: %d, 유지: %d, 실패: %d)",
+		result.Total, result.Rewrapped, result.Encrypted, result.Plaintext, result.AlreadyDone, result.Failed)
+	return result, nil
+}
+
+// rewrapKey는 한 행에 대해 필요한 변경 사항과 수행한 작업 종류를 반환합니다.
+func rewrapKey(ring *utils.MasterKeyRing, key *models.SSHKey, encryptPlaintext bool) (map[string]interface{}, string, error) {
+	// 평문으로 저장된 행은 명시적으로 요청한 경우에만 새 데이터 키로 암호화
+	if key.WrappedDataKey == "" {
+		if !hasKeyMaterial(key) {
+			return nil, "skip", nil
+		}
+		if !encryptPlaintext {
+			return nil, "plaintext", nil
+		}
+		if err := sealKeyMaterial(key); err != nil {
+			return nil, "", err
+		}
+		return map[string]interface{}{
+			"private_key":      key.PrivateKey,
+			"pem":              key.PEM,
+			"ppk":              key.PPK,
+			"wrapped_data_key": key.WrappedDataKey,
+			"master_key_id":    key.MasterKeyID,
+		}, "encrypt", nil
+	}
+
+	if key.MasterKeyID == ring.ActiveKeyID() {
+		return nil, "skip", nil
+	}
+
+	dataKey, err := ring.UnwrapDataKey(key.WrappedDataKey, key.MasterKeyID)
+	if err != nil {
+		return nil, "", err
+	}
+	wrapped, err := ring.WrapDataKey(dataKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return map[string]interface{}{
+		"wrapped_data_key": wrapped,
+		"master_key_id":    ring.ActiveKeyID(),
+	}, "rewrap", nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/config"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"sync"
+	"time"
+)
+
+// 키에 지정할 수 있는 최대 사용 기간 (10년)
+const maxKeyLifetimeDays = 3650
+
+var keyExpirySchedulerOnce sync.Once
+
+// resolveKeyExpiry는 새 키의 만료 시각을 계산합니다.
+// requestedDays가 0이면 부서/조직 최대 사용 기간을 적용하고, 정책보다 긴 기간은 거부합니다.
+// 정책과 요청이 모두 없으면 nil(만료 없음)을 반환합니다.
+func resolveKeyExpiry(cfg *config.Config, userID uint, requestedDays int, from time.Time) (*time.Time, error) {
+	if requestedDays < 0 {
+		return nil, errors.New("만료 기간은 0 이상이어야 합니다")
+	}
+	if requestedDays > maxKeyLifetimeDays {
+		return nil, fmt.Errorf("만료 기간은 최대 %d일까지 가능합니다", maxKeyLifetimeDays)
+	}
+
+	maxAgeDays, err := effectiveMaxKeyAgeDays(cfg, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := requestedDays
+	if maxAgeDays > 0 {
+		if days > maxAgeDays {
+			return nil, fmt.Errorf("만료 기간은 최대 %d일까지 가능합니다 (부서/조직 키 정책)", maxAgeDays)
+		}
+		if days == 0 {
+			days = maxAgeDays
+		}
+	}
+	if days == 0 {
+		return nil, nil
+	}
+
+	expiresAt := from.AddDate(0, 0, days)
+	return &expiresAt, nil
+}
+
+// effectiveMaxKeyAgeDays는 사용자에게 적용되는 최대 키 사용 기간을 반환합니다.
+// 소속 부서부터 상위 부서 순으로 처음 설정된 값을 사용하고, 없으면 조직 기본값(KEY_MAX_AGE_DAYS)을 사용합니다.
+func effectiveMaxKeyAgeDays(cfg *config.Config, userID uint) (int, error) {
+	var user models.User
+	if err := models.DB.Select("id", "department_id").First(&user, userID).Error; err != nil {
+		return 0, errors.New("사용자를 찾을 수 없습니다")
+	}
+
+	deptID := user.DepartmentID
+	for depth := 0; deptID != nil && depth < 32; depth++ {
+		var dept models.Department
+		if err := models.DB.Select("id", "parent_id", "max_key_age_days").First(&dept, *deptID).Error; err != nil {
+			break
+		}
+		if dept.MaxKeyAgeDays > 0 {
+			return dept.MaxKeyAgeDays, nil
+		}
+		deptID = dept.ParentID
+	}
+
+	return cfg.KeyMaxAgeDays, nil
+}
+
+// isKeyExpired는 키가 만료되었는지 확인합니다.
+func isKeyExpired(key *models.SSHKey, now time.Time) bool {
+	return key.ExpiresAt != nil && !key.ExpiresAt.After(now)
+}
+
+// StartKeyExpiryScheduler는 키 만료 검사를 주기적으로 실행하는 백그라운드 작업을 시작합니다.
+// KEY_EXPIRY_CHECK_INTERVAL이 0이면 시작하지 않으며, 여러 번 호출해도 한 번만 시작됩니다.
+func StartKeyExpiryScheduler() {
+	keyExpirySchedulerOnce.Do(func() {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Printf("⚠️ 설정 로드 실패, 키 만료 스케줄러를 시작하지 않습니다: %v", err)
+			return
+		}
+		if cfg.KeyExpiryCheckInterval <= 0 {
+			log.Printf("📋 키 만료 스케줄러 비활성화됨 (KEY_EXPIRY_CHECK_INTERVAL=0)")
+			return
+		}
+
+		log.Printf("⏰ 키 만료 스케줄러 시작 (주기: %s, 경고: 만료 %d일 전, 자동 교체: %t)",
+			cfg.KeyExpiryCheckInterval, cfg.KeyExpiryWarningDays, cfg.KeyAutoRotate)
+
+		go func() {
+			ticker := time.NewTicker(cfg.KeyExpiryCheckInterval)
+			defer ticker.Stop()
+
+			for {
+				if _, err := RunKeyExpiryCheck(); err != nil {
+					log.Printf("❌ 키 만료 검사 실패: %v", err)
+				}
+				<-ticker.C
+			}
+		}()
+	})
+}
+
+// RunKeyExpiryCheck는 만료 예정 키의 소유자에게 알리고, 만료된 키를 교체(또는 폐기)합니다.
+// 기존 키가 남은 서버가 있는 이전 교체도 다시 시도합니다.
+// 스케줄러가 주기적으로 호출하며 관리자가 즉시 실행할 수도 있습니다.
+func RunKeyExpiryCheck() (*types.KeyExpiryCheckSummary, error) {
+	if models.DB == nil {
+		return nil, errors.New("데이터베이스가 초기화되지 않았습니다")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	summary := &types.KeyExpiryCheckSummary{CheckedAt: now}
+
+	// 1. 만료 예정 키 알림 (키당 한 번)
+	if cfg.KeyExpiryWarningDays > 0 {
+		var expiring []models.SSHKey
+		err := models.DB.Select("id", "user_id", "name", "expires_at").
+			Where("status = ? AND replaced_by_id IS NULL AND expires_at > ? AND expires_at <= ? AND expiry_notified_at IS NULL",
+				models.KeyStatusActive, now, now.AddDate(0, 0, cfg.KeyExpiryWarningDays)).
+			Find(&expiring).Error
+		if err != nil {
+			log.Printf("❌ 만료 예정 키 조회 실패: %v", err)
+			return nil, err
+		}
+
+		for _, key := range expiring {
+			daysLeft := int(key.ExpiresAt.Sub(now).Hours()/24) + 1
+			action := "만료되면 새 키로 자동 교체되고 기존 키는 서버에서 제거됩니다"
+			if !cfg.KeyAutoRotate {
+				action = "만료되면 키가 폐기되고 서버에서 제거됩니다. 미리 새 키를 생성해주세요"
+			}
+			notifyUser(key.UserID, models.NotificationTypeWarning, "SSH 키 만료 예정",
+				fmt.Sprintf("키 '%s'가 %s에 만료됩니다 (%d일 남음). %s",
+					key.Name, key.ExpiresAt.Format("2006-01-02 15:04"), daysLeft, action))
+
+			models.DB.Model(&models.SSHKey{}).Where("id = ?", key.ID).UpdateColumn("expiry_notified_at", now)
+			summary.Warned++
+		}
+	}
+
+	// 2. 만료된 키 교체 (교체가 진행 중인 키는 3단계에서 처리)
+	var expired []models.SSHKey
+	if err := models.DB.Where("status = ? AND replaced_by_id IS NULL AND expires_at <= ?", models.KeyStatusActive, now).
+		Order("expires_at").Find(&expired).Error; err != nil {
+		log.Printf("❌ 만료된 키 조회 실패: %v", err)
+		return nil, err
+	}
+
+	for i := range expired {
+		result, err := rotateKey(cfg, &expired[i], models.RotationReasonExpired)
+		if err != nil {
+			log.Printf("❌ 만료 키 교체 실패 (키 ID: %d): %v", expired[i].ID, err)
+			summary.Failed++
+			continue
+		}
+
+		switch result.Status {
+		case models.RotationStatusRevoked:
+			summary.Revoked++
+		case models.RotationStatusFailed:
+			summary.Failed++
+		default:
+			summary.Rotated++
+		}
+	}
+
+	// 3. 기존 키가 남은 서버가 있는 교체 재시도
+	if _, remaining, err := RetryPartialRotations(); err == nil {
+		summary.PendingRotations = remaining
+	}
+
+	if summary.Warned > 0 || len(expired) > 0 || summary.PendingRotations > 0 {
+		log.Printf("⏰ 키 만료 검사 완료 (알림: %d, 교체: %d, 폐기: %d, 실패: %d, 미완료 교체: %d)",
+			summary.Warned, summary.Rotated, summary.Revoked, summary.Failed, summary.PendingRotations)
+	}
+	return summary, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strings"
+)
+
+// SearchKeysByFingerprint는 핑거프린트 또는 sshd 로그 텍스트로 키와 소유자를 찾습니다.
+// "Accepted publickey for deploy from 10.0.0.5 port 52144 ssh2: ED25519 SHA256:..." 같은 로그 줄을
+// 그대로 넣으면 포함된 핑거프린트를 모두 추출하여 검색합니다. 감사 목적상 삭제된 키도 결과에 포함됩니다.
+func SearchKeysByFingerprint(query string) (*types.KeyFingerprintSearchResponse, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("검색할 핑거프린트 또는 로그를 입력해주세요")
+	}
+
+	fingerprints := utils.ExtractFingerprints(query)
+	if len(fingerprints) == 0 {
+		// "SHA256:" 접두사 없이 입력한 경우
+		normalized, _, err := utils.NormalizeFingerprint(query)
+		if err != nil {
+			return nil, errors.New("유효하지 않은 핑거프린트입니다. SHA256:... 또는 aa:bb:... 형식을 입력해주세요")
+		}
+		fingerprints = []string{normalized}
+	}
+
+	log.Printf("🔍 핑거프린트 검색 (%d개): %s", len(fingerprints), strings.Join(fingerprints, ", "))
+
+	var keys []models.SSHKey
+	result := models.DB.Unscoped().
+		Select("id", "user_id", "name", "status", "algorithm", "fingerprint_sha256", "fingerprint_md5", "created_at", "deleted_at").
+		Where("fingerprint_sha256 IN ? OR fingerprint_md5 IN ?", fingerprints, fingerprints).
+		Order("id").
+		Find(&keys)
+	if result.Error != nil {
+		log.Printf("❌ 핑거프린트 검색 실패: %v", result.Error)
+		return nil, result.Error
+	}
+
+	// 소유자 정보 조회 (삭제된 사용자 포함)
+	userIDs := make([]uint, 0, len(keys))
+	for _, key := range keys {
+		userIDs = append(userIDs, key.UserID)
+	}
+	usernames := make(map[uint]string)
+	if len(userIDs) > 0 {
+		var users []models.User
+		if err := models.DB.Unscoped().Select("id", "username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			usernames[user.ID] = user.Username
+		}
+	}
+
+	response := &types.KeyFingerprintSearchResponse{
+		Fingerprints: fingerprints,
+		Matches:      []types.KeyFingerprintMatch{},
+	}
+
+	for _, fingerprint := range fingerprints {
+		matched := false
+		for _, key := range keys {
+			if key.FingerprintSHA256 != fingerprint && key.FingerprintMD5 != fingerprint {
+				continue
+			}
+			matched = true
+
+			match := types.KeyFingerprintMatch{
+				Fingerprint:       fingerprint,
+				KeyID:             key.ID,
+				KeyName:           key.Name,
+				Status:            key.Status,
+				Algorithm:         key.Algorithm,
+				FingerprintSHA256: key.FingerprintSHA256,
+				FingerprintMD5:    key.FingerprintMD5,
+				UserID:            key.UserID,
+				Username:          usernames[key.UserID],
+				CreatedAt:         key.CreatedAt,
+			}
+			if key.DeletedAt.Valid {
+				deletedAt := key.DeletedAt.Time
+				match.DeletedAt = &deletedAt
+			}
+			response.Matches = append(response.Matches, match)
+		}
+		if !matched {
+			response.Unmatched = append(response.Unmatched, fingerprint)
+		}
+	}
+
+	log.Printf("✅ 핑거프린트 검색 완료 (일치: %d건, 미등록: %d건)", len(response.Matches), len(response.Unmatched))
+	return response, nil
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/config"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// importedKeyMaterial은 가져온 키에서 추출한 저장용 데이터입니다.
+type importedKeyMaterial struct {
+	Algorithm           string
+	Bits                int
+	Comment             string
+	PublicKey           ssh.PublicKey
+	PrivateKey          string
+	PEM                 string
+	PPK                 string
+	PassphraseProtected bool
+	Source              string
+
+	signer     crypto.Signer // 개인키를 가져온 경우에만 설정
+	passphrase string        // 개인키 재암호화용 패스프레이즈 (입력 키가 암호화된 경우)
+}
+
+// ImportSSHKey는 기존 개인키(OpenSSH, PEM, PKCS#8, PPK) 또는 공개키(authorized_keys, RFC 4716)를 가져와 저장합니다.
+// 암호화된 개인키는 password로 복호화하며, 같은 패스프레이즈로 다시 암호화하여 보관합니다.
+// 공개키만 가져온 키는 개인키 없이 저장되며 생성된 키와 동일하게 배포할 수 있습니다.
+func ImportSSHKey(userID uint, req types.SSHKeyImportRequest) (*models.SSHKey, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	content := []byte(strings.TrimSpace(req.Content))
+	if len(content) == 0 {
+		return nil, errors.New("가져올 키 내용을 입력해주세요")
+	}
+
+	var user models.User
+	if err := models.DB.First(&user, userID).Error; err != nil {
+		return nil, errors.New("사용자를 찾을 수 없습니다")
+	}
+
+	name, err := normalizeKeyName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := resolveImportFormat(req.Format, content)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := resolveKeyExpiry(cfg, userID, req.ExpiresInDays, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📥 SSH 키 가져오기 시작 (사용자: %s, 형식: %s)", user.Username, format)
+
+	var material *importedKeyMaterial
+	if utils.IsPrivateKeyFormat(format) {
+		material, err = importPrivateKey(content, req.Password)
+	} else {
+		material, err = importPublicKey(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 코멘트 우선순위: 요청 > 키 파일 > 사용자명
+	comment := strings.TrimSpace(req.Comment)
+	if comment == "" {
+		comment = material.Comment
+	}
+	if comment == "" {
+		comment = user.Username
+	}
+
+	// 개인키가 있으면 최종 코멘트로 OpenSSH, PEM, PPK 형식을 만듦
+	if material.signer != nil {
+		if err := material.encode(comment, cfg.PPKVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	publicKey := utils.FormatAuthorizedKey(material.PublicKey, comment)
+
+	existingKey, err := findImportTarget(userID, name, material.PublicKey, req.Overwrite)
+	if err != nil {
+		return nil, err
+	}
+	if existingKey != nil {
+		removeReplacedKeyLocally(cfg, existingKey)
+		// 덮어쓸 때 이름을 지정하지 않았다면 기존 키 이름 유지
+		if strings.TrimSpace(req.Name) == "" {
+			name = existingKey.Name
+		}
+	}
+
+	installationStatus := installPublicKeyLocally(cfg, publicKey)
+
+	sshKey := &models.SSHKey{
+		UserID:              userID,
+		Name:                name,
+		Comment:             comment,
+		Status:              models.KeyStatusActive,
+		Algorithm:           material.Algorithm,
+		Bits:                material.Bits,
+		PrivateKey:          material.PrivateKey,
+		PublicKey:           publicKey,
+		PEM:                 material.PEM,
+		PPK:                 material.PPK,
+		PassphraseProtected: material.PassphraseProtected,
+		Source:              material.Source,
+		ExpiresAt:           expiresAt,
+	}
+
+	if err := storeSSHKey(sshKey, existingKey); err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ SSH 키 가져오기 완료 (사용자: %s, 이름: %s, 알고리즘: %s, 출처: %s)",
+		user.Username, name, material.Algorithm, material.Source)
+	log.Printf("   - 덮어쓰기: %t", existingKey != nil)
+	log.Printf("   - 자동 설치: %s", installationStatus)
+
+	return sshKey, nil
+}
+
+// resolveImportFormat은 요청한 형식과 실제 내용을 비교하여 가져오기 형식을 결정합니다.
+// 형식이 비어있거나 auto이면 내용으로 자동 감지합니다.
+func resolveImportFormat(requested string, content []byte) (string, error) {
+	detected := utils.DetectKeyFormat(content)
+
+	requested = strings.ToLower(strings.TrimSpace(requested))
+	switch requested {
+	case "", "auto":
+		return detected, nil
+	case "openssh", "pem", "pkcs1", "pkcs8", "ppk", "ppk-v2", "ppk-v3", "private":
+		if !utils.IsPrivateKeyFormat(detected) {
+			return "", fmt.Errorf("키 내용이 지정한 형식(%s)과 맞지 않습니다. 형식을 올바르게 선택해주세요", requested)
+		}
+		return detected, nil
+	case "public", "authorized_keys", "rfc4716":
+		if utils.IsPrivateKeyFormat(detected) {
+			return "", errors.New("공개키 형식으로 지정했지만 개인키 내용입니다. 형식을 올바르게 선택해주세요")
+		}
+		return detected, nil
+	}
+
+	return "", fmt.Errorf("지원하지 않는 가져오기 형식입니다: %s (auto, openssh, pem, pkcs8, ppk, public, rfc4716)", requested)
+}
+
+// importPrivateKey는 개인키를 파싱하고 메타데이터를 추출합니다.
+func importPrivateKey(content []byte, password string) (*importedKeyMaterial, error) {
+	// 먼저 암호 없이 시도하여 입력 키가 암호화되어 있는지 판별
+	signer, comment, err := utils.ParsePrivateKeyAnyFormat(content, "")
+	encrypted := false
+	if err != nil && password != "" {
+		signer, comment, err = utils.ParsePrivateKeyAnyFormat(content, password)
+		encrypted = true
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if encrypted {
+		if err := validatePassphrase(password); err != nil {
+			return nil, err
+		}
+	}
+
+	publicKey, err := ssh.NewPublicKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("공개키 추출 실패: %v", err)
+	}
+
+	algorithm, bits, err := describeImportedPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	material := &importedKeyMaterial{
+		Algorithm:           algorithm,
+		Bits:                bits,
+		Comment:             comment,
+		PublicKey:           publicKey,
+		PassphraseProtected: encrypted,
+		Source:              models.KeySourceImported,
+		signer:              signer,
+	}
+	if encrypted {
+		material.passphrase = password
+	}
+
+	log.Printf("   🔑 개인키 파싱 완료 (알고리즘: %s, 암호화: %t)", algorithm, encrypted)
+	return material, nil
+}
+
+// importPublicKey는 authorized_keys 한 줄 또는 RFC 4716 공개키를 파싱합니다.
+// authorized_keys 옵션은 저장하지 않습니다 (배포 시 서버별로 관리).
+func importPublicKey(content []byte) (*importedKeyMaterial, error) {
+	publicKey, comment, options, err := utils.ParsePublicKeyAnyFormat(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := publicKey.(*ssh.Certificate); ok {
+		return nil, errors.New("SSH 인증서는 가져올 수 없습니다. 인증서가 아닌 공개키를 입력해주세요")
+	}
+
+	if len(options) > 0 {
+		log.Printf("⚠️ authorized_keys 옵션은 저장되지 않습니다: %s", strings.Join(options, ","))
+	}
+
+	algorithm, bits, err := describeImportedPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("   🔑 공개키 파싱 완료 (알고리즘: %s)", algorithm)
+	return &importedKeyMaterial{
+		Algorithm: algorithm,
+		Bits:      bits,
+		Comment:   comment,
+		PublicKey: publicKey,
+		Source:    models.KeySourceImportedPublic,
+	}, nil
+}
+
+// describeImportedPublicKey는 공개키의 알고리즘과 크기를 확인하고 허용되지 않는 키를 거부합니다.
+func describeImportedPublicKey(publicKey ssh.PublicKey) (string, int, error) {
+	algorithm, bits, err := utils.DescribePublicKey(publicKey)
+	if err != nil {
+		return "", 0, err
+	}
+
+	switch {
+	case algorithm == utils.KeyAlgorithmDSA:
+		return "", 0, errors.New("DSA 키는 지원하지 않는 알고리즘입니다 (OpenSSH 7.0 이후 비활성화)")
+	case algorithm == utils.KeyAlgorithmRSA && bits < 2048:
+		return "", 0, fmt.Errorf("RSA 키는 최소 2048비트 이상이어야 합니다 (현재: %d비트)", bits)
+	}
+
+	return algorithm, bits, nil
+}
+
+// encode는 개인키를 OpenSSH, PEM, PPK 형식으로 인코딩합니다.
+// 패스프레이즈가 있으면 생성 키와 동일하게 PEM은 만들지 않습니다.
+func (m *importedKeyMaterial) encode(comment string, ppkVersion int) error {
+	opensshKey, err := utils.EncodePrivateKeyToOpenSSHWithPassphrase(m.signer, comment, m.passphrase)
+	if err != nil {
+		return err
+	}
+
+	var pemKey []byte
+	if m.passphrase == "" {
+		pemKey, err = utils.EncodePrivateKeyToPEM(m.signer)
+		if err != nil {
+			return err
+		}
+	}
+
+	ppkKey, err := utils.EncodePPK(m.signer, utils.PPKEncodeOptions{
+		Version:    ppkVersion,
+		Comment:    comment,
+		Passphrase: m.passphrase,
+	})
+	if err != nil {
+		return err
+	}
+
+	m.Comment = comment
+	m.PrivateKey = string(opensshKey)
+	m.PEM = string(pemKey)
+	m.PPK = string(ppkKey)
+	return nil
+}
+
+// findImportTarget은 가져오기 시 덮어쓸 기존 키를 찾습니다.
+// 같은 이름 또는 같은 공개키의 키가 있으면 overwrite가 true일 때만 교체 대상으로 반환합니다.
+func findImportTarget(userID uint, name string, publicKey ssh.PublicKey, overwrite bool) (*models.SSHKey, error) {
+	byName, err := findKeyByName(userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	byPublicKey, err := findKeyByPublicKey(userID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !overwrite {
+		if byPublicKey != nil {
+			return nil, fmt.Errorf("이미 등록된 공개키입니다 (키 이름: %s)", byPublicKey.Name)
+		}
+		if byName != nil {
+			return nil, fmt.Errorf("이미 사용 중인 키 이름입니다: %s", name)
+		}
+		return nil, nil
+	}
+
+	if byName != nil && byPublicKey != nil && byName.ID != byPublicKey.ID {
+		return nil, fmt.Errorf("이름(%s)과 공개키가 서로 다른 키와 일치하여 덮어쓸 키를 선택할 수 없습니다", name)
+	}
+	if byPublicKey != nil {
+		return byPublicKey, nil
+	}
+	return byName, nil
+}
+
+// findKeyByPublicKey는 사용자의 키 중 공개키 본문이 같은 키를 찾습니다 (코멘트는 비교하지 않음).
+func findKeyByPublicKey(userID uint, publicKey ssh.PublicKey) (*models.SSHKey, error) {
+	var keys []models.SSHKey
+	if err := models.DB.Select("id", "name", "public_key").Where("user_id = ?", userID).Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("키 조회 실패: %v", err)
+	}
+
+	blob := publicKey.Marshal()
+	for i := range keys {
+		parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(keys[i].PublicKey))
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(parsed.Marshal(), blob) {
+			var key models.SSHKey
+			if err := models.DB.First(&key, keys[i].ID).Error; err != nil {
+				return nil, fmt.Errorf("키 조회 실패: %v", err)
+			}
+			return &key, nil
+		}
+	}
+
+	return nil, nil
+}
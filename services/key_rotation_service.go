@@ -0,0 +1,553 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/config"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 스케줄러와 수동 교체가 같은 키를 동시에 교체하지 않도록 직렬화합니다.
+var keyRotationMu sync.Mutex
+
+// RotateKey는 사용자의 키를 즉시 새 키로 교체합니다.
+// 새 키를 기존 키가 배포된 모든 서버에 배포한 뒤 기존 키를 제거하고 폐기합니다.
+func RotateKey(userID, keyID uint) (*types.KeyRotationResult, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := findOwnedKey(userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.Status != models.KeyStatusActive {
+		return nil, errors.New("유효하지 않은 키 상태입니다. 활성 상태의 키만 교체할 수 있습니다")
+	}
+	if key.ReplacedByID != nil {
+		return nil, errors.New("이미 교체가 진행 중인 키입니다. 남은 서버에서 기존 키 제거가 끝나면 폐기됩니다")
+	}
+	if !keyHoldsPrivateMaterial(key) {
+		return nil, errors.New("공개키만 등록된 키는 교체할 수 없습니다. 새 공개키를 가져온 뒤 기존 키를 폐기해주세요")
+	}
+
+	return rotateKey(cfg, key, models.RotationReasonManual)
+}
+
+// GetKeyRotations는 키의 교체 기록을 최신순으로 조회합니다.
+func GetKeyRotations(userID, keyID uint) ([]models.KeyRotation, error) {
+	if _, err := findOwnedKey(userID, keyID); err != nil {
+		return nil, err
+	}
+
+	var rotations []models.KeyRotation
+	if err := models.DB.Where("user_id = ? AND (old_key_id = ? OR new_key_id = ?)", userID, keyID, keyID).
+		Order("created_at DESC").Find(&rotations).Error; err != nil {
+		log.Printf("❌ 키 교체 기록 조회 실패: %v", err)
+		return nil, err
+	}
+	return rotations, nil
+}
+
+// rotateKey는 키 교체의 전체 과정을 수행하고 기록합니다.
+//  1. 기존 키 이름을 보관용 이름으로 변경하고 같은 이름/알고리즘으로 새 키 생성
+//  2. 기존 키가 배포된 서버에 DeployKeyToServers로 새 키 배포
+//  3. 새 키 배포에 성공한 서버에서만 기존 키 제거 (접속 불가 방지)
+//  4. 기존 키 폐기, 교체 기록 저장, 소유자에게 알림
+//
+// 기존 키를 제거하지 못한 서버가 남으면 기존 키를 활성 상태로 두고(replaced_by_id만 기록) 교체를 partial로 기록합니다.
+// 남은 서버는 키 만료 스케줄러가 RetryPartialRotations로 다시 처리하고, 모두 제거되면 기존 키를 폐기합니다.
+//
+// 만료로 인한 교체에서 새 키를 만들 수 없으면(공개키만 등록, 자동 교체 비활성화, 생성 실패)
+// 기존 키를 모든 서버에서 제거하고 폐기만 합니다.
+func rotateKey(cfg *config.Config, oldKey *models.SSHKey, reason string) (*types.KeyRotationResult, error) {
+	keyRotationMu.Lock()
+	defer keyRotationMu.Unlock()
+
+	// 잠금을 얻는 동안 다른 작업이 교체했을 수 있으므로 상태 재확인
+	var current models.SSHKey
+	if err := models.DB.Select("id", "status", "replaced_by_id").First(&current, oldKey.ID).Error; err != nil {
+		return nil, errors.New("키를 찾을 수 없습니다")
+	}
+	if current.Status != models.KeyStatusActive || current.ReplacedByID != nil {
+		return nil, errors.New("이미 교체되었거나 폐기된 키입니다")
+	}
+
+	log.Printf("🔄 SSH 키 교체 시작 (키 ID: %d, 이름: %s, 사유: %s)", oldKey.ID, oldKey.Name, reason)
+
+	result := &types.KeyRotationResult{
+		OldKeyID: oldKey.ID,
+		Reason:   reason,
+		Servers:  []types.KeyRotationServerResult{},
+	}
+	rotation := models.KeyRotation{
+		UserID:   oldKey.UserID,
+		OldKeyID: oldKey.ID,
+		Reason:   reason,
+	}
+
+	servers, err := findKeyDeployedServers(oldKey.ID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("   📡 기존 키가 배포된 서버: %d대", len(servers))
+
+	// 1. 새 키 생성
+	originalName := oldKey.Name
+	var newKey *models.SSHKey
+	switch {
+	case !keyHoldsPrivateMaterial(oldKey):
+		result.Warnings = append(result.Warnings, "공개키만 등록된 키는 새 키를 생성할 수 없어 폐기만 합니다")
+	case reason == models.RotationReasonExpired && !cfg.KeyAutoRotate:
+		result.Warnings = append(result.Warnings, "자동 교체가 비활성화되어 있어 만료된 키를 폐기만 합니다 (KEY_AUTO_ROTATE=false)")
+	default:
+		newKey, err = generateReplacementKey(oldKey, reason, result)
+		if err != nil {
+			if reason == models.RotationReasonManual {
+				return nil, err
+			}
+			log.Printf("⚠️ 새 키 생성 실패, 만료된 키를 폐기만 합니다: %v", err)
+			result.Warnings = append(result.Warnings, fmt.Sprintf("새 키 생성 실패로 폐기만 합니다: %v", err))
+			rotation.ErrorMsg = err.Error()
+		}
+	}
+
+	// 2~3. 새 키 배포 후 기존 키 제거
+	replaceKeyOnServers(oldKey, newKey, servers, nil, &rotation, result)
+
+	// 로컬 서버에 자동 설치된 기존 키 제거
+	removeReplacedKeyLocally(cfg, oldKey)
+
+	// 4. 기존 키 폐기 (새 키가 있는데 기존 키가 남은 서버가 있으면 활성 상태로 유지)
+	updates := map[string]interface{}{"status": models.KeyStatusRevoked}
+	if newKey != nil {
+		updates["replaced_by_id"] = newKey.ID
+		if rotation.FailedCount > 0 {
+			delete(updates, "status")
+			log.Printf("⚠️ 서버 %d대에 기존 키가 남아 있어 기존 키를 활성 상태로 유지합니다 (키 ID: %d)", rotation.FailedCount, oldKey.ID)
+			result.Warnings = append(result.Warnings, "기존 키가 남은 서버가 있어 기존 키는 모두 제거될 때까지 활성 상태로 유지되며, 주기적으로 다시 시도합니다")
+		}
+	} else {
+		// 폐기만 하는 경우에도 이름을 비워 같은 이름으로 새 키를 만들 수 있게 함
+		if archivedName, err := archivedKeyName(oldKey.UserID, originalName, reason); err == nil {
+			updates["name"] = archivedName
+		}
+	}
+	if err := models.DB.Model(&models.SSHKey{}).Where("id = ?", oldKey.ID).Updates(updates).Error; err != nil {
+		log.Printf("❌ 기존 키 폐기 실패: %v", err)
+		return nil, err
+	}
+
+	// 교체 기록 저장 (남은 서버가 있으면 partial로 기록하여 재시도 대상으로 남김)
+	switch {
+	case rotation.FailedCount > 0:
+		rotation.Status = models.RotationStatusPartial
+	case newKey == nil:
+		rotation.Status = models.RotationStatusRevoked
+	default:
+		rotation.Status = models.RotationStatusCompleted
+	}
+	if newKey != nil {
+		rotation.NewKeyID = &newKey.ID
+		result.NewKeyID = &newKey.ID
+		result.ExpiresAt = newKey.ExpiresAt
+	}
+	if rotation.ErrorMsg == "" && len(result.Warnings) > 0 {
+		rotation.ErrorMsg = strings.Join(result.Warnings, "; ")
+	}
+	if err := models.DB.Create(&rotation).Error; err != nil {
+		log.Printf("⚠️ 키 교체 기록 저장 실패: %v", err)
+	}
+	result.RotationID = rotation.ID
+	result.Status = rotation.Status
+
+	notifyKeyRotation(oldKey, originalName, newKey, rotation, result)
+
+	log.Printf("✅ SSH 키 교체 완료 (기존 키 ID: %d, 상태: %s, 배포: %d, 제거: %d, 실패: %d)",
+		oldKey.ID, rotation.Status, rotation.DeployedCount, rotation.RemovedCount, rotation.FailedCount)
+	return result, nil
+}
+
+// replaceKeyOnServers는 서버에 새 키를 배포하고, 새 키가 배포된 서버에서만 기존 키를 제거합니다.
+// newKey가 nil이면 배포 없이 기존 키만 제거합니다. alreadyDeployed에 있는 서버는 다시 배포하지 않습니다.
+// 결과는 rotation의 서버 수와 result.Servers에 더합니다.
+func replaceKeyOnServers(oldKey, newKey *models.SSHKey, servers []models.Server, alreadyDeployed map[uint]bool, rotation *models.KeyRotation, result *types.KeyRotationResult) {
+	// 새 키 배포
+	deployed := make(map[uint]string)
+	if newKey != nil {
+		serverIDs := make([]uint, 0, len(servers))
+		for _, server := range servers {
+			if alreadyDeployed[server.ID] {
+				deployed[server.ID] = "success"
+				continue
+			}
+			serverIDs = append(serverIDs, server.ID)
+		}
+
+		if len(serverIDs) > 0 {
+			deployments, err := DeployKeyToServers(oldKey.UserID, types.KeyDeploymentRequest{
+				ServerIDs: serverIDs,
+				SSHKeyID:  newKey.ID,
+			})
+			if err != nil {
+				log.Printf("❌ 새 키 배포 실패: %v", err)
+				result.Warnings = append(result.Warnings, fmt.Sprintf("새 키 배포 실패: %v", err))
+			}
+			for _, deployment := range deployments {
+				if deployment.Status == "success" {
+					deployed[deployment.ServerID] = deployment.Status
+					rotation.DeployedCount++
+				} else {
+					deployed[deployment.ServerID] = deployment.ErrorMessage
+				}
+			}
+		}
+	}
+
+	// 기존 키 제거
+	for _, server := range servers {
+		serverResult := types.KeyRotationServerResult{
+			ServerID:   server.ID,
+			ServerName: server.Name,
+		}
+
+		if newKey != nil {
+			if status, ok := deployed[server.ID]; ok && status == "success" {
+				serverResult.DeployStatus = "success"
+			} else {
+				// 새 키 배포에 실패한 서버는 접속이 끊기지 않도록 기존 키를 남겨둠
+				serverResult.DeployStatus = "failed"
+				serverResult.RemoveStatus = "skipped"
+				serverResult.ErrorMessage = status
+				rotation.FailedCount++
+				result.Servers = append(result.Servers, serverResult)
+				continue
+			}
+		}
+
+		if _, err := removeKeyFromServer(context.Background(), oldKey, server); err != nil {
+			serverResult.RemoveStatus = "failed"
+			serverResult.ErrorMessage = err.Error()
+			rotation.FailedCount++
+		} else {
+			serverResult.RemoveStatus = "success"
+			rotation.RemovedCount++
+		}
+		result.Servers = append(result.Servers, serverResult)
+	}
+}
+
+// RetryPartialRotations는 일부 서버에 기존 키가 남은 교체(partial)를 다시 처리합니다.
+// 남은 서버에 새 키를 배포하고 기존 키를 제거하며, 모든 서버에서 제거되면 기존 키를 폐기하고 교체를 완료로 기록합니다.
+// 키 만료 스케줄러가 주기적으로 호출합니다.
+func RetryPartialRotations() (completed, remaining int, err error) {
+	var rotations []models.KeyRotation
+	if err := models.DB.Where("status = ?", models.RotationStatusPartial).Order("id").Find(&rotations).Error; err != nil {
+		log.Printf("❌ 미완료 키 교체 조회 실패: %v", err)
+		return 0, 0, err
+	}
+
+	for i := range rotations {
+		done, err := retryPartialRotation(&rotations[i])
+		if err != nil {
+			log.Printf("❌ 키 교체 재시도 실패 (교체 ID: %d): %v", rotations[i].ID, err)
+		}
+		if done {
+			completed++
+		} else {
+			remaining++
+		}
+	}
+
+	if len(rotations) > 0 {
+		log.Printf("🔁 미완료 키 교체 재시도 완료 (완료: %d, 남음: %d)", completed, remaining)
+	}
+	return completed, remaining, nil
+}
+
+// retryPartialRotation은 교체 하나의 남은 서버를 처리하고, 모든 서버에서 기존 키가 제거되었는지 반환합니다.
+func retryPartialRotation(rotation *models.KeyRotation) (bool, error) {
+	keyRotationMu.Lock()
+	defer keyRotationMu.Unlock()
+
+	var oldKey models.SSHKey
+	if err := models.DB.Unscoped().First(&oldKey, rotation.OldKeyID).Error; err != nil {
+		return false, fmt.Errorf("기존 키 조회 실패: %w", err)
+	}
+
+	var newKey *models.SSHKey
+	alreadyDeployed := make(map[uint]bool)
+	if rotation.NewKeyID != nil {
+		var key models.SSHKey
+		if err := models.DB.First(&key, *rotation.NewKeyID).Error; err != nil {
+			return false, fmt.Errorf("새 키 조회 실패: %w", err)
+		}
+		if key.Status != models.KeyStatusActive {
+			return false, errors.New("새 키가 활성 상태가 아니어서 기존 키를 제거하지 않습니다")
+		}
+		newKey = &key
+
+		deployed, err := currentDeployedServerIDs([]uint{key.ID})
+		if err != nil {
+			return false, err
+		}
+		for _, serverID := range deployed[key.ID] {
+			alreadyDeployed[serverID] = true
+		}
+	}
+
+	servers, err := findKeyDeployedServers(oldKey.ID)
+	if err != nil {
+		return false, err
+	}
+
+	result := &types.KeyRotationResult{OldKeyID: oldKey.ID, Reason: rotation.Reason}
+	rotation.FailedCount = 0
+	if len(servers) > 0 {
+		log.Printf("🔁 키 교체 재시도 (교체 ID: %d, 기존 키 ID: %d, 남은 서버: %d대)", rotation.ID, oldKey.ID, len(servers))
+		replaceKeyOnServers(&oldKey, newKey, servers, alreadyDeployed, rotation, result)
+	}
+
+	updates := map[string]interface{}{
+		"deployed_count": rotation.DeployedCount,
+		"removed_count":  rotation.RemovedCount,
+		"failed_count":   rotation.FailedCount,
+	}
+	if rotation.FailedCount > 0 {
+		if err := models.DB.Model(rotation).Updates(updates).Error; err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	// 모든 서버에서 제거됨: 기존 키 폐기 후 교체 완료
+	if oldKey.Status != models.KeyStatusRevoked {
+		if err := models.DB.Model(&models.SSHKey{}).Where("id = ?", oldKey.ID).Update("status", models.KeyStatusRevoked).Error; err != nil {
+			return false, fmt.Errorf("기존 키 폐기 실패: %w", err)
+		}
+	}
+	rotation.Status = models.RotationStatusCompleted
+	if newKey == nil {
+		rotation.Status = models.RotationStatusRevoked
+	}
+	updates["status"] = rotation.Status
+	if err := models.DB.Model(rotation).Updates(updates).Error; err != nil {
+		return false, err
+	}
+
+	log.Printf("✅ 키 교체 완료 (교체 ID: %d, 기존 키 ID: %d)", rotation.ID, oldKey.ID)
+	notifyUser(oldKey.UserID, models.NotificationTypeSuccess, "SSH 키 교체 완료",
+		fmt.Sprintf("키 '%s'가 남아 있던 모든 서버에서 제거되어 폐기되었습니다.", oldKey.Name))
+	return true, nil
+}
+
+// generateReplacementKey는 기존 키 이름을 보관용으로 바꾸고 같은 이름, 알고리즘, 키 크기로 새 키를 생성합니다.
+// 생성에 실패하면 기존 키 이름을 되돌립니다.
+func generateReplacementKey(oldKey *models.SSHKey, reason string, result *types.KeyRotationResult) (*models.SSHKey, error) {
+	originalName := oldKey.Name
+
+	archivedName, err := archivedKeyName(oldKey.UserID, originalName, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.DB.Model(&models.SSHKey{}).Where("id = ?", oldKey.ID).Update("name", archivedName).Error; err != nil {
+		return nil, fmt.Errorf("기존 키 이름 변경 실패: %v", err)
+	}
+	oldKey.Name = archivedName
+
+	// 지원하지 않는 알고리즘(가져온 키)이면 기본 알고리즘 사용
+	algorithm, bits := oldKey.Algorithm, oldKey.Bits
+	if _, _, err := utils.NormalizeKeyAlgorithm(algorithm, bits); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s 키는 생성할 수 없어 기본 알고리즘으로 새 키를 생성했습니다", algorithm))
+		algorithm, bits = "", 0
+	}
+
+	newKey, err := GenerateSSHKeyPair(oldKey.UserID, types.SSHKeyGenerationOptions{
+		Name:      originalName,
+		Algorithm: algorithm,
+		Bits:      bits,
+		Comment:   oldKey.Comment,
+	})
+	if err != nil {
+		models.DB.Model(&models.SSHKey{}).Where("id = ?", oldKey.ID).Update("name", originalName)
+		oldKey.Name = originalName
+		return nil, err
+	}
+
+	if oldKey.PassphraseProtected {
+		result.Warnings = append(result.Warnings, "기존 키는 패스프레이즈로 보호되어 있었지만 새 키는 패스프레이즈 없이 생성되었습니다. 내보낼 때 패스프레이즈를 지정해주세요")
+	}
+
+	log.Printf("   🔑 새 키 생성 완료 (ID: %d, 기존 키 이름 변경: %s → %s)", newKey.ID, originalName, archivedName)
+	return newKey, nil
+}
+
+// archivedKeyName은 교체된 키에 붙일 보관용 이름을 만듭니다 (예: laptop-expired-20250101).
+func archivedKeyName(userID uint, name, reason string) (string, error) {
+	suffix := "-rotated-"
+	if reason == models.RotationReasonExpired {
+		suffix = "-expired-"
+	}
+	now := time.Now()
+
+	for _, candidate := range []string{suffix + now.Format("20060102"), suffix + now.Format("20060102-150405")} {
+		base := name
+		if len(base)+len(candidate) > 100 {
+			base = base[:100-len(candidate)]
+		}
+		archived := base + candidate
+
+		existing, err := findKeyByName(userID, archived)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return archived, nil
+		}
+	}
+
+	return "", fmt.Errorf("이미 사용 중인 키 이름입니다: %s%s", name, suffix)
+}
+
+// findKeyDeployedServers는 키가 현재 배포되어 있는 서버 목록을 조회합니다.
+func findKeyDeployedServers(keyID uint) ([]models.Server, error) {
+	deployed, err := currentDeployedServerIDs([]uint{keyID})
+	if err != nil {
+		return nil, err
+	}
+
+	serverIDs := deployed[keyID]
+	if len(serverIDs) == 0 {
+		return nil, nil
+	}
+
+	var servers []models.Server
+	if err := models.DB.Where("id IN ?", serverIDs).Order("id").Find(&servers).Error; err != nil {
+		log.Printf("❌ 배포 서버 조회 실패: %v", err)
+		return nil, err
+	}
+	return servers, nil
+}
+
+// currentDeployedServerIDs는 키별로 현재 배포되어 있는 서버 ID를 조회합니다.
+// 성공한 배포/제거 기록을 순서대로 적용하여, 배포 후 제거된 서버는 제외합니다.
+func currentDeployedServerIDs(keyIDs []uint) (map[uint][]uint, error) {
+	result := make(map[uint][]uint)
+	if len(keyIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		SSHKeyID uint
+		ServerID uint
+		Action   string
+	}
+	err := models.DB.Model(&models.ServerKeyDeployment{}).
+		Select("ssh_key_id, server_id, action").
+		Where("ssh_key_id IN ? AND status = ?", keyIDs, "success").
+		Order("id").
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("❌ 배포 현황 조회 실패: %v", err)
+		return nil, err
+	}
+
+	type keyServer struct{ keyID, serverID uint }
+	state := make(map[keyServer]bool)
+	var order []keyServer
+	for _, row := range rows {
+		ks := keyServer{row.SSHKeyID, row.ServerID}
+		if _, seen := state[ks]; !seen {
+			order = append(order, ks)
+		}
+		state[ks] = row.Action != models.DeploymentActionRemove
+	}
+
+	for _, ks := range order {
+		if state[ks] {
+			result[ks.keyID] = append(result[ks.keyID], ks.serverID)
+		}
+	}
+	return result, nil
+}
+
+// removeKeyFromServer는 원격 서버의 authorized_keys에서 키를 제거하고 배포 기록에 남깁니다.
+func removeKeyFromServer(ctx context.Context, key *models.SSHKey, server models.Server) (*models.ServerKeyDeployment, error) {
+	log.Printf("🗑️ 서버에서 키 제거 중: %s (%s:%d, 키 ID: %d)", server.Name, server.Host, server.Port, key.ID)
+
+	record := models.ServerKeyDeployment{
+		ServerID: server.ID,
+		SSHKeyID: key.ID,
+		UserID:   key.UserID,
+		Action:   models.DeploymentActionRemove,
+		Status:   "pending",
+	}
+	models.DB.Create(&record)
+
+	// 스냅샷을 남긴 뒤 제거 (호스트 키 변경 감지 시 제거하지 않음)
+	err := retryAuthorizedKeysChange(ctx, &server, &record, func(ctx context.Context, deployer utils.Deployer) error {
+		return deployer.Remove(ctx, key.PublicKey)
+	})
+	if err != nil {
+		record.Status = "failed"
+		record.ErrorMsg = err.Error()
+		log.Printf("❌ 키 제거 실패 [%s]: %v", server.Name, err)
+	} else {
+		record.Status = "success"
+		now := gorm.DeletedAt{Time: time.Now(), Valid: true}
+		record.DeployedAt = &now
+		log.Printf("✅ 키 제거 성공: %s", server.Name)
+	}
+
+	models.DB.Save(&record)
+	return &record, err
+}
+
+// notifyKeyRotation은 교체 결과를 키 소유자에게 알립니다.
+func notifyKeyRotation(oldKey *models.SSHKey, originalName string, newKey *models.SSHKey, rotation models.KeyRotation, result *types.KeyRotationResult) {
+	reasonText := "요청에 따라"
+	if rotation.Reason == models.RotationReasonExpired {
+		reasonText = "만료되어"
+	}
+
+	var message strings.Builder
+	notificationType := models.NotificationTypeSuccess
+	title := "SSH 키 교체 완료"
+
+	if newKey == nil {
+		notificationType = models.NotificationTypeWarning
+		title = "SSH 키 폐기"
+		fmt.Fprintf(&message, "키 '%s'가 %s 폐기되었고 서버 %d대에서 제거되었습니다. 새 키를 생성하거나 가져와 주세요.",
+			originalName, reasonText, rotation.RemovedCount)
+	} else {
+		fmt.Fprintf(&message, "키 '%s'가 %s 새 키(ID: %d)로 교체되었습니다. 새 키 배포: %d대, 기존 키 제거: %d대. 새 개인키를 내려받아 사용해주세요.",
+			originalName, reasonText, newKey.ID, rotation.DeployedCount, rotation.RemovedCount)
+	}
+
+	if rotation.FailedCount > 0 {
+		notificationType = models.NotificationTypeError
+		title = "SSH 키 교체 일부 실패"
+		fmt.Fprintf(&message, " 서버 %d대에서 처리에 실패했습니다:", rotation.FailedCount)
+		for _, server := range result.Servers {
+			if server.DeployStatus == "failed" || server.RemoveStatus == "failed" || server.RemoveStatus == "skipped" {
+				fmt.Fprintf(&message, " %s", server.ServerName)
+			}
+		}
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(&message, " %s.", strings.TrimSuffix(warning, "."))
+	}
+
+	notifyUser(oldKey.UserID, notificationType, title, message.String())
+}
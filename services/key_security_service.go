@@ -0,0 +1,256 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strings"
+	"time"
+)
+
+// 키 보안 등급입니다.
+const (
+	SecurityLevelHigh   = "HIGH"
+	SecurityLevelMedium = "MEDIUM"
+	SecurityLevelLow    = "LOW"
+)
+
+// keySecurityPolicy는 키 보안 평가 기준입니다.
+var keySecurityPolicy = struct {
+	HighScore        int // 이 점수 이상이면 HIGH
+	MediumScore      int // 이 점수 이상이면 MEDIUM
+	RotationDays     int // 교체 권장 기간
+	MaxAgeDays       int // 최대 사용 기간 (초과 시 문제로 분류)
+	StaleDays        int // 한 번도 배포되지 않은 키를 미사용으로 보는 기간
+	WideSpreadServer int // 광범위 배포로 보는 서버 수
+}{
+	HighScore:        80,
+	MediumScore:      50,
+	RotationDays:     365,
+	MaxAgeDays:       730,
+	StaleDays:        90,
+	WideSpreadServer: 20,
+}
+
+// keySecurityFacts는 키 자체 외에 평가에 필요한 정보입니다.
+type keySecurityFacts struct {
+	AgeDays         int
+	DeployedServers int
+}
+
+// keySecurityFinding은 규칙 하나의 평가 결과입니다.
+type keySecurityFinding struct {
+	Penalty        int
+	Issue          string // 즉시 조치가 필요한 문제
+	Recommendation string // 개선 권장 사항
+}
+
+// keySecurityRules는 순서대로 적용되는 키 보안 규칙입니다.
+var keySecurityRules = []func(key *models.SSHKey, facts keySecurityFacts) *keySecurityFinding{
+	evaluateKeyAlgorithm,
+	evaluateKeyAge,
+	evaluateKeyCustody,
+	evaluateKeyDeployment,
+}
+
+// CheckKeySecurity는 사용자가 소유한 키의 보안 평가 결과를 반환합니다.
+func CheckKeySecurity(userID, keyID uint) (*types.SSHKeySecurityCheck, error) {
+	key, err := findOwnedKey(userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	deployed, err := countDeployedServers([]uint{key.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	check := evaluateKeySecurity(key, deployed[key.ID], time.Now())
+	log.Printf("🛡️ 키 보안 평가 완료 (키 ID: %d, 점수: %d, 등급: %s)", key.ID, check.Score, check.SecurityLevel)
+	return &check, nil
+}
+
+// GetKeySecurityReport는 전체 키를 평가하여 점수가 낮은 순으로 보고서를 만듭니다 (관리자용).
+// level을 지정하면 해당 등급의 키만 포함합니다 (집계는 전체 기준).
+func GetKeySecurityReport(level string) (*types.SSHKeySecurityReport, error) {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if level != "" && level != SecurityLevelHigh && level != SecurityLevelMedium && level != SecurityLevelLow {
+		return nil, fmt.Errorf("유효하지 않은 보안 등급입니다: %s (HIGH, MEDIUM, LOW)", level)
+	}
+
+	var keys []models.SSHKey
+	if err := models.DB.Preload("User").
+		Omit("private_key", "pem", "ppk", "wrapped_data_key").
+		Order("id").Find(&keys).Error; err != nil {
+		log.Printf("❌ 키 목록 조회 실패: %v", err)
+		return nil, err
+	}
+
+	keyIDs := make([]uint, 0, len(keys))
+	for _, key := range keys {
+		keyIDs = append(keyIDs, key.ID)
+	}
+	deployed, err := countDeployedServers(keyIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &types.SSHKeySecurityReport{
+		GeneratedAt: now,
+		TotalKeys:   len(keys),
+		LevelCounts: map[string]int{SecurityLevelHigh: 0, SecurityLevelMedium: 0, SecurityLevelLow: 0},
+		Checks:      []types.SSHKeySecurityCheck{},
+	}
+
+	totalScore := 0
+	for i := range keys {
+		check := evaluateKeySecurity(&keys[i], deployed[keys[i].ID], now)
+		check.Username = keys[i].User.Username
+
+		totalScore += check.Score
+		report.LevelCounts[check.SecurityLevel]++
+		if level == "" || check.SecurityLevel == level {
+			report.Checks = append(report.Checks, check)
+		}
+	}
+
+	if len(keys) > 0 {
+		report.AverageScore = float64(totalScore) / float64(len(keys))
+	}
+	sort.SliceStable(report.Checks, func(i, j int) bool {
+		return report.Checks[i].Score < report.Checks[j].Score
+	})
+
+	log.Printf("🛡️ 키 보안 보고서 생성 완료 (전체: %d, HIGH: %d, MEDIUM: %d, LOW: %d)", report.TotalKeys,
+		report.LevelCounts[SecurityLevelHigh], report.LevelCounts[SecurityLevelMedium], report.LevelCounts[SecurityLevelLow])
+	return report, nil
+}
+
+// evaluateKeySecurity는 보안 규칙을 적용하여 점수와 등급을 계산합니다.
+// 개인키 필드는 보관 여부만 확인하므로 복호화되지 않은 키를 넘겨도 됩니다.
+func evaluateKeySecurity(key *models.SSHKey, deployedServers int, now time.Time) types.SSHKeySecurityCheck {
+	facts := keySecurityFacts{
+		AgeDays:         int(now.Sub(key.CreatedAt).Hours() / 24),
+		DeployedServers: deployedServers,
+	}
+
+	check := types.SSHKeySecurityCheck{
+		KeyID:           key.ID,
+		KeyName:         key.Name,
+		UserID:          key.UserID,
+		Algorithm:       key.Algorithm,
+		Bits:            key.Bits,
+		Status:          key.Status,
+		AgeDays:         facts.AgeDays,
+		DeployedServers: facts.DeployedServers,
+		PrivateKeyHeld:  keyHoldsPrivateMaterial(key),
+		Score:           100,
+		Issues:          []string{},
+		Recommendations: []string{},
+	}
+
+	for _, rule := range keySecurityRules {
+		finding := rule(key, facts)
+		if finding == nil {
+			continue
+		}
+		check.Score -= finding.Penalty
+		if finding.Issue != "" {
+			check.Issues = append(check.Issues, finding.Issue)
+		}
+		if finding.Recommendation != "" {
+			check.Recommendations = append(check.Recommendations, finding.Recommendation)
+		}
+	}
+
+	if check.Score < 0 {
+		check.Score = 0
+	}
+	switch {
+	case check.Score >= keySecurityPolicy.HighScore:
+		check.SecurityLevel = SecurityLevelHigh
+	case check.Score >= keySecurityPolicy.MediumScore:
+		check.SecurityLevel = SecurityLevelMedium
+	default:
+		check.SecurityLevel = SecurityLevelLow
+	}
+
+	return check
+}
+
+// evaluateKeyAlgorithm은 알고리즘과 키 크기를 평가합니다.
+func evaluateKeyAlgorithm(key *models.SSHKey, facts keySecurityFacts) *keySecurityFinding {
+	switch key.Algorithm {
+	case utils.KeyAlgorithmDSA:
+		return &keySecurityFinding{Penalty: 60, Issue: "DSA 키는 더 이상 안전하지 않습니다", Recommendation: "Ed25519 키로 교체하세요"}
+	case utils.KeyAlgorithmRSA:
+		switch {
+		case key.Bits < 2048:
+			return &keySecurityFinding{Penalty: 50, Issue: fmt.Sprintf("RSA %d비트 키는 안전하지 않습니다", key.Bits), Recommendation: "Ed25519 또는 RSA 3072비트 이상 키로 교체하세요"}
+		case key.Bits < recommendedRSABits:
+			return &keySecurityFinding{Penalty: 15, Recommendation: fmt.Sprintf("RSA %d비트 키입니다. Ed25519 또는 RSA %d비트 이상으로 교체를 권장합니다", key.Bits, recommendedRSABits)}
+		}
+	}
+	return nil
+}
+
+// evaluateKeyAge는 키 사용 기간을 평가합니다.
+func evaluateKeyAge(key *models.SSHKey, facts keySecurityFacts) *keySecurityFinding {
+	switch {
+	case facts.AgeDays > keySecurityPolicy.MaxAgeDays:
+		return &keySecurityFinding{Penalty: 25, Issue: fmt.Sprintf("%d일 동안 교체되지 않은 키입니다 (최대 %d일)", facts.AgeDays, keySecurityPolicy.MaxAgeDays), Recommendation: "새 키를 생성하여 교체하세요"}
+	case facts.AgeDays > keySecurityPolicy.RotationDays:
+		return &keySecurityFinding{Penalty: 10, Recommendation: fmt.Sprintf("생성 후 %d일이 지났습니다. 키 교체를 권장합니다", facts.AgeDays)}
+	}
+	return nil
+}
+
+// evaluateKeyCustody는 서버의 개인키 보관 여부와 패스프레이즈 보호를 평가합니다.
+func evaluateKeyCustody(key *models.SSHKey, facts keySecurityFacts) *keySecurityFinding {
+	if !keyHoldsPrivateMaterial(key) {
+		return nil
+	}
+	if !key.PassphraseProtected {
+		return &keySecurityFinding{Penalty: 20, Issue: "서버에 패스프레이즈 없는 개인키가 보관되어 있습니다", Recommendation: "개인키를 내려받은 뒤 공개키만 남기거나 패스프레이즈로 보호된 키로 교체하세요"}
+	}
+	return &keySecurityFinding{Penalty: 5, Recommendation: "서버에 개인키가 보관되어 있습니다. 가능하면 공개키만 등록하세요"}
+}
+
+// evaluateKeyDeployment는 배포 범위와 사용 여부를 평가합니다.
+func evaluateKeyDeployment(key *models.SSHKey, facts keySecurityFacts) *keySecurityFinding {
+	switch {
+	case key.Status == models.KeyStatusRevoked && facts.DeployedServers > 0:
+		return &keySecurityFinding{Penalty: 30, Issue: fmt.Sprintf("폐기된 키가 서버 %d대에 배포되어 있습니다", facts.DeployedServers), Recommendation: "배포된 서버에서 키를 제거하세요"}
+	case facts.DeployedServers >= keySecurityPolicy.WideSpreadServer:
+		return &keySecurityFinding{Penalty: 15, Issue: fmt.Sprintf("키가 서버 %d대에 배포되어 있어 유출 시 영향 범위가 큽니다", facts.DeployedServers), Recommendation: "용도별로 키를 분리하세요"}
+	case facts.DeployedServers == 0 && facts.AgeDays > keySecurityPolicy.StaleDays:
+		return &keySecurityFinding{Penalty: 5, Recommendation: fmt.Sprintf("%d일 동안 배포되지 않은 키입니다. 사용하지 않는다면 삭제하세요", facts.AgeDays)}
+	}
+	return nil
+}
+
+// keyHoldsPrivateMaterial은 서버에 개인키가 보관되어 있는지 확인합니다.
+func keyHoldsPrivateMaterial(key *models.SSHKey) bool {
+	if key.Source != "" {
+		return key.Source != models.KeySourceImportedPublic
+	}
+	return key.PrivateKey != ""
+}
+
+// countDeployedServers는 키별로 현재 배포되어 있는 서버 수(중복 제외, 제거된 서버 제외)를 조회합니다.
+func countDeployedServers(keyIDs []uint) (map[uint]int, error) {
+	deployed, err := currentDeployedServerIDs(keyIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int, len(deployed))
+	for keyID, serverIDs := range deployed {
+		counts[keyID] = len(serverIDs)
+	}
+	return counts, nil
+}
@@ -6,122 +6,160 @@ import (
 	"log"
 	"ssh-key-manager/config"
 	"ssh-key-manager/models"
+	"ssh-key-manager/types"
 	"ssh-key-manager/utils"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 // GenerateSSHKeyPair는 로그인된 사용자의 SSH 키 쌍을 생성하고 DB에 저장합니다.
-func GenerateSSHKeyPair(userID uint) (*models.SSHKey, error) {
+// 알고리즘이 지정되지 않으면 설정의 기본 알고리즘(KEY_ALGORITHM)을 사용합니다.
+func GenerateSSHKeyPair(userID uint, opts types.SSHKeyGenerationOptions) (*models.SSHKey, error) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return nil, err
 	}
 
+	// 알고리즘 및 키 크기 결정
+	algorithm := opts.Algorithm
+	if strings.TrimSpace(algorithm) == "" {
+		algorithm = cfg.KeyAlgorithm
+	}
+	bits := opts.Bits
+	if bits == 0 && strings.EqualFold(strings.TrimSpace(algorithm), "rsa") {
+		bits = cfg.KeyBits
+	}
+	algorithm, bits, err = utils.NormalizeKeyAlgorithm(algorithm, bits)
+	if err != nil {
+		return nil, err
+	}
+
 	// 현재 사용자 정보 조회 (코멘트용)
 	var user models.User
 	if err := models.DB.First(&user, userID).Error; err != nil {
 		return nil, errors.New("사용자를 찾을 수 없습니다")
 	}
 
-	// 기존 키가 있으면 서버에서 제거
-	if cfg.AutoInstallKeys {
-		if existingKey, err := GetKeyByUserID(userID); err == nil {
-			log.Printf("🔄 기존 키를 서버에서 제거 중...")
-			if removeErr := utils.RemovePublicKeyFromServer(existingKey.PublicKey, cfg.SSHUser, cfg.SSHHomePath); removeErr != nil {
-				log.Printf("⚠️ 기존 키 제거 실패 (계속 진행): %v", removeErr)
-			}
-		}
+	comment := strings.TrimSpace(opts.Comment)
+	if comment == "" {
+		comment = user.Username
 	}
 
-	log.Printf("🚀 SSH 키 쌍 생성 시작 (사용자: %s)", user.Username)
-
-	// 1. RSA 키 쌍 생성 (개인키 생성하면 공개키도 함께 생성됨)
-	privateKey, err := utils.GeneratePrivateKey(cfg.KeyBits)
+	name, err := normalizeKeyName(opts.Name)
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("   ✅ RSA 키 쌍 생성 완료 (개인키 + 공개키)")
 
-	// 2. 개인키를 PEM 형식으로 인코딩 (OpenSSH, Linux/macOS 용)
-	pemKey := utils.EncodePrivateKeyToPEM(privateKey)
-	log.Printf("   📄 PEM 형식 개인키 생성 완료")
+	if err := validatePassphrase(opts.Passphrase); err != nil {
+		return nil, err
+	}
+	passphraseProtected := opts.Passphrase != ""
 
-	// 3. 개인키에서 공개키 추출하여 SSH 형식으로 변환 (authorized_keys 용)
-	publicKey, err := utils.GeneratePublicKeyWithUserComment(privateKey, user.Username)
+	// 만료 시각 결정 (부서/조직 최대 사용 기간 적용)
+	expiresAt, err := resolveKeyExpiry(cfg, userID, opts.ExpiresInDays, time.Now())
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("   🔑 SSH 공개키 생성 완료 (코멘트: %s)", user.Username)
 
-	// 4. 개인키를 PPK 형식으로 변환 (PuTTY 용)
-	ppkKey, err := utils.EncodePrivateKeyToPPKWithUser(privateKey, user.Username)
+	// 같은 이름의 키가 있으면 강제 교체 옵션이 있을 때만 교체
+	existingKey, err := findKeyByName(userID, name)
 	if err != nil {
-		log.Printf("⚠️ PPK 생성 실패, 기본 방법으로 재시도: %v", err)
-		// PPK 생성 실패 시 기본 방법으로 재시도
-		ppkKey, err = utils.EncodePrivateKeyToPPK(privateKey)
-		if err != nil {
-			return nil, errors.New("PPK 키 생성에 실패했습니다. 시스템에 puttygen이 설치되어 있는지 확인하세요")
+		return nil, err
+	}
+	if existingKey != nil {
+		if !opts.ForceReplace {
+			return nil, fmt.Errorf("이미 사용 중인 키 이름입니다: %s", name)
 		}
+
+		// 기존 키가 있으면 서버에서 제거
+		removeReplacedKeyLocally(cfg, existingKey)
 	}
-	log.Printf("   🔧 PPK 형식 개인키 생성 완료")
 
-	// 5. 로컬 서버에 공개키 자동 설치 (설정이 활성화된 경우)
-	var installationStatus string
-	if cfg.AutoInstallKeys {
-		log.Printf("🔧 로컬 서버 자동 설치 시작...")
+	log.Printf("🚀 SSH 키 쌍 생성 시작 (사용자: %s, 알고리즘: %s)", user.Username, algorithm)
 
-		// SSH 설정 검증
-		if err := utils.ValidateSSHConfig(cfg.SSHUser, cfg.SSHHomePath); err != nil {
-			log.Printf("⚠️ SSH 설정 검증 실패: %v", err)
-			installationStatus = fmt.Sprintf("자동 설치 실패: %v", err)
-		} else {
-			// 공개키 설치
-			if err := utils.InstallPublicKeyToServer(string(publicKey), cfg.SSHUser, cfg.SSHHomePath); err != nil {
-				log.Printf("⚠️ 공개키 자동 설치 실패: %v", err)
-				installationStatus = fmt.Sprintf("자동 설치 실패: %v", err)
-			} else {
-				log.Printf("✅ 로컬 서버에 공개키 자동 설치 완료")
-				installationStatus = "로컬 서버에 자동 설치 완료"
-			}
+	// 1. 키 쌍 생성 (개인키 생성하면 공개키도 함께 생성됨)
+	privateKey, err := utils.GenerateKeyPair(algorithm, bits)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("   ✅ %s 키 쌍 생성 완료 (개인키 + 공개키)", algorithm)
+
+	// 2. 개인키를 OpenSSH 형식으로 인코딩 (모든 알고리즘 공통, Linux/macOS 용)
+	// 패스프레이즈가 있으면 bcrypt-pbkdf + aes256-ctr로 암호화
+	opensshKey, err := utils.EncodePrivateKeyToOpenSSHWithPassphrase(privateKey, comment, opts.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("   📄 OpenSSH 형식 개인키 생성 완료 (암호화: %t)", passphraseProtected)
+
+	// 3. 개인키를 PEM 형식으로 인코딩 (RSA: PKCS#1, ECDSA: SEC1, Ed25519: PKCS#8)
+	// 전통적인 PEM 암호화는 안전하지 않으므로 패스프레이즈 보호 키는 PEM을 만들지 않음
+	var pemKey []byte
+	if !passphraseProtected {
+		pemKey, err = utils.EncodePrivateKeyToPEM(privateKey)
+		if err != nil {
+			return nil, err
 		}
+		log.Printf("   📄 PEM 형식 개인키 생성 완료")
 	} else {
-		log.Printf("📋 자동 설치 비활성화됨 (AUTO_INSTALL_KEYS=false)")
-		installationStatus = "자동 설치 비활성화됨"
+		log.Printf("   📄 패스프레이즈 보호 키는 PEM 형식을 제공하지 않음 (OpenSSH/PPK 사용)")
 	}
 
-	// 6. DB에 저장 또는 업데이트 (Upsert)
-	sshKey := &models.SSHKey{
-		UserID:     userID,
-		Algorithm:  "RSA",
-		Bits:       cfg.KeyBits,
-		PrivateKey: string(pemKey),
-		PublicKey:  string(publicKey),
-		PEM:        string(pemKey),
-		PPK:        string(ppkKey),
-	}
-
-	// 기존 키가 있으면 업데이트, 없으면 새로 생성
-	err = models.DB.Where(models.SSHKey{UserID: userID}).Assign(models.SSHKey{
-		Algorithm:  "RSA",
-		Bits:       cfg.KeyBits,
-		PrivateKey: string(pemKey),
-		PublicKey:  string(publicKey),
-		PEM:        string(pemKey),
-		PPK:        string(ppkKey),
-	}).FirstOrCreate(sshKey).Error
+	// 4. 개인키에서 공개키 추출하여 SSH 형식으로 변환 (authorized_keys 용)
+	publicKey, err := utils.GeneratePublicKeyWithUserComment(privateKey, comment)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("   🔑 SSH 공개키 생성 완료 (코멘트: %s)", comment)
 
+	// 5. 개인키를 PPK 형식으로 변환 (PuTTY 용, 패스프레이즈가 있으면 aes256-cbc 암호화)
+	ppkKey, err := utils.EncodePPK(privateKey, utils.PPKEncodeOptions{
+		Version:    cfg.PPKVersion,
+		Comment:    comment,
+		Passphrase: opts.Passphrase,
+	})
 	if err != nil {
-		log.Printf("❌ 키 저장 실패: %v", err)
+		return nil, err
+	}
+	log.Printf("   🔧 PPK v%d 형식 개인키 생성 완료", cfg.PPKVersion)
+
+	// 6. 로컬 서버에 공개키 자동 설치 (설정이 활성화된 경우)
+	installationStatus := installPublicKeyLocally(cfg, string(publicKey))
+
+	// 7. DB에 저장 (같은 이름의 키를 교체하는 경우 기존 행을 갱신)
+	sshKey := &models.SSHKey{
+		UserID:              userID,
+		Name:                name,
+		Comment:             comment,
+		Status:              models.KeyStatusActive,
+		Algorithm:           algorithm,
+		Bits:                bits,
+		PrivateKey:          string(opensshKey),
+		PublicKey:           string(publicKey),
+		PEM:                 string(pemKey),
+		PPK:                 string(ppkKey),
+		PassphraseProtected: passphraseProtected,
+		Source:              models.KeySourceGenerated,
+		ExpiresAt:           expiresAt,
+	}
+
+	if err := storeSSHKey(sshKey, existingKey); err != nil {
 		return nil, err
 	}
 
 	log.Printf("✅ SSH 키 쌍 생성 및 저장 완료")
 	log.Printf("   - 사용자: %s", user.Username)
-	log.Printf("   - 알고리즘: RSA")
-	log.Printf("   - 키 크기: %d bits", cfg.KeyBits)
-	log.Printf("   - 개인키 형식: PEM (OpenSSH용), PPK (PuTTY용)")
-	log.Printf("   - 공개키 코멘트: %s", user.Username)
+	log.Printf("   - 키 이름: %s (ID: %d)", name, sshKey.ID)
+	log.Printf("   - 알고리즘: %s", algorithm)
+	log.Printf("   - 키 크기: %d bits", bits)
+	log.Printf("   - 개인키 형식: OpenSSH, PEM/PKCS#8, PPK (PuTTY용)")
+	log.Printf("   - 공개키 코멘트: %s", comment)
+	log.Printf("   - 패스프레이즈 보호: %t", passphraseProtected)
+	if expiresAt != nil {
+		log.Printf("   - 만료: %s", expiresAt.Format("2006-01-02 15:04"))
+	}
 	log.Printf("   - 자동 설치 상태: %s", installationStatus)
 	log.Printf("📋 생성된 키 쌍:")
 	log.Printf("   🔒 개인키: 클라이언트에서 사용 (절대 공유하지 마세요!)")
@@ -130,23 +168,208 @@ func GenerateSSHKeyPair(userID uint) (*models.SSHKey, error) {
 	return sshKey, nil
 }
 
-// GetKeyByUserID는 사용자의 키를 조회합니다.
-func GetKeyByUserID(userID uint) (*models.SSHKey, error) {
+// storeSSHKey는 개인키 필드를 봉투 암호화하여 저장합니다.
+// existingKey가 있으면 해당 행을 교체하며, 저장 후 sshKey에는 복호화된 값이 다시 채워집니다.
+// 암호문을 행 ID에 묶으므로 새 키는 개인키 없이 행을 먼저 만든 뒤 같은 트랜잭션에서 암호화한 값을 저장합니다.
+func storeSSHKey(sshKey *models.SSHKey, existingKey *models.SSHKey) error {
+	if err := applyFingerprints(sshKey); err != nil {
+		return err
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if existingKey != nil {
+			sshKey.ID = existingKey.ID
+			sshKey.CreatedAt = existingKey.CreatedAt
+		} else {
+			privateKey, pem, ppk := sshKey.PrivateKey, sshKey.PEM, sshKey.PPK
+			sshKey.PrivateKey, sshKey.PEM, sshKey.PPK = "", "", ""
+			if err := tx.Create(sshKey).Error; err != nil {
+				return err
+			}
+			sshKey.PrivateKey, sshKey.PEM, sshKey.PPK = privateKey, pem, ppk
+		}
+
+		// 개인키 필드는 봉투 암호화하여 저장
+		if err := sealKeyMaterial(sshKey); err != nil {
+			return err
+		}
+		return tx.Save(sshKey).Error
+	})
+	if err != nil {
+		log.Printf("❌ 키 저장 실패: %v", err)
+		// 동시에 같은 이름으로 생성한 경우 고유 인덱스에서 거부됨
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("이미 사용 중인 키 이름입니다: %s", sshKey.Name)
+		}
+		return err
+	}
+
+	// 응답에는 복호화된 개인키를 반환
+	return openKeyMaterial(sshKey)
+}
+
+// applyFingerprints는 공개키로 SHA256 / MD5 핑거프린트를 계산하여 키에 설정합니다.
+func applyFingerprints(key *models.SSHKey) error {
+	fingerprints, err := utils.ComputeFingerprints(key.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	key.FingerprintSHA256 = fingerprints.SHA256
+	key.FingerprintMD5 = fingerprints.MD5
+	return nil
+}
+
+// keyFingerprint는 저장된 SHA256 핑거프린트를 반환합니다.
+// 아직 백필되지 않은 행은 공개키로 계산하여 채웁니다.
+func keyFingerprint(key *models.SSHKey) string {
+	if key.FingerprintSHA256 == "" {
+		if err := applyFingerprints(key); err != nil {
+			log.Printf("⚠️ 핑거프린트 생성 실패 (키 ID: %d): %v", key.ID, err)
+		}
+	}
+	return key.FingerprintSHA256
+}
+
+// installPublicKeyLocally는 설정이 활성화된 경우 로컬 서버에 공개키를 설치하고 결과를 반환합니다.
+func installPublicKeyLocally(cfg *config.Config, publicKey string) string {
+	if !cfg.AutoInstallKeys {
+		log.Printf("📋 자동 설치 비활성화됨 (AUTO_INSTALL_KEYS=false)")
+		return "자동 설치 비활성화됨"
+	}
+
+	log.Printf("🔧 로컬 서버 자동 설치 시작...")
+
+	// SSH 설정 검증
+	if err := utils.ValidateSSHConfig(cfg.SSHUser, cfg.SSHHomePath); err != nil {
+		log.Printf("⚠️ SSH 설정 검증 실패: %v", err)
+		return fmt.Sprintf("자동 설치 실패: %v", err)
+	}
+
+	// 공개키 설치
+	if err := utils.InstallPublicKeyToServer(publicKey, cfg.SSHUser, cfg.SSHHomePath); err != nil {
+		log.Printf("⚠️ 공개키 자동 설치 실패: %v", err)
+		return fmt.Sprintf("자동 설치 실패: %v", err)
+	}
+
+	log.Printf("✅ 로컬 서버에 공개키 자동 설치 완료")
+	return "로컬 서버에 자동 설치 완료"
+}
+
+// removeReplacedKeyLocally는 교체되는 기존 키를 로컬 서버에서 제거합니다 (설정이 활성화된 경우).
+func removeReplacedKeyLocally(cfg *config.Config, existingKey *models.SSHKey) {
+	if !cfg.AutoInstallKeys {
+		return
+	}
+
+	log.Printf("🔄 기존 키를 서버에서 제거 중...")
+	if err := utils.RemovePublicKeyFromServer(existingKey.PublicKey, cfg.SSHUser, cfg.SSHHomePath); err != nil {
+		log.Printf("⚠️ 기존 키 제거 실패 (계속 진행): %v", err)
+	}
+}
+
+// ListKeysByUserID는 사용자의 모든 키를 최신순으로 조회합니다.
+// 목록 응답에는 개인키(PEM, PPK)가 포함되지 않습니다.
+func ListKeysByUserID(userID uint) ([]types.SSHKeyResponse, error) {
+	var keys []models.SSHKey
+	result := models.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys)
+	if result.Error != nil {
+		log.Printf("❌ 키 목록 조회 실패: %v", result.Error)
+		return nil, result.Error
+	}
+
+	responses := make([]types.SSHKeyResponse, 0, len(keys))
+	for i := range keys {
+		fingerprint := keyFingerprint(&keys[i])
+		responses = append(responses, types.ToSSHKeySummary(keys[i], fingerprint))
+	}
+
+	log.Printf("🔍 키 목록 조회 완료 (사용자 ID: %d, 총 %d개)", userID, len(keys))
+	return responses, nil
+}
+
+// GetKeyByID는 사용자가 소유한 특정 키를 조회하고 개인키를 복호화합니다.
+func GetKeyByID(userID, keyID uint) (*models.SSHKey, error) {
+	key, err := findOwnedKey(userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := openKeyMaterial(key); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔍 키 조회 완료 (사용자 ID: %d, 키 ID: %d)", userID, keyID)
+	return key, nil
+}
+
+// findOwnedKey는 사용자가 소유한 키를 복호화 없이 조회합니다 (공개키만 필요한 경우).
+func findOwnedKey(userID, keyID uint) (*models.SSHKey, error) {
 	var key models.SSHKey
-	result := models.DB.Where("user_id = ?", userID).First(&key)
+	result := models.DB.Where("id = ? AND user_id = ?", keyID, userID).First(&key)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("키를 찾을 수 없습니다. 먼저 키를 생성해주세요")
+			return nil, errors.New("키를 찾을 수 없습니다")
 		}
 		return nil, result.Error
 	}
-
-	log.Printf("🔍 키 조회 완료 (사용자 ID: %d)", userID)
 	return &key, nil
 }
 
-// DeleteKeyByUserID는 사용자의 키를 삭제합니다.
-func DeleteKeyByUserID(userID uint) error {
+// UpdateKey는 키 이름과 상태를 변경합니다.
+func UpdateKey(userID, keyID uint, req types.SSHKeyUpdateRequest) (*models.SSHKey, error) {
+	key, err := findOwnedKey(userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+
+	if strings.TrimSpace(req.Name) != "" && strings.TrimSpace(req.Name) != key.Name {
+		name, err := normalizeKeyName(req.Name)
+		if err != nil {
+			return nil, err
+		}
+		existingKey, err := findKeyByName(userID, name)
+		if err != nil {
+			return nil, err
+		}
+		if existingKey != nil {
+			return nil, fmt.Errorf("이미 사용 중인 키 이름입니다: %s", name)
+		}
+		updates["name"] = name
+	}
+	if req.Status != "" && req.Status != key.Status {
+		if req.Status != models.KeyStatusActive && req.Status != models.KeyStatusRevoked {
+			return nil, errors.New("유효하지 않은 키 상태입니다. 'active' 또는 'revoked'만 가능합니다")
+		}
+		if req.Status == models.KeyStatusActive && isKeyExpired(key, time.Now()) {
+			return nil, errors.New("유효하지 않은 키 상태입니다. 만료된 키는 다시 활성화할 수 없습니다")
+		}
+		updates["status"] = req.Status
+	}
+
+	if len(updates) > 0 {
+		if err := models.DB.Model(key).Updates(updates).Error; err != nil {
+			log.Printf("❌ 키 업데이트 실패: %v", err)
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return nil, fmt.Errorf("이미 사용 중인 키 이름입니다: %s", updates["name"])
+			}
+			return nil, errors.New("키 업데이트 중 오류가 발생했습니다")
+		}
+	}
+
+	log.Printf("✅ 키 업데이트 완료 (키 ID: %d)", keyID)
+	return GetKeyByID(userID, keyID)
+}
+
+// DeleteKeyByID는 사용자의 특정 키를 삭제합니다.
+func DeleteKeyByID(userID, keyID uint) error {
+	existingKey, err := findOwnedKey(userID, keyID)
+	if err != nil {
+		return err
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Printf("⚠️ 설정 로드 실패, 자동 제거 건너뜀: %v", err)
@@ -154,23 +377,21 @@ func DeleteKeyByUserID(userID uint) error {
 
 	// 삭제 전에 서버에서 공개키 제거 (설정이 활성화된 경우)
 	if cfg != nil && cfg.AutoInstallKeys {
-		if existingKey, err := GetKeyByUserID(userID); err == nil {
-			log.Printf("🗑️ 서버에서 SSH 공개키 자동 제거 중...")
+		log.Printf("🗑️ 서버에서 SSH 공개키 자동 제거 중...")
 
-			if err := utils.ValidateSSHConfig(cfg.SSHUser, cfg.SSHHomePath); err != nil {
-				log.Printf("⚠️ SSH 설정 검증 실패: %v", err)
+		if err := utils.ValidateSSHConfig(cfg.SSHUser, cfg.SSHHomePath); err != nil {
+			log.Printf("⚠️ SSH 설정 검증 실패: %v", err)
+		} else {
+			if err := utils.RemovePublicKeyFromServer(existingKey.PublicKey, cfg.SSHUser, cfg.SSHHomePath); err != nil {
+				log.Printf("⚠️ 서버에서 공개키 제거 실패: %v", err)
 			} else {
-				if err := utils.RemovePublicKeyFromServer(existingKey.PublicKey, cfg.SSHUser, cfg.SSHHomePath); err != nil {
-					log.Printf("⚠️ 서버에서 공개키 제거 실패: %v", err)
-				} else {
-					log.Printf("✅ 서버에서 공개키 자동 제거 완료")
-				}
+				log.Printf("✅ 서버에서 공개키 자동 제거 완료")
 			}
 		}
 	}
 
 	// DB에서 키 삭제
-	result := models.DB.Where("user_id = ?", userID).Delete(&models.SSHKey{})
+	result := models.DB.Where("id = ? AND user_id = ?", keyID, userID).Delete(&models.SSHKey{})
 	if result.Error != nil {
 		log.Printf("❌ 키 삭제 실패: %v", result.Error)
 		return result.Error
@@ -179,6 +400,77 @@ func DeleteKeyByUserID(userID uint) error {
 		return errors.New("삭제할 키를 찾을 수 없습니다")
 	}
 
-	log.Printf("🗑️ 키 삭제 완료 (사용자 ID: %d)", userID)
+	log.Printf("🗑️ 키 삭제 완료 (사용자 ID: %d, 키 ID: %d)", userID, keyID)
 	return nil
 }
+
+// resolveDeploymentKey는 배포에 사용할 키를 결정합니다.
+// 키 ID가 지정되지 않은 경우 활성 키가 하나뿐일 때만 해당 키를 사용합니다.
+func resolveDeploymentKey(userID, keyID uint) (*models.SSHKey, error) {
+	if keyID != 0 {
+		key, err := findOwnedKey(userID, keyID)
+		if err != nil {
+			return nil, err
+		}
+		if key.Status != models.KeyStatusActive {
+			return nil, errors.New("활성 상태의 키만 배포할 수 있습니다")
+		}
+		if isKeyExpired(key, time.Now()) {
+			return nil, errors.New("유효하지 않은 키입니다. 만료된 키는 배포할 수 없습니다")
+		}
+		return key, nil
+	}
+
+	var keys []models.SSHKey
+	if err := models.DB.Where("user_id = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)", userID, models.KeyStatusActive, time.Now()).
+		Limit(2).Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	switch len(keys) {
+	case 0:
+		return nil, errors.New("SSH 키를 찾을 수 없습니다. 먼저 키를 생성해주세요")
+	case 1:
+		return &keys[0], nil
+	}
+	return nil, errors.New("배포할 SSH 키를 선택해주세요 (ssh_key_id)")
+}
+
+// findKeyByName은 사용자의 키 중 이름이 같은 키를 찾습니다. 없으면 nil을 반환합니다.
+func findKeyByName(userID uint, name string) (*models.SSHKey, error) {
+	var key models.SSHKey
+	result := models.DB.Where("user_id = ? AND name = ?", userID, name).First(&key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &key, nil
+}
+
+// validatePassphrase는 개인키 패스프레이즈를 검증합니다. 빈 값은 암호화하지 않음을 의미합니다.
+// ssh-keygen과 같이 5자 이상을 요구합니다.
+func validatePassphrase(passphrase string) error {
+	if passphrase == "" {
+		return nil
+	}
+	if len(passphrase) < 5 {
+		return errors.New("패스프레이즈는 최소 5자 이상이어야 합니다")
+	}
+	if len(passphrase) > 1024 {
+		return errors.New("패스프레이즈는 최대 1024자까지 가능합니다")
+	}
+	return nil
+}
+
+// normalizeKeyName은 키 이름을 검증하고, 비어있으면 생성 시각 기반 이름을 만듭니다.
+func normalizeKeyName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "key-" + time.Now().Format("20060102-150405"), nil
+	}
+	if len(name) > 100 {
+		return "", errors.New("키 이름은 최대 100자까지 가능합니다")
+	}
+	return name, nil
+}
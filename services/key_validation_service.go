@@ -0,0 +1,161 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// 검증 시 권장 RSA 키 크기 (NIST SP 800-57 기준 2030년 이후 권장)
+const recommendedRSABits = 3072
+
+// ValidateSSHKey는 붙여넣은 공개키 또는 개인키를 검사하여 알고리즘, 크기, 핑거프린트와 오류/경고를 반환합니다.
+// 키를 저장하지 않으며, 파싱 오류도 error가 아닌 결과의 Errors로 반환합니다.
+func ValidateSSHKey(userID uint, req types.SSHKeyValidateRequest) *types.SSHKeyValidationResult {
+	result := &types.SSHKeyValidationResult{
+		Errors:   []string{},
+		Warnings: []string{},
+		KeyInfo:  make(map[string]interface{}),
+	}
+
+	content := []byte(strings.TrimSpace(req.Content))
+	if len(content) == 0 {
+		result.Errors = append(result.Errors, "검증할 키 내용을 입력해주세요")
+		return result
+	}
+
+	format := utils.DetectKeyFormat(content)
+	result.KeyInfo["format"] = format
+
+	var publicKey ssh.PublicKey
+	if utils.IsPrivateKeyFormat(format) {
+		publicKey = validatePrivateKeyContent(content, req.Password, result)
+	} else {
+		publicKey = validatePublicKeyContent(content, result)
+	}
+
+	if publicKey != nil {
+		validatePublicKeyPolicy(userID, publicKey, result)
+	}
+
+	result.Valid = len(result.Errors) == 0
+	log.Printf("🔍 키 검증 완료 (사용자 ID: %d, 형식: %s, 유효: %t, 오류: %d, 경고: %d)",
+		userID, format, result.Valid, len(result.Errors), len(result.Warnings))
+	return result
+}
+
+// validatePrivateKeyContent는 개인키를 파싱하고 결과에 개인키 관련 정보를 기록합니다.
+// 암호화된 키에 패스프레이즈가 없으면 평문으로 저장된 공개키 정보만 확인합니다 (OpenSSH, PPK).
+func validatePrivateKeyContent(content []byte, password string, result *types.SSHKeyValidationResult) ssh.PublicKey {
+	result.KeyInfo["private_key"] = true
+
+	signer, comment, err := utils.ParsePrivateKeyAnyFormat(content, "")
+	encrypted := false
+	if err != nil && password != "" {
+		signer, comment, err = utils.ParsePrivateKeyAnyFormat(content, password)
+		encrypted = true
+	}
+
+	if err != nil {
+		if password == "" {
+			if publicKey, peekComment, peekErr := utils.PeekPrivateKeyPublicKey(content); peekErr == nil {
+				result.KeyInfo["encrypted"] = true
+				result.Comment = peekComment
+				result.Warnings = append(result.Warnings, "암호화된 개인키입니다. 공개키 정보만 확인했으며, 가져올 때는 password를 입력해주세요")
+				return publicKey
+			}
+		}
+		result.Errors = append(result.Errors, err.Error())
+		return nil
+	}
+
+	result.KeyInfo["encrypted"] = encrypted
+	result.Comment = comment
+
+	if !encrypted {
+		result.Warnings = append(result.Warnings, "패스프레이즈로 보호되지 않은 개인키입니다. 전송하거나 보관할 때 주의하세요")
+	}
+	result.Warnings = append(result.Warnings, "개인키를 가져오면 서버에 보관됩니다. 배포만 필요하면 공개키만 등록하는 것을 권장합니다")
+
+	publicKey, err := ssh.NewPublicKey(signer.Public())
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("공개키 추출 실패: %v", err))
+		return nil
+	}
+	return publicKey
+}
+
+// validatePublicKeyContent는 authorized_keys 한 줄 또는 RFC 4716 공개키를 파싱합니다.
+func validatePublicKeyContent(content []byte, result *types.SSHKeyValidationResult) ssh.PublicKey {
+	result.KeyInfo["private_key"] = false
+
+	publicKey, comment, options, err := utils.ParsePublicKeyAnyFormat(content)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return nil
+	}
+	result.Comment = comment
+
+	if len(options) > 0 {
+		result.KeyInfo["options"] = options
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"authorized_keys 옵션이 포함되어 있습니다 (%s). 가져올 때 옵션은 저장되지 않습니다", strings.Join(options, ",")))
+	}
+
+	if cert, ok := publicKey.(*ssh.Certificate); ok {
+		result.KeyInfo["certificate"] = true
+		result.Errors = append(result.Errors, "SSH 인증서는 가져올 수 없습니다. 인증서가 아닌 공개키를 입력해주세요")
+		if cert.Signature != nil && cert.Signature.Format == ssh.KeyAlgoRSA {
+			result.Warnings = append(result.Warnings, "인증서가 SHA-1 기반 ssh-rsa 서명으로 발급되었습니다 (OpenSSH 8.8부터 기본 거부)")
+		}
+		return cert.Key
+	}
+
+	return publicKey
+}
+
+// validatePublicKeyPolicy는 알고리즘/크기 정책과 핑거프린트, 중복 등록 여부를 확인합니다.
+func validatePublicKeyPolicy(userID uint, publicKey ssh.PublicKey, result *types.SSHKeyValidationResult) {
+	fingerprints := utils.FingerprintsOf(publicKey)
+	result.Fingerprint = fingerprints.SHA256
+	result.KeyInfo["ssh_type"] = publicKey.Type()
+	result.KeyInfo["fingerprint_md5"] = fingerprints.MD5
+
+	algorithm, bits, err := utils.DescribePublicKey(publicKey)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return
+	}
+	result.Algorithm = algorithm
+	result.Bits = bits
+
+	// 가져오기와 동일한 거부 조건 (DSA, RSA 2048비트 미만)
+	if _, _, err := describeImportedPublicKey(publicKey); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	switch algorithm {
+	case utils.KeyAlgorithmRSA:
+		if bits >= 2048 && bits < recommendedRSABits {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("RSA %d비트 키입니다. %d비트 이상 또는 Ed25519 사용을 권장합니다", bits, recommendedRSABits))
+		}
+		result.Warnings = append(result.Warnings, "RSA 키는 서버와 클라이언트가 rsa-sha2-256/512 서명을 지원해야 합니다 (SHA-1 기반 ssh-rsa 서명은 OpenSSH 8.8부터 기본 비활성화)")
+	case utils.KeyAlgorithmEd25519SK, utils.KeyAlgorithmECDSASK:
+		result.KeyInfo["hardware_backed"] = true
+		result.Warnings = append(result.Warnings, "하드웨어 보안 키(FIDO) 기반 키입니다. 접속 시 장치가 필요하며 서버에 OpenSSH 8.2 이상이 필요합니다")
+	}
+
+	// 이미 등록된 키인지 확인
+	if userID != 0 {
+		var existing models.SSHKey
+		if err := models.DB.Select("id", "name").Where("user_id = ? AND fingerprint_sha256 = ?", userID, fingerprints.SHA256).First(&existing).Error; err == nil {
+			result.KeyInfo["registered_key_id"] = existing.ID
+			result.Warnings = append(result.Warnings, fmt.Sprintf("이미 등록된 키입니다 (키 이름: %s)", existing.Name))
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// notifyUser는 사용자에게 알림을 저장합니다.
+// 알림 저장 실패는 본 작업을 실패시키지 않도록 로그만 남깁니다.
+func notifyUser(userID uint, notificationType, title, message string) {
+	notification := models.Notification{
+		UserID:  userID,
+		Type:    notificationType,
+		Title:   title,
+		Message: message,
+	}
+
+	if err := models.DB.Create(&notification).Error; err != nil {
+		log.Printf("⚠️ 알림 저장 실패 (사용자 ID: %d, 제목: %s): %v", userID, title, err)
+		return
+	}
+	log.Printf("🔔 알림 생성 (사용자 ID: %d, 종류: %s): %s", userID, notificationType, title)
+}
+
+// GetNotifications는 사용자의 알림을 최신순으로 조회합니다.
+func GetNotifications(userID uint, unreadOnly bool) ([]types.NotificationResponse, error) {
+	query := models.DB.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Limit(100).Find(&notifications).Error; err != nil {
+		log.Printf("❌ 알림 조회 실패: %v", err)
+		return nil, err
+	}
+
+	responses := make([]types.NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		responses = append(responses, types.ToNotificationResponse(notification))
+	}
+
+	log.Printf("🔔 알림 조회 완료 (사용자 ID: %d, 총 %d건)", userID, len(responses))
+	return responses, nil
+}
+
+// MarkNotificationRead는 사용자의 알림을 읽음으로 표시합니다.
+func MarkNotificationRead(userID, notificationID uint) error {
+	var notification models.Notification
+	result := models.DB.Where("id = ? AND user_id = ?", notificationID, userID).First(&notification)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return errors.New("알림을 찾을 수 없습니다")
+		}
+		return result.Error
+	}
+
+	if notification.ReadAt != nil {
+		return nil
+	}
+
+	if err := models.DB.Model(&notification).Update("read_at", time.Now()).Error; err != nil {
+		log.Printf("❌ 알림 읽음 처리 실패: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// pullTokenPrefix는 pull 방식 호스트 토큰의 접두사입니다 (로그, 설정 파일에서 구분용).
+const pullTokenPrefix = "skmp_"
+
+// ErrPullUnauthorized는 pull 요청의 호스트 인증 실패를 나타냅니다.
+var ErrPullUnauthorized = errors.New("호스트 인증에 실패했습니다")
+
+// RegisterPullHost는 pull 방식 호스트를 등록하고 호스트 토큰을 발급합니다 (관리자 전용).
+// 이미 등록된 호스트이면 토큰을 새로 발급하며, 기존 토큰은 즉시 사용할 수 없게 됩니다.
+func RegisterPullHost(adminID uint, req types.PullHostRegisterRequest) (*types.PullHostTokenResponse, error) {
+	host := normalizePullHost(req.Host)
+	if host == "" {
+		return nil, errors.New("호스트를 입력해주세요")
+	}
+
+	token, err := generatePullToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var pullHost models.PullHost
+	err = models.DB.Where("host = ?", host).First(&pullHost).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("❌ pull 호스트 조회 실패: %v", err)
+		return nil, err
+	}
+	reissued := err == nil
+
+	pullHost.Host = host
+	pullHost.TokenHash = hashPullToken(token)
+	pullHost.TokenPrefix = token[:len(pullTokenPrefix)+6]
+	pullHost.CreatedBy = adminID
+	if req.Description != "" {
+		pullHost.Description = strings.TrimSpace(req.Description)
+	}
+	if err := models.DB.Save(&pullHost).Error; err != nil {
+		log.Printf("❌ pull 호스트 저장 실패: %v", err)
+		return nil, errors.New("pull 호스트 등록 중 오류가 발생했습니다")
+	}
+
+	if reissued {
+		log.Printf("🔁 pull 호스트 토큰 재발급: %s (%s...)", host, pullHost.TokenPrefix)
+		utils.LogSecurityEvent("pull 호스트 토큰 재발급", adminID, fmt.Sprintf("호스트 %s", host), "medium")
+	} else {
+		log.Printf("✅ pull 호스트 등록: %s (%s...)", host, pullHost.TokenPrefix)
+	}
+
+	return &types.PullHostTokenResponse{
+		PullHostResponse: types.ToPullHostResponse(pullHost),
+		Token:            token,
+		Usage: "토큰을 서버의 /etc/ssh/skm_pull_token(AuthorizedKeysCommandUser만 읽기 가능)에 저장하고 sshd_config에 " +
+			"'AuthorizedKeysCommand /usr/local/bin/authorized-keys-command -url <관리 서버 URL> -host " + host + " %u'와 " +
+			"'AuthorizedKeysCommandUser nobody'를 추가하세요",
+	}, nil
+}
+
+// GetPullHosts는 등록된 pull 방식 호스트 목록을 조회합니다 (관리자 전용).
+func GetPullHosts() ([]types.PullHostResponse, error) {
+	var hosts []models.PullHost
+	if err := models.DB.Order("host").Find(&hosts).Error; err != nil {
+		log.Printf("❌ pull 호스트 목록 조회 실패: %v", err)
+		return nil, err
+	}
+
+	responses := make([]types.PullHostResponse, 0, len(hosts))
+	for _, host := range hosts {
+		responses = append(responses, types.ToPullHostResponse(host))
+	}
+	return responses, nil
+}
+
+// DeletePullHost는 pull 방식 호스트 등록을 삭제합니다. 삭제 후 해당 호스트 토큰은 사용할 수 없습니다.
+func DeletePullHost(adminID, pullHostID uint) error {
+	var pullHost models.PullHost
+	if err := models.DB.First(&pullHost, pullHostID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("pull 호스트를 찾을 수 없습니다")
+		}
+		return err
+	}
+
+	if err := models.DB.Unscoped().Delete(&pullHost).Error; err != nil {
+		log.Printf("❌ pull 호스트 삭제 실패: %v", err)
+		return errors.New("pull 호스트 삭제 중 오류가 발생했습니다")
+	}
+
+	log.Printf("🗑️ pull 호스트 삭제: %s", pullHost.Host)
+	utils.LogSecurityEvent("pull 호스트 삭제", adminID, fmt.Sprintf("호스트 %s", pullHost.Host), "medium")
+	return nil
+}
+
+// GetPulledAuthorizedKeys는 호스트 토큰을 확인하고, 호스트 계정이 가져야 할 authorized_keys 내용을 사용자 CA로 서명해 반환합니다.
+// 계정에 부여된 키가 없어도 빈 내용에 서명하여 반환합니다 (호스트는 캐시를 비움).
+// port가 0이면 호스트의 모든 포트에 등록된 같은 계정의 키를 합칩니다.
+// 관리자가 승인한 pull 방식 서버 계정에 배포된 키만 포함합니다 (simulated, ssh 등 다른 방식이나 미승인 서버 제외).
+func GetPulledAuthorizedKeys(host, user string, port int, token, remoteAddr string) (*types.PulledAuthorizedKeys, error) {
+	pullHost, err := authenticatePullHost(host, token, remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+	if !isValidAccountName(user) {
+		return nil, errors.New("유효하지 않은 계정 이름입니다")
+	}
+
+	// 관리자가 승인한 pull 서버 계정의 배포만 포함 (다른 방식이나 미승인 서버로 배포한 키는 이 호스트의 권한이 아님)
+	query := models.DB.Model(&models.Server{}).
+		Where("LOWER(host) = ? AND username = ? AND deploy_method = ? AND approved_at IS NOT NULL", pullHost.Host, user, models.DeployMethodPull)
+	if port > 0 {
+		query = query.Where("port = ?", port)
+	}
+	var serverIDs []uint
+	if err := query.Pluck("id", &serverIDs).Error; err != nil {
+		log.Printf("❌ pull 대상 서버 조회 실패: %v", err)
+		return nil, err
+	}
+
+	keys, err := managedKeysForServers(serverIDs)
+	if err != nil {
+		return nil, err
+	}
+	content := managedKeysContent(keys)
+
+	_, caSigner, err := getCertificateAuthority(models.CATypeUser)
+	if err != nil {
+		return nil, err
+	}
+	issuedAt := time.Now().Unix()
+	signature, err := utils.SignPulledAuthorizedKeys(caSigner, host, port, user, issuedAt, content)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	models.DB.Model(pullHost).UpdateColumns(map[string]interface{}{
+		"last_pulled_at": now,
+		"last_pull_addr": remoteAddr,
+	})
+
+	log.Printf("📥 authorized_keys pull: %s@%s (키 %d개, 요청: %s)", user, host, len(keys), remoteAddr)
+	return &types.PulledAuthorizedKeys{
+		Host:      host,
+		Port:      port,
+		User:      user,
+		Content:   content,
+		KeyCount:  len(keys),
+		IssuedAt:  issuedAt,
+		Signature: signature,
+	}, nil
+}
+
+// authenticatePullHost는 호스트 토큰이 요청한 호스트에 발급된 것인지 확인합니다.
+func authenticatePullHost(host, token, remoteAddr string) (*models.PullHost, error) {
+	if !strings.HasPrefix(token, pullTokenPrefix) {
+		return nil, ErrPullUnauthorized
+	}
+
+	var pullHost models.PullHost
+	if err := models.DB.Where("token_hash = ?", hashPullToken(token)).First(&pullHost).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogSecurityEvent("pull 호스트 인증 실패", 0, fmt.Sprintf("호스트 %s, 요청 %s (등록되지 않은 토큰)", host, remoteAddr), "high")
+			return nil, ErrPullUnauthorized
+		}
+		return nil, err
+	}
+
+	if pullHost.Host != normalizePullHost(host) {
+		utils.LogSecurityEvent("pull 호스트 인증 실패", 0,
+			fmt.Sprintf("호스트 %s, 요청 %s (%s에 발급된 토큰)", host, remoteAddr, pullHost.Host), "high")
+		return nil, ErrPullUnauthorized
+	}
+	return &pullHost, nil
+}
+
+// managedKeysContent는 관리 키 목록을 authorized_keys 내용으로 만듭니다.
+func managedKeysContent(keys []models.SSHKey) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, strings.TrimSpace(key.PublicKey))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// normalizePullHost는 호스트 비교용 값을 만듭니다 (앞뒤 공백 제거, 소문자).
+func normalizePullHost(host string) string {
+	return strings.ToLower(strings.TrimSpace(host))
+}
+
+// isValidAccountName은 sshd가 넘긴 계정 이름이 조회에 쓸 수 있는 값인지 확인합니다.
+func isValidAccountName(user string) bool {
+	if user == "" || len(user) > 64 {
+		return false
+	}
+	for _, r := range user {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || r == '/' {
+			return false
+		}
+	}
+	return true
+}
+
+// generatePullToken은 새 호스트 토큰을 생성합니다.
+func generatePullToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("호스트 토큰 생성 실패: %v", err)
+	}
+	return pullTokenPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashPullToken은 저장용 토큰 해시(SHA256 hex)를 계산합니다.
+func hashPullToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// pullDeployer는 pull 방식 서버의 Deployer입니다.
+// 서버에 접속하지 않으며, 배포/제거는 배포 기록으로만 반영되어 호스트가 다음에 가져갈 때 적용됩니다.
+type pullDeployer struct {
+	server *models.Server
+}
+
+// Install은 아무것도 하지 않습니다. 성공한 배포 기록이 호스트가 가져갈 내용에 반영됩니다.
+// 관리자 승인 전인 서버 계정에는 배포하지 않습니다 (호스트가 가져갈 내용에 포함되지 않음).
+func (d *pullDeployer) Install(ctx context.Context, publicKey string) error {
+	if d.server.ApprovedAt == nil {
+		return errPullServerNotApproved(d.server)
+	}
+	return nil
+}
+
+// Remove는 아무것도 하지 않습니다. 성공한 제거 기록이 호스트가 가져갈 내용에 반영됩니다.
+func (d *pullDeployer) Remove(ctx context.Context, publicKey string) error {
+	return nil
+}
+
+// List는 호스트가 가져갈 authorized_keys 키 줄을 반환합니다.
+func (d *pullDeployer) List(ctx context.Context) ([]string, error) {
+	keys, err := managedServerKeys(d.server)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, strings.TrimSpace(key.PublicKey))
+	}
+	return lines, nil
+}
+
+// Verify는 호스트가 pull 방식으로 등록되어 있는지 확인합니다.
+func (d *pullDeployer) Verify(ctx context.Context) error {
+	var count int64
+	models.DB.Model(&models.PullHost{}).Where("host = ?", normalizePullHost(d.server.Host)).Count(&count)
+	if count == 0 {
+		return fmt.Errorf("pull 방식 호스트로 등록되지 않은 서버입니다: %s (관리자에게 호스트 등록을 요청하세요)", d.server.Host)
+	}
+	if d.server.ApprovedAt == nil {
+		return errPullServerNotApproved(d.server)
+	}
+	return nil
+}
+
+// errPullServerNotApproved는 관리자 승인 전인 pull 서버 계정 오류를 만듭니다.
+func errPullServerNotApproved(server *models.Server) error {
+	return fmt.Errorf("관리자 승인을 받지 않은 서버 계정입니다: %s@%s (관리자에게 계정 승인을 요청하세요)", server.Username, server.Host)
+}
+
+// Snapshot은 현재 호스트가 가져갈 내용을 반환합니다 (서버 백업 파일 없음).
+func (d *pullDeployer) Snapshot(ctx context.Context) (*utils.AuthorizedKeysBackup, error) {
+	keys, err := managedServerKeys(d.server)
+	if err != nil {
+		return nil, err
+	}
+	return &utils.AuthorizedKeysBackup{Content: managedKeysContent(keys)}, nil
+}
+
+// Restore는 지원하지 않습니다. 호스트가 가져갈 내용은 배포 기록으로만 바뀝니다.
+func (d *pullDeployer) Restore(ctx context.Context, content string) error {
+	return errors.New("pull 방식 서버는 authorized_keys 직접 복원을 지원하지 않는 작업입니다 (배포 기록으로 관리)")
+}
+
+// String은 로그에 표시할 대상 설명을 반환합니다.
+func (d *pullDeployer) String() string {
+	return fmt.Sprintf("pull://%s@%s", d.server.Username, d.server.Host)
+}
@@ -0,0 +1,402 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReconcileServerAuthorizedKeys는 서버 authorized_keys를 관리 중인 키와 정확히 일치시킵니다.
+// 독점 관리 모드가 아닌 서버는 dry_run으로 정리 계획만 확인할 수 있습니다.
+func ReconcileServerAuthorizedKeys(userID, serverID uint, req types.ServerReconcileRequest) (*types.ServerReconcileResult, error) {
+	server, err := findUserServer(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if !server.ManagedExclusively && !req.DryRun {
+		return nil, errors.New("독점 관리 모드가 아닌 서버의 authorized_keys를 정리할 권한이 없습니다 (dry_run으로 미리보기만 가능)")
+	}
+
+	result, err := reconcileServer(context.Background(), server, userID, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Changed && !result.DryRun && len(result.Removed) > 0 {
+		utils.LogSecurityEvent("authorized_keys 독점 관리 정리", userID,
+			fmt.Sprintf("서버 %s (ID %d) 키 %d개 제거, 스냅샷 %d", server.Name, server.ID, len(result.Removed), *result.SnapshotID), "medium")
+	}
+	return result, nil
+}
+
+// enforceManagedServer는 드리프트가 감지된 독점 관리 서버를 정리합니다 (드리프트 검사 스케줄러용).
+// 작업자는 서버를 등록한 사용자로 기록합니다.
+func enforceManagedServer(server *models.Server) {
+	result, err := reconcileServer(context.Background(), server, server.UserID, false)
+	if err != nil {
+		log.Printf("❌ 독점 관리 정리 실패 [%s]: %v", server.Name, err)
+		notifyUser(server.UserID, models.NotificationTypeError, "authorized_keys 정리 실패",
+			fmt.Sprintf("독점 관리 서버 '%s'의 authorized_keys를 정리하지 못했습니다: %v", server.Name, err))
+		return
+	}
+	if !result.Changed {
+		return
+	}
+
+	if len(result.Removed) > 0 {
+		utils.LogSecurityEvent("authorized_keys 독점 관리 정리", server.UserID,
+			fmt.Sprintf("서버 %s (ID %d) 키 %d개 제거, 스냅샷 %d", server.Name, server.ID, len(result.Removed), *result.SnapshotID), "medium")
+	}
+	notifyUser(server.UserID, models.NotificationTypeInfo, "authorized_keys 정리됨",
+		fmt.Sprintf("독점 관리 서버 '%s'의 authorized_keys를 정리했습니다 (추가 %d, 제거 %d). 필요하면 스냅샷 %d로 되돌릴 수 있습니다.",
+			server.Name, len(result.Added), len(result.Removed), *result.SnapshotID))
+}
+
+// reconcileServer는 현재 authorized_keys와 관리 중인 키를 비교해 정리합니다.
+// 바뀔 내용이 있을 때만 스냅샷(서버 백업 파일 포함)을 남긴 뒤 파일 전체를 교체하고, 추가/제거한 등록 키는 배포 기록에 남깁니다.
+func reconcileServer(ctx context.Context, server *models.Server, userID uint, dryRun bool) (*types.ServerReconcileResult, error) {
+	deployer, err := serverDeployer(server)
+	if err != nil {
+		return nil, err
+	}
+
+	managed, err := managedServerKeys(server)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := deployer.List(ctx)
+	if err != nil {
+		return nil, checkRemoteError(server, err)
+	}
+	plan, err := planReconcile(lines, managed)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.ServerReconcileResult{
+		ServerID:   server.ID,
+		ServerName: server.Name,
+		DryRun:     dryRun,
+		Changed:    plan.changed,
+		Kept:       plan.kept,
+		Added:      plan.added,
+		Removed:    plan.removed,
+	}
+	if dryRun || !plan.changed {
+		return result, nil
+	}
+
+	log.Printf("🔒 authorized_keys 정리 시작: %s (유지 %d, 추가 %d, 제거 %d)", server.Name, plan.kept, len(plan.added), len(plan.removed))
+
+	snapshot, err := snapshotAuthorizedKeys(ctx, deployer, server, userID, models.SnapshotActionReconcile)
+	if err != nil {
+		return nil, checkRemoteError(server, err)
+	}
+
+	// 목록 조회 이후 바뀌었을 수 있으므로 스냅샷 내용으로 다시 계획
+	plan, err = planReconcile(strings.Split(snapshot.Content, "\n"), managed)
+	if err != nil {
+		return nil, err
+	}
+	if err := deployer.Restore(ctx, plan.content); err != nil {
+		log.Printf("❌ authorized_keys 정리 실패 [%s]: %v", server.Name, err)
+		return nil, checkRemoteError(server, err)
+	}
+
+	recordReconcileDeployments(server, snapshot.ID, plan)
+
+	result.Changed = plan.changed
+	result.Kept = plan.kept
+	result.Added = plan.added
+	result.Removed = plan.removed
+	result.SnapshotID = &snapshot.ID
+	result.BackupPath = snapshot.RemotePath
+
+	log.Printf("✅ authorized_keys 정리 완료: %s (스냅샷 ID: %d)", server.Name, snapshot.ID)
+	return result, nil
+}
+
+// reconcilePlan은 authorized_keys 정리 계획입니다.
+type reconcilePlan struct {
+	content string // 정리 후 authorized_keys 내용
+	changed bool
+	kept    int
+	added   []types.DriftEntry
+	removed []types.DriftEntry
+}
+
+// planReconcile은 현재 줄과 관리 중인 키로 정리 후 내용을 계산합니다.
+// 관리 키 줄은 옵션(from= 등)을 유지하고, 없는 관리 키는 추가하며, 그 외 키 줄과 주석은 제거합니다.
+// 관리 도구 자신의 접속 키는 등록 여부와 관계없이 유지합니다.
+func planReconcile(lines []string, managed []models.SSHKey) (*reconcilePlan, error) {
+	managedByFingerprint := make(map[string]*models.SSHKey)
+	for i := range managed {
+		managedByFingerprint[managed[i].FingerprintSHA256] = &managed[i]
+	}
+	managerKeys := make(map[string]bool)
+	for _, fingerprint := range utils.ManagerKeyFingerprints() {
+		managerKeys[fingerprint] = true
+	}
+
+	present, invalid := utils.ParseAuthorizedKeyLines(lines)
+	known, err := keysByFingerprints(present)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	plan := &reconcilePlan{}
+	var output []string
+	seen := make(map[string]bool)
+	for _, line := range present {
+		keep := managedByFingerprint[line.Fingerprint] != nil || managerKeys[line.Fingerprint]
+		if keep && !seen[line.Fingerprint] {
+			seen[line.Fingerprint] = true
+			output = append(output, line.Line)
+			plan.kept++
+			continue
+		}
+
+		entry := types.DriftEntry{
+			Kind:        types.DriftKindForeign,
+			Fingerprint: line.Fingerprint,
+			Algorithm:   line.Algorithm,
+			Comment:     line.Comment,
+			Reason:      "관리하지 않는 키",
+		}
+		switch key := known[line.Fingerprint]; {
+		case keep:
+			// 유지하는 키의 중복 줄 (배포 기록에는 남기지 않음)
+			entry.Kind = types.DriftKindStale
+			entry.Reason = "중복된 키 줄"
+		case key != nil:
+			entry.Kind = types.DriftKindStale
+			entry.KeyID = &key.ID
+			entry.KeyName = key.Name
+			entry.KeyOwnerID = &key.UserID
+			entry.Reason = unusableKeyReason(key, now)
+			if entry.Reason == "" {
+				entry.Reason = "이 계정에 부여되지 않은 등록된 키"
+			}
+		}
+		plan.removed = append(plan.removed, entry)
+	}
+
+	for _, line := range invalid {
+		if len(line) > 80 {
+			line = line[:80] + "..."
+		}
+		plan.removed = append(plan.removed, types.DriftEntry{Kind: types.DriftKindForeign, Comment: line, Reason: "해석할 수 없는 키 줄"})
+	}
+
+	for i := range managed {
+		key := &managed[i]
+		if seen[key.FingerprintSHA256] {
+			continue
+		}
+		seen[key.FingerprintSHA256] = true
+		output = append(output, strings.TrimSpace(key.PublicKey))
+		plan.added = append(plan.added, types.DriftEntry{
+			Kind:        types.DriftKindMissing,
+			Fingerprint: key.FingerprintSHA256,
+			Algorithm:   key.Algorithm,
+			KeyID:       &key.ID,
+			KeyName:     key.Name,
+			KeyOwnerID:  &key.UserID,
+			Reason:      "부여된 관리 키",
+		})
+	}
+
+	if len(output) > 0 {
+		plan.content = strings.Join(output, "\n") + "\n"
+	}
+	plan.changed = plan.content != normalizedAuthorizedKeys(lines)
+	return plan, nil
+}
+
+// normalizedAuthorizedKeys는 줄 목록을 비교용 파일 내용으로 합칩니다 (빈 줄 제외).
+func normalizedAuthorizedKeys(lines []string) string {
+	var kept []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return strings.Join(kept, "\n") + "\n"
+}
+
+// managedServerKeys는 서버 계정에 부여된 관리 키 목록을 반환합니다 (같은 계정을 가리키는 본인 또는 승인된 서버 등록 기준).
+func managedServerKeys(server *models.Server) ([]models.SSHKey, error) {
+	serverIDs, err := accountServerIDs(server)
+	if err != nil {
+		return nil, err
+	}
+	return managedKeysForServers(serverIDs)
+}
+
+// managedKeysForServers는 서버들의 배포 기록을 순서대로 적용해 마지막 작업이 배포이고,
+// 사용 가능한(폐기/만료/삭제되지 않은) 키만 반환합니다.
+func managedKeysForServers(serverIDs []uint) ([]models.SSHKey, error) {
+	lastAction, err := lastDeploymentActions(serverIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyIDs []uint
+	for keyID, action := range lastAction {
+		if action == models.DeploymentActionDeploy {
+			keyIDs = append(keyIDs, keyID)
+		}
+	}
+	if len(keyIDs) == 0 {
+		return nil, nil
+	}
+
+	var keys []models.SSHKey
+	if err := models.DB.Where("id IN ?", keyIDs).Order("id").Find(&keys).Error; err != nil {
+		log.Printf("❌ 관리 키 조회 실패: %v", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	managed := keys[:0]
+	for _, key := range keys {
+		if unusableKeyReason(&key, now) != "" {
+			continue
+		}
+		if key.FingerprintSHA256 == "" {
+			fingerprints, err := utils.ComputeFingerprints(key.PublicKey)
+			if err != nil {
+				log.Printf("⚠️ 관리 키 핑거프린트 계산 실패 (키 ID: %d): %v", key.ID, err)
+				continue
+			}
+			key.FingerprintSHA256 = fingerprints.SHA256
+		}
+		managed = append(managed, key)
+	}
+	return managed, nil
+}
+
+// accountServerIDs는 서버와 같은 authorized_keys 파일(같은 호스트, 포트, 계정)을 가리키는 서버 등록 ID 목록입니다.
+// local 방식은 모두 관리 도구가 실행 중인 서버의 같은 파일을 사용합니다.
+// 다른 사용자가 같은 계정으로 등록한 서버는 관리자가 승인한 경우에만 포함하고, simulated 서버는 실제 계정이 아니므로 자기 자신만 사용합니다.
+// pull 방식은 호스트가 가져가는 내용과 같도록 승인된 pull 서버만 포함합니다.
+func accountServerIDs(server *models.Server) ([]uint, error) {
+	if server.DeployMethod == models.DeployMethodSimulated {
+		return []uint{server.ID}, nil
+	}
+
+	query := models.DB.Model(&models.Server{}).Where("id <> ?", server.ID)
+	switch server.DeployMethod {
+	case models.DeployMethodLocal:
+		query = query.Where("deploy_method = ?", models.DeployMethodLocal)
+	case models.DeployMethodPull:
+		query = query.Where("host = ? AND port = ? AND username = ? AND deploy_method = ?",
+			server.Host, server.Port, server.Username, models.DeployMethodPull)
+	default:
+		query = query.Where("host = ? AND port = ? AND username = ? AND deploy_method NOT IN ?",
+			server.Host, server.Port, server.Username, []string{models.DeployMethodLocal, models.DeployMethodSimulated})
+	}
+	if server.DeployMethod == models.DeployMethodPull {
+		query = query.Where("approved_at IS NOT NULL")
+	} else {
+		query = query.Where("(user_id = ? OR approved_at IS NOT NULL)", server.UserID)
+	}
+
+	serverIDs := []uint{server.ID}
+	var others []uint
+	if err := query.Pluck("id", &others).Error; err != nil {
+		log.Printf("❌ 같은 계정 서버 조회 실패: %v", err)
+		return nil, err
+	}
+	return append(serverIDs, others...), nil
+}
+
+// lastDeploymentActions는 서버들의 성공한 배포 기록을 순서대로 적용해 키별 마지막 작업(deploy, remove)을 반환합니다.
+func lastDeploymentActions(serverIDs []uint) (map[uint]string, error) {
+	lastAction := make(map[uint]string)
+	if len(serverIDs) == 0 {
+		return lastAction, nil
+	}
+
+	var rows []struct {
+		SSHKeyID uint
+		Action   string
+	}
+	if err := models.DB.Model(&models.ServerKeyDeployment{}).
+		Select("ssh_key_id, action").
+		Where("server_id IN ? AND status = ?", serverIDs, "success").
+		Order("id").Scan(&rows).Error; err != nil {
+		log.Printf("❌ 배포 기록 조회 실패: %v", err)
+		return nil, err
+	}
+	for _, row := range rows {
+		lastAction[row.SSHKeyID] = row.Action
+	}
+	return lastAction, nil
+}
+
+// keysByFingerprints는 authorized_keys의 키와 같은 등록 키를 핑거프린트로 찾습니다 (삭제된 키 포함).
+func keysByFingerprints(present []utils.AuthorizedKeyLine) (map[string]*models.SSHKey, error) {
+	result := make(map[string]*models.SSHKey)
+	if len(present) == 0 {
+		return result, nil
+	}
+
+	fingerprints := make([]string, 0, len(present))
+	for _, line := range present {
+		fingerprints = append(fingerprints, line.Fingerprint)
+	}
+
+	var keys []models.SSHKey
+	if err := models.DB.Unscoped().Where("fingerprint_sha256 IN ?", fingerprints).Order("id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		if _, ok := result[keys[i].FingerprintSHA256]; !ok {
+			result[keys[i].FingerprintSHA256] = &keys[i]
+		}
+	}
+	return result, nil
+}
+
+// recordReconcileDeployments는 정리하면서 추가/제거한 등록 키를 키 소유자의 배포 기록으로 남깁니다.
+// 배포 기록으로 현재 상태를 계산하는 키 교체, 드리프트 검사가 정리 결과를 반영하도록 합니다.
+func recordReconcileDeployments(server *models.Server, snapshotID uint, plan *reconcilePlan) {
+	now := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	record := func(entry types.DriftEntry, action string) {
+		deployment := models.ServerKeyDeployment{
+			ServerID:   server.ID,
+			SSHKeyID:   *entry.KeyID,
+			UserID:     *entry.KeyOwnerID,
+			Action:     action,
+			Status:     "success",
+			DeployedAt: &now,
+			SnapshotID: &snapshotID,
+		}
+		if err := models.DB.Create(&deployment).Error; err != nil {
+			log.Printf("⚠️ 정리 기록 저장 실패 (키 ID: %d): %v", *entry.KeyID, err)
+		}
+	}
+
+	for _, entry := range plan.added {
+		record(entry, models.DeploymentActionDeploy)
+	}
+	for _, entry := range plan.removed {
+		if entry.KeyID != nil {
+			record(entry, models.DeploymentActionRemove)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetUnapprovedServers는 관리자 승인을 받지 않은 서버 계정 목록을 조회합니다 (관리자 전용, simulated 제외).
+func GetUnapprovedServers() ([]types.ServerResponse, error) {
+	var servers []models.Server
+	if err := models.DB.Where("approved_at IS NULL AND deploy_method <> ?", models.DeployMethodSimulated).
+		Order("created_at DESC").Find(&servers).Error; err != nil {
+		log.Printf("❌ 미승인 서버 조회 실패: %v", err)
+		return nil, err
+	}
+
+	responses := make([]types.ServerResponse, 0, len(servers))
+	for _, server := range servers {
+		responses = append(responses, types.ToServerResponse(server))
+	}
+	return responses, nil
+}
+
+// ApproveServer는 사용자가 등록한 서버 계정을 관리자가 확인하고 승인합니다 (관리자 전용).
+// 승인된 계정만 pull 배포 대상, 같은 계정의 키 합산, 사용자 인증서 principal에 사용됩니다.
+// simulated 서버는 실제 계정이 아니므로 승인할 수 없습니다.
+func ApproveServer(adminUserID, serverID uint) (*types.ServerResponse, error) {
+	var server models.Server
+	if err := models.DB.First(&server, serverID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("서버를 찾을 수 없습니다")
+		}
+		return nil, err
+	}
+	if server.DeployMethod == models.DeployMethodSimulated {
+		return nil, errors.New("simulated 서버는 승인할 수 없습니다 (지원하지 않는 배포 방식)")
+	}
+
+	now := time.Now()
+	if err := models.DB.Model(&server).Updates(map[string]interface{}{
+		"approved_by": adminUserID,
+		"approved_at": now,
+	}).Error; err != nil {
+		log.Printf("❌ 서버 계정 승인 실패 (서버 ID: %d): %v", server.ID, err)
+		return nil, errors.New("서버 계정 승인 중 오류가 발생했습니다")
+	}
+	models.DB.First(&server, serverID)
+
+	log.Printf("✅ 서버 계정 승인: %s (%s@%s:%d, 관리자 ID: %d)", server.Name, server.Username, server.Host, server.Port, adminUserID)
+	utils.LogSecurityEvent("서버 계정 승인", adminUserID,
+		fmt.Sprintf("서버 %s (%s@%s:%d, ID %d, 소유자 ID %d)", server.Name, server.Username, server.Host, server.Port, server.ID, server.UserID), "medium")
+
+	notifyUser(server.UserID, models.NotificationTypeInfo, "서버 계정 승인",
+		fmt.Sprintf("관리자가 서버 '%s'(%s@%s)의 계정을 승인했습니다.", server.Name, server.Username, server.Host))
+
+	response := types.ToServerResponse(server)
+	return &response, nil
+}
+
+// RevokeServerApproval은 서버 계정 승인을 취소합니다 (관리자 전용).
+func RevokeServerApproval(adminUserID, serverID uint) (*types.ServerResponse, error) {
+	var server models.Server
+	if err := models.DB.First(&server, serverID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("서버를 찾을 수 없습니다")
+		}
+		return nil, err
+	}
+
+	if err := models.DB.Model(&server).Updates(map[string]interface{}{
+		"approved_by": nil,
+		"approved_at": nil,
+	}).Error; err != nil {
+		log.Printf("❌ 서버 계정 승인 취소 실패 (서버 ID: %d): %v", server.ID, err)
+		return nil, errors.New("서버 계정 승인 취소 중 오류가 발생했습니다")
+	}
+	models.DB.First(&server, serverID)
+
+	log.Printf("🔓 서버 계정 승인 취소: %s (관리자 ID: %d)", server.Name, adminUserID)
+	utils.LogSecurityEvent("서버 계정 승인 취소", adminUserID,
+		fmt.Sprintf("서버 %s (%s@%s:%d, ID %d)", server.Name, server.Username, server.Host, server.Port, server.ID), "medium")
+
+	response := types.ToServerResponse(server)
+	return &response, nil
+}
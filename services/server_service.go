@@ -1,17 +1,56 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
+	"ssh-key-manager/config"
 	"ssh-key-manager/models"
 	"ssh-key-manager/types"
 	"ssh-key-manager/utils"
 	"strings"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// ConfigureSSHTransport는 설정 파일의 원격 SSH 접속 설정(접속 키, 타임아웃)을 적용합니다.
+func ConfigureSSHTransport() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	utils.ConfigureSSHTransport(utils.SSHTransportOptions{
+		IdentityFiles:  cfg.SSHIdentityFiles,
+		ConnectTimeout: cfg.SSHConnectTimeout,
+		CommandTimeout: cfg.SSHCommandTimeout,
+		IdleTimeout:    cfg.SSHIdleTimeout,
+	})
+	log.Printf("🔌 원격 SSH 접속 설정 적용 (연결 타임아웃: %s, 명령 타임아웃: %s, 유휴 연결 유지: %s)",
+		cfg.SSHConnectTimeout, cfg.SSHCommandTimeout, cfg.SSHIdleTimeout)
+	return nil
+}
+
+// ConfigureDeployEngine은 설정 파일의 배포 실행 설정(동시 서버 수, 재시도)을 적용합니다.
+func ConfigureDeployEngine() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	utils.ConfigureDeployEngine(utils.DeployEngineOptions{
+		MaxParallel:  cfg.DeployMaxParallel,
+		MaxRetries:   cfg.DeployMaxRetries,
+		RetryBackoff: cfg.DeployRetryBackoff,
+	})
+	log.Printf("🚚 배포 실행 설정 적용 (동시 서버: %d대, 재시도: %d회, 첫 재시도 대기: %s)",
+		cfg.DeployMaxParallel, cfg.DeployMaxRetries, cfg.DeployRetryBackoff)
+	return nil
+}
+
 // CreateServer는 새로운 서버를 등록합니다.
 func CreateServer(userID uint, req types.ServerCreateRequest) (*types.ServerResponse, error) {
 	log.Printf("🖥️ 새 서버 등록 시도: %s (%s)", req.Name, req.Host)
@@ -29,10 +68,14 @@ func CreateServer(userID uint, req types.ServerCreateRequest) (*types.ServerResp
 	if req.Port <= 0 {
 		req.Port = 22 // 기본 SSH 포트
 	}
+	deployMethod, err := normalizeDeployMethod(userID, req.DeployMethod)
+	if err != nil {
+		return nil, err
+	}
 
 	// 중복 확인 (동일 사용자가 같은 호스트+포트 조합으로 등록했는지)
 	var existingServer models.Server
-	err := models.DB.Where("user_id = ? AND host = ? AND port = ?", userID, req.Host, req.Port).First(&existingServer).Error
+	err = models.DB.Where("user_id = ? AND host = ? AND port = ?", userID, req.Host, req.Port).First(&existingServer).Error
 	if err == nil {
 		return nil, errors.New("이미 등록된 서버입니다")
 	}
@@ -45,6 +88,15 @@ func CreateServer(userID uint, req types.ServerCreateRequest) (*types.ServerResp
 		Username:    strings.TrimSpace(req.Username),
 		Description: strings.TrimSpace(req.Description),
 		Status:      "active",
+
+		DeployMethod:       deployMethod,
+		ManagedExclusively: req.ManagedExclusively,
+	}
+	if deployMethod != models.DeployMethodSimulated && IsUserAdmin(userID) {
+		// 관리자가 등록한 서버 계정은 바로 승인
+		now := time.Now()
+		server.ApprovedBy = &userID
+		server.ApprovedAt = &now
 	}
 
 	result := models.DB.Create(&server)
@@ -128,6 +180,12 @@ func UpdateServer(userID, serverID uint, req types.ServerUpdateRequest) (*types.
 	if req.Port > 0 && req.Port != server.Port {
 		updates["port"] = req.Port
 	}
+	if updates["host"] != nil || updates["port"] != nil {
+		// 접속 대상이 바뀌면 기록된 호스트 키를 지우고 다음 접속에서 다시 기록
+		updates["host_key_type"] = ""
+		updates["host_key_fingerprint"] = ""
+		updates["host_key_pinned_at"] = nil
+	}
 	if req.Username != "" && req.Username != server.Username {
 		updates["username"] = strings.TrimSpace(req.Username)
 	}
@@ -138,8 +196,30 @@ func UpdateServer(userID, serverID uint, req types.ServerUpdateRequest) (*types.
 		if req.Status != "active" && req.Status != "inactive" {
 			return nil, errors.New("상태는 'active' 또는 'inactive'만 가능합니다")
 		}
+		if server.Status == models.ServerStatusHostKeyChanged {
+			return nil, errors.New("호스트 키가 변경된 서버의 상태를 바꿀 권한이 없습니다. 관리자의 호스트 키 승인이 필요합니다")
+		}
 		updates["status"] = req.Status
 	}
+	if req.DeployMethod != "" && req.DeployMethod != server.DeployMethod {
+		deployMethod, err := normalizeDeployMethod(userID, req.DeployMethod)
+		if err != nil {
+			return nil, err
+		}
+		updates["deploy_method"] = deployMethod
+	}
+	if req.ManagedExclusively != nil && *req.ManagedExclusively != server.ManagedExclusively {
+		// 켜면 다음 드리프트 검사부터 관리하지 않는 키를 제거하므로 로그에 남김
+		log.Printf("🔒 독점 관리 모드 변경: %s (%t → %t)", server.Name, server.ManagedExclusively, *req.ManagedExclusively)
+		updates["managed_exclusively"] = *req.ManagedExclusively
+	}
+
+	if server.ApprovedAt != nil && (updates["host"] != nil || updates["port"] != nil || updates["username"] != nil || updates["deploy_method"] != nil) {
+		// 승인받은 계정이 아니게 되므로 관리자 승인을 다시 받아야 함
+		log.Printf("🔓 서버 계정 승인 해제: %s (접속 대상 또는 배포 방식 변경)", server.Name)
+		updates["approved_by"] = nil
+		updates["approved_at"] = nil
+	}
 
 	// 업데이트할 내용이 있는 경우에만 실행
 	if len(updates) > 0 {
@@ -173,10 +253,16 @@ func DeleteServer(userID, serverID uint) error {
 		return result.Error
 	}
 
-	// 관련된 배포 기록도 함께 삭제 (CASCADE)
+	// 관련된 배포 기록, 스냅샷, 드리프트 검사 결과도 함께 삭제 (CASCADE)
 	if err := models.DB.Where("server_id = ?", serverID).Delete(&models.ServerKeyDeployment{}).Error; err != nil {
 		log.Printf("⚠️ 배포 기록 삭제 실패: %v", err)
 	}
+	if err := models.DB.Where("server_id = ?", serverID).Delete(&models.AuthorizedKeysSnapshot{}).Error; err != nil {
+		log.Printf("⚠️ authorized_keys 스냅샷 삭제 실패: %v", err)
+	}
+	if err := models.DB.Where("server_id = ?", serverID).Delete(&models.ServerDriftReport{}).Error; err != nil {
+		log.Printf("⚠️ 드리프트 검사 결과 삭제 실패: %v", err)
+	}
 
 	// 서버 삭제
 	if err := models.DB.Delete(&server).Error; err != nil {
@@ -188,15 +274,89 @@ func DeleteServer(userID, serverID uint) error {
 	return nil
 }
 
+// TestServerConnection은 서버의 배포 방식으로 authorized_keys를 수정할 수 있는지 테스트합니다.
+// 원격 방식은 호스트 키를 검증한 뒤 접속하며, 기록된 호스트 키가 없으면 이 테스트에서만 처음 받은 키를 기록합니다.
+// 연결 실패는 오류가 아닌 실패 결과로 반환합니다.
+func TestServerConnection(userID, serverID uint) (*types.ConnectionTestResult, error) {
+	var server models.Server
+	if err := models.DB.Where("id = ? AND user_id = ?", serverID, userID).First(&server).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("서버를 찾을 수 없습니다")
+		}
+		return nil, err
+	}
+
+	log.Printf("🔌 서버 연결 테스트: %s (%s:%d, 배포 방식: %s)", server.Name, server.Host, server.Port, server.DeployMethod)
+
+	pinned, err := pinHostKeyOnFirstTest(&server)
+	var deployer utils.Deployer
+	if err == nil {
+		deployer, err = serverDeployer(&server)
+	}
+	if err == nil {
+		err = checkRemoteError(&server, deployer.Verify(context.Background()))
+	}
+
+	result := &types.ConnectionTestResult{
+		Success:            err == nil,
+		Message:            "연결 테스트 성공",
+		HostKeyType:        server.HostKeyType,
+		HostKeyFingerprint: server.HostKeyFingerprint,
+		HostKeyPinned:      pinned,
+	}
+	if err != nil {
+		result.Message = "연결 테스트 실패"
+		result.Error = err.Error()
+		if errors.Is(err, ErrHostKeyChanged) {
+			result.Message = "호스트 키 변경 감지"
+		}
+	}
+	return result, nil
+}
+
+// GetServerInfo는 호스트 키를 검증한 뒤 원격 서버의 OS, 커널 등 기본 정보를 조회합니다.
+// 원격 명령을 실행하므로 ssh 배포 방식 서버만 지원합니다.
+func GetServerInfo(userID, serverID uint) (*types.ServerInfoResult, error) {
+	var server models.Server
+	if err := models.DB.Where("id = ? AND user_id = ?", serverID, userID).First(&server).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("서버를 찾을 수 없습니다")
+		}
+		return nil, err
+	}
+	if server.DeployMethod != "" && server.DeployMethod != models.DeployMethodSSH {
+		return nil, fmt.Errorf("지원하지 않는 배포 방식입니다: 서버 정보 조회는 ssh 방식 서버만 가능합니다 (현재: %s)", server.DeployMethod)
+	}
+
+	target, err := serverSSHTarget(&server)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := utils.GetRemoteServerInfo(context.Background(), target)
+	if err != nil {
+		return nil, checkRemoteError(&server, err)
+	}
+
+	return &types.ServerInfoResult{
+		OS:           info["OS"],
+		Kernel:       info["Kernel"],
+		Architecture: info["Architecture"],
+		Hostname:     info["Hostname"],
+		Uptime:       info["Uptime"],
+	}, nil
+}
+
 // DeployKeyToServers는 SSH 키를 선택된 서버들에 배포합니다.
 func DeployKeyToServers(userID uint, req types.KeyDeploymentRequest) ([]types.DeploymentResult, error) {
 	log.Printf("🚀 SSH 키 배포 시작 (사용자 ID: %d, 서버 수: %d)", userID, len(req.ServerIDs))
 
-	// 사용자의 SSH 키 조회
-	sshKey, err := GetKeyByUserID(userID)
+	// 배포할 SSH 키 조회 (요청에 키 ID가 없으면 유일한 활성 키 사용)
+	sshKey, err := resolveDeploymentKey(userID, req.SSHKeyID)
 	if err != nil {
-		return nil, errors.New("SSH 키를 찾을 수 없습니다. 먼저 키를 생성해주세요")
+		return nil, err
 	}
+	log.Printf("🔑 배포 키: %s (ID: %d, %s)", sshKey.Name, sshKey.ID, sshKey.Algorithm)
 
 	// 선택된 서버들 조회
 	var servers []models.Server
@@ -209,64 +369,244 @@ func DeployKeyToServers(userID uint, req types.KeyDeploymentRequest) ([]types.De
 		return nil, errors.New("선택된 서버를 찾을 수 없습니다")
 	}
 
-	var deploymentResults []types.DeploymentResult
+	// 각 서버에 키 배포 (동시 실행, 전체 기한은 req.Options.Timeout)
+	ctx, cancel := deploymentContext(context.Background(), req.Options)
+	defer cancel()
 
-	// 각 서버에 키 배포
-	for _, server := range servers {
-		log.Printf("📡 서버에 키 배포 중: %s (%s:%d)", server.Name, server.Host, server.Port)
+	deploymentResults := make([]types.DeploymentResult, len(servers))
+	runServerDeployments(ctx, userID, 0, models.DeploymentActionDeploy, servers,
+		func(ctx context.Context, server models.Server) types.DeploymentResult {
+			return deployKeyToServer(ctx, userID, sshKey, server, req.Options)
+		},
+		func(index int, result types.DeploymentResult) {
+			deploymentResults[index] = result
+		})
 
-		result := types.DeploymentResult{
-			ServerID:   server.ID,
-			ServerName: server.Name,
+	successCount := 0
+	for _, result := range deploymentResults {
+		if result.Status == "success" {
+			successCount++
 		}
+	}
+
+	log.Printf("🎯 키 배포 완료: 성공 %d/%d", successCount, len(deploymentResults))
+	return deploymentResults, nil
+}
+
+// deployKeyToServer는 서버 한 대에 키를 배포하고 배포 기록을 남깁니다.
+// 네트워크 오류는 배포 엔진 설정에 따라 재시도하며, ctx가 취소되면 진행 중인 원격 작업도 중단됩니다.
+func deployKeyToServer(ctx context.Context, userID uint, sshKey *models.SSHKey, server models.Server, options types.DeploymentOptions) types.DeploymentResult {
+	log.Printf("📡 서버에 키 배포 중: %s (%s:%d)", server.Name, server.Host, server.Port)
+
+	result := types.DeploymentResult{
+		ServerID:   server.ID,
+		ServerName: server.Name,
+	}
+
+	// 배포 기록 생성
+	deployment := models.ServerKeyDeployment{
+		ServerID: server.ID,
+		SSHKeyID: sshKey.ID,
+		UserID:   userID,
+		Action:   models.DeploymentActionDeploy,
+		Status:   "pending",
+	}
+	models.DB.Create(&deployment)
 
-		// 배포 기록 생성
-		deployment := models.ServerKeyDeployment{
-			ServerID: server.ID,
-			SSHKeyID: sshKey.ID,
-			UserID:   userID,
-			Status:   "pending",
+	// 스냅샷을 남긴 뒤 서버 배포 방식으로 키 설치 (호스트 키 변경 감지 시 배포하지 않음)
+	err := retryAuthorizedKeysChange(ctx, &server, &deployment, func(ctx context.Context, deployer utils.Deployer) error {
+		if options.OverwriteKeys {
+			log.Printf("⚠️ 기존 키를 모두 교체합니다: %s", server.Name)
+			return overwriteAuthorizedKeys(ctx, deployer, sshKey.PublicKey)
 		}
-		models.DB.Create(&deployment)
+		return deployer.Install(ctx, sshKey.PublicKey)
+	})
+	result.SnapshotID = deployment.SnapshotID
+
+	if err != nil {
+		// 배포 실패
+		result.Status = "failed"
+		result.ErrorMessage = err.Error()
+		deployment.Status = "failed"
+		deployment.ErrorMsg = err.Error()
+		log.Printf("❌ 키 배포 실패 [%s]: %v", server.Name, err)
+	} else {
+		// 배포 성공
+		result.Status = "success"
+		deployment.Status = "success"
+		now := gorm.DeletedAt{Time: time.Now(), Valid: true}
+		deployment.DeployedAt = &now
+		log.Printf("✅ 키 배포 성공: %s", server.Name)
+	}
 
-		// 실제 키 배포 실행
-		err := utils.DeploySSHKeyToRemoteServer(
-			sshKey.PublicKey,
-			server.Host,
-			server.Port,
-			server.Username,
-		)
+	// 배포 기록 업데이트
+	models.DB.Save(&deployment)
+	return result
+}
 
-		if err != nil {
-			// 배포 실패
-			result.Status = "failed"
-			result.ErrorMessage = err.Error()
-			deployment.Status = "failed"
-			deployment.ErrorMsg = err.Error()
-			log.Printf("❌ 키 배포 실패 [%s]: %v", server.Name, err)
-		} else {
-			// 배포 성공
-			result.Status = "success"
-			deployment.Status = "success"
-			now := gorm.DeletedAt{Time: time.Now(), Valid: true}
-			deployment.DeployedAt = &now
-			log.Printf("✅ 키 배포 성공: %s", server.Name)
+// overwriteAuthorizedKeys는 authorized_keys를 배포할 키만 남기고 교체합니다.
+// 관리 도구 자신의 키는 남겨 두어 교체 후에도 서버에 접속할 수 있게 합니다 (planReconcile과 같음).
+func overwriteAuthorizedKeys(ctx context.Context, deployer utils.Deployer, publicKey string) error {
+	lines, err := deployer.List(ctx)
+	if err != nil {
+		return err
+	}
+	present, _ := utils.ParseAuthorizedKeyLines(lines)
+
+	managerKeys := make(map[string]bool)
+	for _, fingerprint := range utils.ManagerKeyFingerprints() {
+		managerKeys[fingerprint] = true
+	}
+
+	var output []string
+	kept := make(map[string]bool)
+	for _, line := range present {
+		if managerKeys[line.Fingerprint] && !kept[line.Fingerprint] {
+			kept[line.Fingerprint] = true
+			output = append(output, line.Line)
 		}
+	}
+	if len(kept) > 0 {
+		log.Printf("🔐 관리 도구 키 %d개는 유지합니다: %s", len(kept), deployer)
+	}
 
-		// 배포 기록 업데이트
-		models.DB.Save(&deployment)
-		deploymentResults = append(deploymentResults, result)
+	// 배포할 키가 관리 도구 키와 같으면 이미 남아 있음
+	deployed, _ := utils.ParseAuthorizedKeyLines([]string{publicKey})
+	if len(deployed) == 0 || !kept[deployed[0].Fingerprint] {
+		output = append(output, strings.TrimSpace(publicKey))
 	}
+	return deployer.Restore(ctx, strings.Join(output, "\n")+"\n")
+}
 
+// UndeployKeyFromServers는 SSH 키를 선택된 서버들의 authorized_keys에서 제거합니다.
+// 폐기되거나 삭제된 키도 제거할 수 있으며, 서버별 결과를 배포와 같은 형식으로 반환합니다.
+func UndeployKeyFromServers(userID uint, req types.KeyUndeployRequest) ([]types.DeploymentResult, error) {
+	log.Printf("🗑️ SSH 키 제거 시작 (사용자 ID: %d, 서버 수: %d)", userID, len(req.ServerIDs))
+
+	sshKey, err := resolveUndeployKey(userID, req.SSHKeyID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🔑 제거할 키: %s (ID: %d, %s)", sshKey.Name, sshKey.ID, sshKey.Algorithm)
+
+	var servers []models.Server
+	if err := models.DB.Where("id IN ? AND user_id = ?", req.ServerIDs, userID).Find(&servers).Error; err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("선택된 서버를 찾을 수 없습니다")
+	}
+
+	results := make([]types.DeploymentResult, len(servers))
 	successCount := 0
-	for _, result := range deploymentResults {
-		if result.Status == "success" {
-			successCount++
+	runServerDeployments(context.Background(), userID, 0, models.DeploymentActionRemove, servers,
+		func(ctx context.Context, server models.Server) types.DeploymentResult {
+			return undeployKeyFromServer(ctx, sshKey, server)
+		},
+		func(index int, result types.DeploymentResult) {
+			if result.Status == "success" {
+				successCount++
+			}
+			results[index] = result
+		})
+
+	log.Printf("🎯 키 제거 완료: 성공 %d/%d", successCount, len(results))
+	return results, nil
+}
+
+// undeployKeyFromServer는 서버 한 대에서 키를 제거하고 배포와 같은 형식의 결과를 반환합니다.
+func undeployKeyFromServer(ctx context.Context, sshKey *models.SSHKey, server models.Server) types.DeploymentResult {
+	result := types.DeploymentResult{
+		ServerID:   server.ID,
+		ServerName: server.Name,
+		Status:     "success",
+	}
+
+	record, err := removeKeyFromServer(ctx, sshKey, server)
+	result.SnapshotID = record.SnapshotID
+	if err != nil {
+		result.Status = "failed"
+		result.ErrorMessage = err.Error()
+	}
+	return result
+}
+
+// resolveUndeployKey는 서버에서 제거할 키를 결정합니다.
+// 이미 폐기되었거나 삭제된 키도 서버에 남아 있을 수 있으므로 상태와 관계없이 찾습니다.
+func resolveUndeployKey(userID, keyID uint) (*models.SSHKey, error) {
+	if keyID != 0 {
+		var key models.SSHKey
+		if err := models.DB.Unscoped().Where("id = ? AND user_id = ?", keyID, userID).First(&key).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("키를 찾을 수 없습니다")
+			}
+			return nil, err
 		}
+		return &key, nil
 	}
 
-	log.Printf("🎯 키 배포 완료: 성공 %d/%d", successCount, len(deploymentResults))
-	return deploymentResults, nil
+	var keys []models.SSHKey
+	if err := models.DB.Where("user_id = ?", userID).Limit(2).Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	switch len(keys) {
+	case 0:
+		return nil, errors.New("SSH 키를 찾을 수 없습니다")
+	case 1:
+		return &keys[0], nil
+	}
+	return nil, errors.New("제거할 SSH 키를 선택해주세요 (ssh_key_id)")
+}
+
+// runServerDeployments는 서버별 작업을 배포 엔진으로 동시에 실행하고, 서버마다 connecting과 결과 이벤트를 보냅니다.
+// done은 서버 작업이 끝날 때마다 servers의 index와 함께 호출되며, 동시에 호출되지 않습니다.
+// 전체 기한이 지나 시작하지 못한 서버는 실패로 done을 호출하고, 취소로 시작하지 못한 서버는 호출하지 않습니다.
+func runServerDeployments(ctx context.Context, userID, jobID uint, action string, servers []models.Server,
+	process func(ctx context.Context, server models.Server) types.DeploymentResult, done func(index int, result types.DeploymentResult)) {
+	var mu sync.Mutex
+	finish := func(index int, result types.DeploymentResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		done(index, result)
+		publishServerResult(userID, jobID, action, result)
+	}
+
+	ctx = withDeploymentJobID(ctx, jobID)
+	skipped, err := utils.RunDeployTasks(ctx, len(servers), func(ctx context.Context, index int) {
+		publishServerConnecting(userID, jobID, servers[index], 1)
+		finish(index, process(ctx, servers[index]))
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	log.Printf("⏰ 전체 배포 기한이 지나 서버 %d대에 작업하지 않았습니다", len(skipped))
+	for _, index := range skipped {
+		finish(index, types.DeploymentResult{
+			ServerID:     servers[index].ID,
+			ServerName:   servers[index].Name,
+			Status:       "failed",
+			ErrorMessage: "전체 배포 기한이 지나 작업하지 않았습니다",
+		})
+	}
+}
+
+// retryAuthorizedKeysChange는 changeAuthorizedKeys를 실행하고, 네트워크 오류이면 배포 엔진 설정에 따라 재시도합니다.
+// 같은 배포 기록을 사용하므로 재시도해도 기록은 하나이며, 스냅샷은 마지막 시도의 것이 연결됩니다.
+func retryAuthorizedKeysChange(ctx context.Context, server *models.Server, deployment *models.ServerKeyDeployment, change func(ctx context.Context, deployer utils.Deployer) error) error {
+	return utils.RetryDeploy(ctx, func(ctx context.Context) error {
+		return changeAuthorizedKeys(ctx, server, deployment, change)
+	}, func(attempt int, err error, delay time.Duration) {
+		publishServerRetried(deployment.UserID, deploymentJobIDFromContext(ctx), *server, attempt, err)
+	})
+}
+
+// deploymentContext는 배포 옵션의 전체 기한을 적용한 컨텍스트를 만듭니다.
+func deploymentContext(parent context.Context, options types.DeploymentOptions) (context.Context, context.CancelFunc) {
+	if options.Timeout > 0 {
+		return context.WithTimeout(parent, time.Duration(options.Timeout)*time.Second)
+	}
+	return context.WithCancel(parent)
 }
 
 // GetDeploymentHistory는 키 배포 기록을 조회합니다.
@@ -288,11 +628,13 @@ func GetDeploymentHistory(userID uint) ([]map[string]interface{}, error) {
 	var history []map[string]interface{}
 	for _, deployment := range deployments {
 		record := map[string]interface{}{
-			"id":         deployment.ID,
-			"server_id":  deployment.ServerID,
-			"ssh_key_id": deployment.SSHKeyID,
-			"status":     deployment.Status,
-			"created_at": deployment.CreatedAt,
+			"id":          deployment.ID,
+			"server_id":   deployment.ServerID,
+			"ssh_key_id":  deployment.SSHKeyID,
+			"action":      deployment.Action,
+			"status":      deployment.Status,
+			"created_at":  deployment.CreatedAt,
+			"snapshot_id": deployment.SnapshotID,
 			"server": map[string]interface{}{
 				"name": deployment.Server.Name,
 				"host": deployment.Server.Host,
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"ssh-key-manager/models"
+	"ssh-key-manager/types"
+	"ssh-key-manager/utils"
+
+	"gorm.io/gorm"
+)
+
+// changeAuthorizedKeys는 서버 authorized_keys의 스냅샷을 남긴 뒤 change를 실행하고, 스냅샷을 배포 기록에 연결합니다.
+// 스냅샷을 남길 수 없으면 되돌릴 방법이 없으므로 변경하지 않습니다.
+func changeAuthorizedKeys(ctx context.Context, server *models.Server, deployment *models.ServerKeyDeployment, change func(ctx context.Context, deployer utils.Deployer) error) error {
+	deployer, err := serverDeployer(server)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := snapshotAuthorizedKeys(ctx, deployer, server, deployment.UserID, deployment.Action)
+	if err != nil {
+		return checkRemoteError(server, err)
+	}
+	deployment.SnapshotID = &snapshot.ID
+
+	return checkRemoteError(server, change(ctx, deployer))
+}
+
+// snapshotAuthorizedKeys는 현재 authorized_keys를 서버 백업 파일과 DB에 스냅샷으로 남깁니다.
+func snapshotAuthorizedKeys(ctx context.Context, deployer utils.Deployer, server *models.Server, userID uint, action string) (*models.AuthorizedKeysSnapshot, error) {
+	backup, err := deployer.Snapshot(ctx)
+	if err != nil {
+		log.Printf("❌ authorized_keys 스냅샷 실패 [%s]: %v", server.Name, err)
+		return nil, fmt.Errorf("authorized_keys 백업에 실패하여 작업을 중단합니다: %w", err)
+	}
+
+	checksum := sha256.Sum256([]byte(backup.Content))
+	snapshot := models.AuthorizedKeysSnapshot{
+		ServerID:   server.ID,
+		UserID:     userID,
+		Action:     action,
+		Content:    backup.Content,
+		Checksum:   hex.EncodeToString(checksum[:]),
+		KeyCount:   utils.CountAuthorizedKeys(backup.Content),
+		RemotePath: backup.Path,
+	}
+	if err := models.DB.Create(&snapshot).Error; err != nil {
+		log.Printf("❌ authorized_keys 스냅샷 저장 실패 [%s]: %v", server.Name, err)
+		return nil, errors.New("authorized_keys 백업 저장 중 오류가 발생하여 작업을 중단합니다")
+	}
+
+	log.Printf("💾 authorized_keys 스냅샷 저장: %s (스냅샷 ID: %d, 키 %d개)", server.Name, snapshot.ID, snapshot.KeyCount)
+	return &snapshot, nil
+}
+
+// GetServerSnapshots는 서버의 authorized_keys 스냅샷 목록을 최신순으로 조회합니다 (내용 제외).
+func GetServerSnapshots(userID, serverID uint) ([]types.AuthorizedKeysSnapshotResponse, error) {
+	if _, err := findUserServer(userID, serverID); err != nil {
+		return nil, err
+	}
+
+	var snapshots []models.AuthorizedKeysSnapshot
+	if err := models.DB.Omit("content").Where("server_id = ?", serverID).
+		Order("created_at DESC").Find(&snapshots).Error; err != nil {
+		log.Printf("❌ 스냅샷 목록 조회 실패: %v", err)
+		return nil, err
+	}
+
+	responses := make([]types.AuthorizedKeysSnapshotResponse, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		responses = append(responses, types.ToAuthorizedKeysSnapshotResponse(snapshot, false))
+	}
+	return responses, nil
+}
+
+// GetServerSnapshot은 스냅샷 하나를 내용과 함께 조회합니다.
+func GetServerSnapshot(userID, serverID, snapshotID uint) (*types.AuthorizedKeysSnapshotResponse, error) {
+	if _, err := findUserServer(userID, serverID); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := findServerSnapshot(serverID, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := types.ToAuthorizedKeysSnapshotResponse(*snapshot, true)
+	return &response, nil
+}
+
+// RollbackServerAuthorizedKeys는 서버 authorized_keys를 선택한 스냅샷 내용으로 되돌립니다.
+// 롤백 직전 상태도 스냅샷으로 남기므로 롤백 자체를 다시 되돌릴 수 있습니다.
+func RollbackServerAuthorizedKeys(userID, serverID uint, req types.ServerRollbackRequest) (*types.ServerRollbackResult, error) {
+	server, err := findUserServer(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := findServerSnapshot(serverID, req.SnapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("⏪ authorized_keys 롤백 시작: %s (스냅샷 ID: %d, 키 %d개)", server.Name, target.ID, target.KeyCount)
+
+	deployer, err := serverDeployer(server)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	previous, err := snapshotAuthorizedKeys(ctx, deployer, server, userID, models.SnapshotActionRollback)
+	if err != nil {
+		return nil, checkRemoteError(server, err)
+	}
+
+	if err := deployer.Restore(ctx, target.Content); err != nil {
+		log.Printf("❌ authorized_keys 롤백 실패 [%s]: %v", server.Name, err)
+		return nil, checkRemoteError(server, err)
+	}
+
+	log.Printf("✅ authorized_keys 롤백 완료: %s (복원: %d, 롤백 전: %d)", server.Name, target.ID, previous.ID)
+	utils.LogSecurityEvent("authorized_keys 롤백", userID,
+		fmt.Sprintf("서버 %s (ID %d) 스냅샷 %d로 복원, 롤백 전 스냅샷 %d", server.Name, server.ID, target.ID, previous.ID), "medium")
+
+	return &types.ServerRollbackResult{
+		ServerID:           server.ID,
+		ServerName:         server.Name,
+		RestoredSnapshotID: target.ID,
+		PreviousSnapshotID: previous.ID,
+		KeyCount:           target.KeyCount,
+		BackupPath:         previous.RemotePath,
+	}, nil
+}
+
+// findUserServer는 사용자가 등록한 서버를 조회합니다.
+func findUserServer(userID, serverID uint) (*models.Server, error) {
+	var server models.Server
+	if err := models.DB.Where("id = ? AND user_id = ?", serverID, userID).First(&server).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("서버를 찾을 수 없습니다")
+		}
+		return nil, err
+	}
+	return &server, nil
+}
+
+// findServerSnapshot은 서버의 스냅샷을 조회합니다.
+func findServerSnapshot(serverID, snapshotID uint) (*models.AuthorizedKeysSnapshot, error) {
+	var snapshot models.AuthorizedKeysSnapshot
+	if err := models.DB.Where("id = ? AND server_id = ?", snapshotID, serverID).First(&snapshot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("스냅샷을 찾을 수 없습니다")
+		}
+		return nil, err
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"log"
+)
+
+// StartBackgroundServices는 애플리케이션 시작 시 원격 접속/배포 설정을 적용하고 백그라운드 작업을 시작합니다.
+// 데이터베이스 연결(models.SetDB) 후, HTTP 서버 시작 전에 한 번 호출합니다.
+func StartBackgroundServices() {
+	// 원격 SSH 접속 설정 적용
+	if err := ConfigureSSHTransport(); err != nil {
+		log.Printf("⚠️ 원격 SSH 접속 설정 실패 (기본값 사용): %v", err)
+	}
+
+	// 배포 실행 설정 적용 (동시 서버 수, 네트워크 오류 재시도)
+	if err := ConfigureDeployEngine(); err != nil {
+		log.Printf("⚠️ 배포 실행 설정 실패 (기본값 사용): %v", err)
+	}
+
+	// 관리용 known_hosts 준비 (호스트 CA 검증 및 첫 접속 키 기록)
+	if err := RefreshManagerKnownHosts(); err != nil {
+		log.Printf("⚠️ 관리용 known_hosts 준비 실패 (호스트 키를 검증하지 않습니다): %v", err)
+	}
+
+	// 백그라운드 작업 시작 (키 만료 알림 및 자동 교체)
+	StartKeyExpiryScheduler()
+
+	// 백그라운드 작업 시작 (authorized_keys 드리프트 검사)
+	StartDriftScanScheduler()
+
+	// 백그라운드 작업 시작 (비동기 배포 작업 실행, 중단된 작업 재개)
+	StartDeploymentJobWorkers()
+}
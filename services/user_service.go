@@ -68,31 +68,34 @@ func GetUserDetailWithKey(userID uint) (*types.UserDetailWithKey, error) {
 
 	hasSSHKey := false
 	var sshKeyResponse *types.SSHKeyResponse
+	var keySummaries []types.SSHKeyResponse
 
-	// SSH 키 정보 조회
-	var sshKey models.SSHKey
-	keyResult := models.DB.Where("user_id = ?", userID).First(&sshKey)
-	if keyResult.Error == nil {
-		// SSH 키가 있는 경우
-		hasSSHKey = true
+	// SSH 키 목록 조회 (최신순)
+	var sshKeys []models.SSHKey
+	keyResult := models.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&sshKeys)
+	if keyResult.Error != nil {
+		log.Printf("⚠️ SSH 키 조회 중 오류: %v", keyResult.Error)
+	}
 
-		// SSH 키 핑거프린트 생성
-		fingerprint, err := generateSSHKeyFingerprint(sshKey.PublicKey)
-		if err != nil {
-			log.Printf("⚠️ 핑거프린트 생성 실패: %v", err)
-		}
+	for i, sshKey := range sshKeys {
+		// SSH 키 핑거프린트 (SHA256)
+		fingerprint := keyFingerprint(&sshKey)
 
-		response := types.ToSSHKeyResponse(sshKey, fingerprint)
-		sshKeyResponse = &response
-	} else if !errors.Is(keyResult.Error, gorm.ErrRecordNotFound) {
-		// SSH 키 조회 중 다른 오류 발생
-		log.Printf("⚠️ SSH 키 조회 중 오류: %v", keyResult.Error)
+		// 가장 최근 키는 대표 키로 포함 (관리자 조회, 권한 변경 응답에도 쓰이므로 개인키는 복호화하지 않음)
+		if i == 0 {
+			hasSSHKey = true
+			response := types.ToSSHKeySummary(sshKey, fingerprint)
+			sshKeyResponse = &response
+		}
+		keySummaries = append(keySummaries, types.ToSSHKeySummary(sshKey, fingerprint))
 	}
 
 	userDetail := types.ToUserDetailWithKey(user, hasSSHKey, sshKeyResponse)
+	userDetail.SSHKeys = keySummaries
+	userDetail.KeyCount = len(sshKeys)
 
-	log.Printf("✅ 사용자 상세 정보 조회 완료 (사용자: %s, 권한: %s, SSH 키: %t)",
-		user.Username, string(user.Role), hasSSHKey)
+	log.Printf("✅ 사용자 상세 정보 조회 완료 (사용자: %s, 권한: %s, SSH 키: %d개)",
+		user.Username, string(user.Role), len(sshKeys))
 	return &userDetail, nil
 }
 
@@ -171,36 +174,6 @@ func UpdateUserProfile(userID uint, updateData types.UserProfileUpdate) (*types.
 	return &userInfo, nil
 }
 
-// generateSSHKeyFingerprint는 SSH 공개키의 핑거프린트를 생성합니다.
-func generateSSHKeyFingerprint(publicKey string) (string, error) {
-	// 간단한 핑거프린트 생성 (실제로는 더 복잡한 알고리즘 사용)
-	// 여기서는 공개키의 앞부분과 뒷부분을 조합하여 간단한 식별자 생성
-	lines := strings.Split(strings.TrimSpace(publicKey), " ")
-	if len(lines) < 2 {
-		return "", errors.New("유효하지 않은 공개키 형식")
-	}
-
-	keyData := lines[1] // base64 인코딩된 키 데이터
-	if len(keyData) < 16 {
-		return "", errors.New("키 데이터가 너무 짧습니다")
-	}
-
-	// 간단한 핑거프린트: 처음 8자 + 마지막 8자를 콜론으로 구분
-	fingerprint := ""
-	start := keyData[:8]
-	end := keyData[len(keyData)-8:]
-
-	// 콜론으로 구분된 형식으로 변환
-	for i, c := range start + end {
-		if i > 0 && i%2 == 0 {
-			fingerprint += ":"
-		}
-		fingerprint += string(c)
-	}
-
-	return fingerprint, nil
-}
-
 // CreateAdminUser는 초기 관리자 계정을 생성합니다.
 func CreateAdminUser(username, password string) error {
 	log.Printf("👑 초기 관리자 계정 생성 시도: %s", username)
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"ssh-key-manager/models"
+	"strings"
+	"time"
+)
+
+// === SSH 인증서 관련 ===
+
+// UserCertificateRequest는 사용자 인증서 발급 요청 구조체입니다.
+type UserCertificateRequest struct {
+	SSHKeyID  uint   `json:"ssh_key_id"`           // 서명할 키 ID (비어있으면 유일한 활성 키)
+	ServerIDs []uint `json:"server_ids,omitempty"` // principal을 가져올 서버 (비어있으면 관리자가 승인한 모든 서버)
+	ValidFor  string `json:"valid_for,omitempty"`  // 유효 기간 (예: 1h, 8h, 비어있으면 기본값)
+}
+
+// CertificateResponse는 발급된 SSH 인증서 정보입니다.
+type CertificateResponse struct {
+	ID            uint      `json:"id"`
+	CertType      string    `json:"cert_type"`
+	Serial        uint64    `json:"serial"`
+	KeyID         string    `json:"key_id"`
+	Principals    []string  `json:"principals"`
+	ValidAfter    time.Time `json:"valid_after"`
+	ValidBefore   time.Time `json:"valid_before"`
+	Fingerprint   string    `json:"fingerprint"` // 서명된 공개키 핑거프린트
+	Certificate   string    `json:"certificate"` // *-cert.pub 파일 내용
+	SSHKeyID      *uint     `json:"ssh_key_id,omitempty"`
+	ServerID      *uint     `json:"server_id,omitempty"`
+	Revoked       bool      `json:"revoked"`
+	CAPublicKey   string    `json:"ca_public_key,omitempty"`
+	CAFingerprint string    `json:"ca_fingerprint,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// HostCertificateRequest는 서버 호스트 키 서명 요청 구조체입니다.
+type HostCertificateRequest struct {
+	HostPublicKey string   `json:"host_public_key,omitempty"` // 서버의 /etc/ssh/ssh_host_*_key.pub 내용 (비어있으면 접속하여 조회한 키 중 기록된 호스트 키와 일치하는 키)
+	Principals    []string `json:"principals,omitempty"`      // 서버 호스트 외에 추가할 호스트명 (별칭, FQDN 등)
+	ValidFor      string   `json:"valid_for,omitempty"`       // 유효 기간 (비어있으면 HOST_CERT_TTL, 최대 HOST_CERT_MAX_TTL)
+}
+
+// HostCertificateResponse는 발급된 호스트 인증서와 설치 방법입니다.
+type HostCertificateResponse struct {
+	CertificateResponse
+	HostKeySource string   `json:"host_key_source"` // request, scan
+	Instructions  []string `json:"instructions"`
+}
+
+// CAPublicKeyResponse는 CA 공개키 정보입니다.
+type CAPublicKeyResponse struct {
+	Type        string    `json:"type"` // user, host
+	Algorithm   string    `json:"algorithm"`
+	PublicKey   string    `json:"public_key"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at"`
+	Usage       string    `json:"usage"` // 서버/클라이언트 설정 방법
+}
+
+// ToCertificateResponse는 모델을 CertificateResponse로 변환합니다.
+func ToCertificateResponse(cert models.SSHCertificate) CertificateResponse {
+	var principals []string
+	if cert.Principals != "" {
+		principals = strings.Split(cert.Principals, ",")
+	}
+
+	return CertificateResponse{
+		ID:          cert.ID,
+		CertType:    cert.CertType,
+		Serial:      cert.Serial,
+		KeyID:       cert.KeyID,
+		Principals:  principals,
+		ValidAfter:  cert.ValidAfter,
+		ValidBefore: cert.ValidBefore,
+		Fingerprint: cert.Fingerprint,
+		Certificate: cert.Certificate,
+		SSHKeyID:    cert.SSHKeyID,
+		ServerID:    cert.ServerID,
+		Revoked:     cert.RevokedAt != nil,
+		CreatedAt:   cert.CreatedAt,
+	}
+}
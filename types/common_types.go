@@ -1,5 +1,10 @@
 package types
 
+import (
+	"ssh-key-manager/models"
+	"time"
+)
+
 // === 공통 응답 구조체 ===
 
 // APIResponse는 표준 API 응답 구조체입니다.
@@ -209,12 +214,24 @@ type NotificationResponse struct {
 	CreatedAt string `json:"created_at"`
 }
 
+// ToNotificationResponse는 모델을 NotificationResponse로 변환합니다.
+func ToNotificationResponse(notification models.Notification) NotificationResponse {
+	return NotificationResponse{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		Title:     notification.Title,
+		Message:   notification.Message,
+		Read:      notification.ReadAt != nil,
+		CreatedAt: notification.CreatedAt.Format(time.RFC3339),
+	}
+}
+
 // === 작업 진행 상황 관련 ===
 
 // ProgressResponse는 작업 진행 상황 응답입니다.
 type ProgressResponse struct {
 	TaskID      string      `json:"task_id"`
-	Status      string      `json:"status"`   // PENDING, RUNNING, COMPLETED, FAILED
+	Status      string      `json:"status"`   // PENDING, RUNNING, COMPLETED, FAILED, CANCELLED
 	Progress    float64     `json:"progress"` // 0.0 - 1.0
 	Message     string      `json:"message"`
 	StartedAt   string      `json:"started_at"`
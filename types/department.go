@@ -9,32 +9,35 @@ import (
 
 // DepartmentCreateRequest는 부서 생성 요청 구조체입니다.
 type DepartmentCreateRequest struct {
-	Code        string `json:"code" binding:"required"` // 부서 코드
-	Name        string `json:"name" binding:"required"` // 부서명
-	Description string `json:"description"`             // 부서 설명
-	ParentID    *uint  `json:"parent_id"`               // 상위 부서 ID
+	Code          string `json:"code" binding:"required"` // 부서 코드
+	Name          string `json:"name" binding:"required"` // 부서명
+	Description   string `json:"description"`             // 부서 설명
+	ParentID      *uint  `json:"parent_id"`               // 상위 부서 ID
+	MaxKeyAgeDays int    `json:"max_key_age_days"`        // 최대 키 사용 기간 (일, 0이면 상위 부서/조직 기본값)
 }
 
 // DepartmentUpdateRequest는 부서 수정 요청 구조체입니다.
 type DepartmentUpdateRequest struct {
-	Code        string `json:"code,omitempty"`
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
-	ParentID    *uint  `json:"parent_id,omitempty"`
-	IsActive    *bool  `json:"is_active,omitempty"`
+	Code          string `json:"code,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Description   string `json:"description,omitempty"`
+	ParentID      *uint  `json:"parent_id,omitempty"`
+	IsActive      *bool  `json:"is_active,omitempty"`
+	MaxKeyAgeDays *int   `json:"max_key_age_days,omitempty"` // 0이면 상위 부서/조직 기본값 사용
 }
 
 // DepartmentResponse는 부서 정보 응답 구조체입니다.
 type DepartmentResponse struct {
-	ID          uint      `json:"id"`
-	Code        string    `json:"code"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	ParentID    *uint     `json:"parent_id"`
-	Level       int       `json:"level"`
-	IsActive    bool      `json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uint      `json:"id"`
+	Code          string    `json:"code"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	ParentID      *uint     `json:"parent_id"`
+	Level         int       `json:"level"`
+	IsActive      bool      `json:"is_active"`
+	MaxKeyAgeDays int       `json:"max_key_age_days"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 
 	// 관계 데이터
 	Parent    *DepartmentSimple  `json:"parent,omitempty"`
@@ -126,15 +129,16 @@ type DepartmentBulkUpdateRequest struct {
 // ToDepartmentResponse는 모델을 DepartmentResponse로 변환합니다.
 func ToDepartmentResponse(dept models.Department) DepartmentResponse {
 	response := DepartmentResponse{
-		ID:          dept.ID,
-		Code:        dept.Code,
-		Name:        dept.Name,
-		Description: dept.Description,
-		ParentID:    dept.ParentID,
-		Level:       dept.Level,
-		IsActive:    dept.IsActive,
-		CreatedAt:   dept.CreatedAt,
-		UpdatedAt:   dept.UpdatedAt,
+		ID:            dept.ID,
+		Code:          dept.Code,
+		Name:          dept.Name,
+		Description:   dept.Description,
+		ParentID:      dept.ParentID,
+		Level:         dept.Level,
+		IsActive:      dept.IsActive,
+		MaxKeyAgeDays: dept.MaxKeyAgeDays,
+		CreatedAt:     dept.CreatedAt,
+		UpdatedAt:     dept.UpdatedAt,
 	}
 
 	// 상위 부서 정보
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"encoding/json"
+	"fmt"
 	"ssh-key-manager/models"
 	"time"
 )
@@ -14,6 +16,12 @@ type ServerCreateRequest struct {
 	Port        int    `json:"port"`
 	Username    string `json:"username" binding:"required"`
 	Description string `json:"description"`
+
+	// 키 배포 방식 (ssh, sftp, local, simulated, pull). 생략하면 ssh
+	DeployMethod string `json:"deploy_method,omitempty"`
+
+	// 독점 관리 모드 (authorized_keys를 관리 중인 키와 정확히 일치시킴)
+	ManagedExclusively bool `json:"managed_exclusively,omitempty"`
 }
 
 // ServerUpdateRequest는 서버 업데이트 요청 구조체입니다.
@@ -24,6 +32,9 @@ type ServerUpdateRequest struct {
 	Username    string `json:"username,omitempty"`
 	Description string `json:"description,omitempty"`
 	Status      string `json:"status,omitempty"`
+
+	DeployMethod       string `json:"deploy_method,omitempty"`       // 키 배포 방식 (ssh, sftp, local, simulated, pull)
+	ManagedExclusively *bool  `json:"managed_exclusively,omitempty"` // 독점 관리 모드 (생략하면 변경하지 않음)
 }
 
 // ServerResponse는 API용 서버 정보 응답 구조체입니다.
@@ -37,6 +48,21 @@ type ServerResponse struct {
 	Status      string    `json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	DeployMethod       string `json:"deploy_method"`       // 키 배포 방식
+	ManagedExclusively bool   `json:"managed_exclusively"` // 독점 관리 모드
+
+	// 관리자 계정 승인 정보
+	Approved   bool       `json:"approved"`
+	ApprovedAt *time.Time `json:"approved_at,omitempty"`
+
+	// 호스트 키 고정 정보
+	HostKeyType               string     `json:"host_key_type,omitempty"`
+	HostKeyFingerprint        string     `json:"host_key_fingerprint,omitempty"`
+	HostKeyPinnedAt           *time.Time `json:"host_key_pinned_at,omitempty"`
+	PendingHostKeyType        string     `json:"pending_host_key_type,omitempty"`
+	PendingHostKeyFingerprint string     `json:"pending_host_key_fingerprint,omitempty"`
+	HostKeyChangedAt          *time.Time `json:"host_key_changed_at,omitempty"`
 }
 
 // ServerListRequest는 서버 목록 요청 구조체입니다.
@@ -51,15 +77,24 @@ type ServerListRequest struct {
 
 // KeyDeploymentRequest는 키 배포 요청 구조체입니다.
 type KeyDeploymentRequest struct {
+	ServerIDs []uint            `json:"server_ids" binding:"required"`
+	SSHKeyID  uint              `json:"ssh_key_id,omitempty"` // 배포할 키 ID (활성 키가 하나뿐이면 생략 가능)
+	Options   DeploymentOptions `json:"options,omitempty"`
+}
+
+// KeyUndeployRequest는 서버에서 키를 제거하는 요청 구조체입니다.
+type KeyUndeployRequest struct {
 	ServerIDs []uint `json:"server_ids" binding:"required"`
+	SSHKeyID  uint   `json:"ssh_key_id,omitempty"` // 제거할 키 ID (키가 하나뿐이면 생략 가능, 폐기/삭제된 키도 가능)
 }
 
-// DeploymentResult는 키 배포 결과를 담는 구조체입니다.
+// DeploymentResult는 키 배포(제거) 결과를 담는 구조체입니다.
 type DeploymentResult struct {
 	ServerID     uint   `json:"server_id"`
 	ServerName   string `json:"server_name"`
 	Status       string `json:"status"`
 	ErrorMessage string `json:"error_message,omitempty"`
+	SnapshotID   *uint  `json:"snapshot_id,omitempty"` // 배포 직전 authorized_keys 스냅샷 (롤백용)
 }
 
 // DeploymentSummary는 배포 결과 요약 정보입니다.
@@ -69,6 +104,38 @@ type DeploymentSummary struct {
 	Failed  int `json:"failed"`
 }
 
+// DeploymentJobResult는 비동기 배포 작업의 서버별 결과입니다 (진행 중이면 처리한 서버까지의 부분 결과).
+type DeploymentJobResult struct {
+	JobType          string             `json:"job_type"` // deploy_key, undeploy_key
+	Results          []DeploymentResult `json:"results"`
+	Summary          DeploymentSummary  `json:"summary"`
+	PendingServerIDs []uint             `json:"pending_server_ids,omitempty"` // 아직 처리하지 않은 서버
+}
+
+// 배포 이벤트 종류입니다.
+const (
+	DeploymentEventSnapshot    = "snapshot"     // 스트림 연결 시 현재 작업 진행 상황
+	DeploymentEventJobStarted  = "job_started"  // 작업 실행 시작 (재시작 후 재개 포함)
+	DeploymentEventConnecting  = "connecting"   // 서버 작업 시작
+	DeploymentEventInstalled   = "installed"    // 키 배포 성공
+	DeploymentEventRemoved     = "removed"      // 키 제거 성공
+	DeploymentEventFailed      = "failed"       // 서버 작업 실패 (error_message에 사유)
+	DeploymentEventRetried     = "retried"      // 일시적 오류로 서버 작업 재시도
+	DeploymentEventJobFinished = "job_finished" // 작업 종료 (완료, 실패, 취소)
+)
+
+// DeploymentEvent는 배포 진행 중 서버별 이벤트입니다 (SSE 스트림으로 전달).
+// 서버 이벤트는 DeploymentResult 형식에 이벤트 종류와 시도 횟수를 더하고, 작업 이벤트는 작업 진행 상황을 함께 보냅니다.
+type DeploymentEvent struct {
+	ID                uint64            `json:"id"`
+	Event             string            `json:"event"`            // DeploymentEvent* 값
+	JobID             uint              `json:"job_id,omitempty"` // 비동기 작업 ID (요청 안에서 바로 실행한 배포는 0)
+	*DeploymentResult                   // 서버 이벤트의 대상 서버와 결과
+	Attempt           int               `json:"attempt,omitempty"` // 서버 작업 시도 횟수 (재시도 시 2부터)
+	Job               *ProgressResponse `json:"job,omitempty"`     // 작업 진행 상황 (작업 이벤트)
+	Time              time.Time         `json:"time"`
+}
+
 // KeyDeploymentHistoryRequest는 배포 이력 조회 요청입니다.
 type KeyDeploymentHistoryRequest struct {
 	PaginationRequest
@@ -83,9 +150,18 @@ type KeyDeploymentHistoryRequest struct {
 
 // ConnectionTestResult는 서버 연결 테스트 결과입니다.
 type ConnectionTestResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Error   string `json:"error,omitempty"`
+	Success            bool   `json:"success"`
+	Message            string `json:"message"`
+	Error              string `json:"error,omitempty"`
+	HostKeyType        string `json:"host_key_type,omitempty"`
+	HostKeyFingerprint string `json:"host_key_fingerprint,omitempty"`
+	HostKeyPinned      bool   `json:"host_key_pinned"` // 이번 접속에서 호스트 키를 처음 기록했는지 여부
+}
+
+// HostKeyAcceptRequest는 변경된 호스트 키 승인 요청입니다.
+// 검토한 키가 승인 직전에 다시 바뀌지 않았는지 확인하기 위해 핑거프린트를 함께 받습니다.
+type HostKeyAcceptRequest struct {
+	Fingerprint string `json:"fingerprint" binding:"required"` // 승인할 호스트 키 SHA256 핑거프린트 (pending_host_key_fingerprint)
 }
 
 // ServerInfoResult는 서버 정보 조회 결과입니다.
@@ -97,40 +173,13 @@ type ServerInfoResult struct {
 	Uptime       string `json:"uptime"`
 }
 
-// === 배치 배포 관련 ===
-
-// ServerDeployTarget은 배포 대상 서버 정보입니다.
-type ServerDeployTarget struct {
-	Name     string `json:"name"`
-	Host     string `json:"host"`
-	Port     int    `json:"port"`
-	Username string `json:"username"`
-}
-
-// ServerDeployResult는 서버별 배포 결과입니다.
-type ServerDeployResult struct {
-	Index        int           `json:"index"`
-	ServerName   string        `json:"server_name"`
-	Host         string        `json:"host"`
-	Port         int           `json:"port"`
-	Username     string        `json:"username"`
-	Success      bool          `json:"success"`
-	ErrorMessage string        `json:"error_message,omitempty"`
-	Duration     time.Duration `json:"duration"`
-}
-
-// BatchDeploymentRequest는 배치 배포 요청 구조체입니다.
-type BatchDeploymentRequest struct {
-	Servers   []ServerDeployTarget `json:"servers" binding:"required"`
-	PublicKey string               `json:"public_key" binding:"required"`
-	Options   DeploymentOptions    `json:"options,omitempty"`
-}
+// === 배포 옵션 ===
 
 // DeploymentOptions는 배포 옵션 구조체입니다.
 type DeploymentOptions struct {
-	Timeout       int  `json:"timeout"`        // 초 단위
-	CreateBackup  bool `json:"create_backup"`  // 백업 생성 여부
-	OverwriteKeys bool `json:"overwrite_keys"` // 기존 키 덮어쓰기 여부
+	Timeout       int  `json:"timeout"`        // 전체 배포 기한 (초 단위, 0이면 원격 명령마다 SSH_COMMAND_TIMEOUT만 적용)
+	CreateBackup  bool `json:"create_backup"`  // 배포 전 서버에 authorized_keys 백업 파일 생성 (등록된 서버 배포는 항상 백업)
+	OverwriteKeys bool `json:"overwrite_keys"` // 기존 키를 모두 지우고 배포할 키만 남김 (관리 도구 자신의 키는 유지)
 }
 
 // === 변환 헬퍼 함수들 ===
@@ -147,5 +196,261 @@ func ToServerResponse(server models.Server) ServerResponse {
 		Status:      server.Status,
 		CreatedAt:   server.CreatedAt,
 		UpdatedAt:   server.UpdatedAt,
+
+		DeployMethod:       server.DeployMethod,
+		ManagedExclusively: server.ManagedExclusively,
+
+		Approved:   server.ApprovedAt != nil,
+		ApprovedAt: server.ApprovedAt,
+
+		HostKeyType:               server.HostKeyType,
+		HostKeyFingerprint:        server.HostKeyFingerprint,
+		HostKeyPinnedAt:           server.HostKeyPinnedAt,
+		PendingHostKeyType:        server.PendingHostKeyType,
+		PendingHostKeyFingerprint: server.PendingHostKeyFingerprint,
+		HostKeyChangedAt:          server.HostKeyChangedAt,
+	}
+}
+
+// === authorized_keys 스냅샷 및 롤백 ===
+
+// AuthorizedKeysSnapshotResponse는 authorized_keys 스냅샷 응답입니다.
+// 내용(content)은 단건 조회에서만 포함합니다.
+type AuthorizedKeysSnapshotResponse struct {
+	ID         uint      `json:"id"`
+	ServerID   uint      `json:"server_id"`
+	UserID     uint      `json:"user_id"`
+	Action     string    `json:"action"`
+	Checksum   string    `json:"checksum"`
+	KeyCount   int       `json:"key_count"`
+	RemotePath string    `json:"remote_path,omitempty"`
+	Content    *string   `json:"content,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ServerRollbackRequest는 authorized_keys 롤백 요청입니다.
+type ServerRollbackRequest struct {
+	SnapshotID uint `json:"snapshot_id" binding:"required"` // 되돌릴 스냅샷 ID
+}
+
+// ServerRollbackResult는 authorized_keys 롤백 결과입니다.
+type ServerRollbackResult struct {
+	ServerID           uint   `json:"server_id"`
+	ServerName         string `json:"server_name"`
+	RestoredSnapshotID uint   `json:"restored_snapshot_id"` // 복원한 스냅샷
+	PreviousSnapshotID uint   `json:"previous_snapshot_id"` // 롤백 직전 상태 스냅샷 (롤백을 되돌릴 때 사용)
+	KeyCount           int    `json:"key_count"`
+	BackupPath         string `json:"backup_path,omitempty"` // 롤백 직전 상태의 서버 백업 파일
+}
+
+// ToAuthorizedKeysSnapshotResponse는 스냅샷 모델을 응답으로 변환합니다.
+func ToAuthorizedKeysSnapshotResponse(snapshot models.AuthorizedKeysSnapshot, withContent bool) AuthorizedKeysSnapshotResponse {
+	response := AuthorizedKeysSnapshotResponse{
+		ID:         snapshot.ID,
+		ServerID:   snapshot.ServerID,
+		UserID:     snapshot.UserID,
+		Action:     snapshot.Action,
+		Checksum:   snapshot.Checksum,
+		KeyCount:   snapshot.KeyCount,
+		RemotePath: snapshot.RemotePath,
+		CreatedAt:  snapshot.CreatedAt,
+	}
+	if withContent {
+		content := snapshot.Content
+		response.Content = &content
+	}
+	return response
+}
+
+// === 독점 관리 모드 (authorized_keys 정리) ===
+
+// ServerReconcileRequest는 authorized_keys 정리 요청입니다.
+type ServerReconcileRequest struct {
+	DryRun bool `json:"dry_run"` // true면 변경하지 않고 정리 계획만 반환
+}
+
+// ServerReconcileResult는 authorized_keys 정리 결과입니다.
+// 추가한 키는 missing, 제거한 키는 stale/foreign 항목으로 표시합니다.
+type ServerReconcileResult struct {
+	ServerID   uint         `json:"server_id"`
+	ServerName string       `json:"server_name"`
+	DryRun     bool         `json:"dry_run"`
+	Changed    bool         `json:"changed"`               // 내용이 바뀌었는지 (dry_run이면 바뀔 예정인지)
+	Kept       int          `json:"kept"`                  // 유지한 키 수
+	Added      []DriftEntry `json:"added,omitempty"`       // 추가한 관리 키
+	Removed    []DriftEntry `json:"removed,omitempty"`     // 제거한 키
+	SnapshotID *uint        `json:"snapshot_id,omitempty"` // 정리 직전 authorized_keys 스냅샷 (롤백용)
+	BackupPath string       `json:"backup_path,omitempty"` // 정리 직전 상태의 서버 백업 파일
+}
+
+// === 드리프트 검사 ===
+
+// 드리프트 항목 종류입니다.
+const (
+	DriftKindMissing = "missing" // 배포 기록상 있어야 하지만 서버에 없는 키
+	DriftKindStale   = "stale"   // 제거(폐기)되었어야 하지만 서버에 남아 있는 관리 키
+	DriftKindForeign = "foreign" // 이 계정에 배포한 기록이 없는 외부 키
+)
+
+// DriftEntry는 드리프트 항목 하나입니다.
+type DriftEntry struct {
+	Kind        string `json:"kind"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Algorithm   string `json:"algorithm,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+	KeyID       *uint  `json:"key_id,omitempty"`       // 등록된 키이면 키 ID
+	KeyName     string `json:"key_name,omitempty"`     // 등록된 키이면 키 이름
+	KeyOwnerID  *uint  `json:"key_owner_id,omitempty"` // 등록된 키이면 소유자 ID
+	Reason      string `json:"reason,omitempty"`
+}
+
+// ServerDriftReportResponse는 서버 드리프트 검사 결과 응답입니다.
+type ServerDriftReportResponse struct {
+	ID           uint         `json:"id"`
+	ServerID     uint         `json:"server_id"`
+	ServerName   string       `json:"server_name"`
+	Host         string       `json:"host"`
+	ScannedAt    time.Time    `json:"scanned_at"`
+	Status       string       `json:"status"`
+	MissingCount int          `json:"missing_count"`
+	StaleCount   int          `json:"stale_count"`
+	ForeignCount int          `json:"foreign_count"`
+	Entries      []DriftEntry `json:"entries,omitempty"`
+	Error        string       `json:"error,omitempty"`
+}
+
+// DriftScanRequest는 드리프트 검사 요청입니다.
+type DriftScanRequest struct {
+	ServerIDs []uint `json:"server_ids,omitempty"` // 검사할 서버 (비어있으면 사용 중인 모든 서버)
+}
+
+// DriftScanSummary는 드리프트 검사 실행 결과 요약입니다.
+type DriftScanSummary struct {
+	ScannedAt time.Time                   `json:"scanned_at"`
+	Total     int                         `json:"total"`
+	Clean     int                         `json:"clean"`
+	Drift     int                         `json:"drift"`
+	Failed    int                         `json:"failed"`
+	Reports   []ServerDriftReportResponse `json:"reports"`
+}
+
+// ToServerDriftReportResponse는 드리프트 검사 결과 모델을 응답으로 변환합니다.
+// 서버 이름과 호스트는 Server가 함께 로드된 경우에만 채워집니다.
+func ToServerDriftReportResponse(report models.ServerDriftReport) ServerDriftReportResponse {
+	response := ServerDriftReportResponse{
+		ID:           report.ID,
+		ServerID:     report.ServerID,
+		ServerName:   report.Server.Name,
+		Host:         report.Server.Host,
+		ScannedAt:    report.ScannedAt,
+		Status:       report.Status,
+		MissingCount: report.MissingCount,
+		StaleCount:   report.StaleCount,
+		ForeignCount: report.ForeignCount,
+		Error:        report.ErrorMsg,
+	}
+	if report.Details != "" {
+		json.Unmarshal([]byte(report.Details), &response.Entries)
+	}
+	return response
+}
+
+// === pull 방식 (sshd AuthorizedKeysCommand) ===
+
+// PullHostRegisterRequest는 pull 방식 호스트 등록 요청입니다.
+// 이미 등록된 호스트이면 토큰을 새로 발급합니다 (기존 토큰은 즉시 사용 불가).
+type PullHostRegisterRequest struct {
+	Host        string `json:"host" binding:"required"` // 서버 호스트 (등록된 서버의 host와 같은 값)
+	Description string `json:"description,omitempty"`
+}
+
+// PullHostResponse는 pull 방식 호스트 정보 응답입니다.
+type PullHostResponse struct {
+	ID           uint       `json:"id"`
+	Host         string     `json:"host"`
+	Description  string     `json:"description,omitempty"`
+	TokenPrefix  string     `json:"token_prefix"`
+	CreatedBy    uint       `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastPulledAt *time.Time `json:"last_pulled_at,omitempty"`
+	LastPullAddr string     `json:"last_pull_addr,omitempty"`
+}
+
+// PullHostTokenResponse는 호스트 등록 결과입니다. 토큰은 이 응답에서만 확인할 수 있습니다.
+type PullHostTokenResponse struct {
+	PullHostResponse
+	Token string `json:"token"`
+	Usage string `json:"usage"`
+}
+
+// PulledAuthorizedKeys는 호스트가 가져갈 서명된 authorized_keys 내용입니다.
+type PulledAuthorizedKeys struct {
+	Host      string
+	Port      int // 요청한 포트 (0이면 모든 포트)
+	User      string
+	Content   string
+	KeyCount  int
+	IssuedAt  int64  // 발급 시각 (유닉스 초)
+	Signature string // 사용자 CA 서명 (base64)
+}
+
+// ToPullHostResponse는 pull 방식 호스트 모델을 응답으로 변환합니다.
+func ToPullHostResponse(host models.PullHost) PullHostResponse {
+	return PullHostResponse{
+		ID:           host.ID,
+		Host:         host.Host,
+		Description:  host.Description,
+		TokenPrefix:  host.TokenPrefix,
+		CreatedBy:    host.CreatedBy,
+		CreatedAt:    host.CreatedAt,
+		LastPulledAt: host.LastPulledAt,
+		LastPullAddr: host.LastPullAddr,
+	}
+}
+
+// ToDeploymentJobProgress는 비동기 배포 작업 모델을 작업 진행 상황 응답으로 변환합니다.
+func ToDeploymentJobProgress(job models.DeploymentJob) ProgressResponse {
+	var request struct {
+		ServerIDs []uint `json:"server_ids"`
+	}
+	json.Unmarshal([]byte(job.Request), &request)
+
+	result := DeploymentJobResult{
+		JobType: job.Type,
+		Results: []DeploymentResult{},
+		Summary: DeploymentSummary{Total: job.Total, Success: job.Succeeded, Failed: job.Failed},
+	}
+	if job.Results != "" {
+		json.Unmarshal([]byte(job.Results), &result.Results)
+	}
+	processed := make(map[uint]bool, len(result.Results))
+	for _, r := range result.Results {
+		processed[r.ServerID] = true
+	}
+	for _, serverID := range request.ServerIDs {
+		if !processed[serverID] {
+			result.PendingServerIDs = append(result.PendingServerIDs, serverID)
+		}
+	}
+
+	progress := ProgressResponse{
+		TaskID:  fmt.Sprintf("%d", job.ID),
+		Status:  job.Status,
+		Message: fmt.Sprintf("서버 %d/%d대 처리 (성공 %d, 실패 %d)", job.Done, job.Total, job.Succeeded, job.Failed),
+		Result:  result,
+		Error:   job.ErrorMsg,
+	}
+	if job.Total > 0 {
+		progress.Progress = float64(job.Done) / float64(job.Total)
+	}
+	if job.StartedAt != nil {
+		progress.StartedAt = job.StartedAt.Format(time.RFC3339)
+	}
+	if job.CompletedAt != nil {
+		progress.CompletedAt = job.CompletedAt.Format(time.RFC3339)
+	}
+	if job.Status == models.JobStatusCancelled {
+		progress.Message += " - 취소됨"
 	}
+	return progress
 }
@@ -9,22 +9,42 @@ import (
 
 // SSHKeyResponse는 API 응답용 SSH 키 정보입니다.
 type SSHKeyResponse struct {
-	ID          uint      `json:"id"`
-	Algorithm   string    `json:"algorithm"`
-	Bits        int       `json:"bits"`
-	PublicKey   string    `json:"public_key"`
-	PEM         string    `json:"pem"`
-	PPK         string    `json:"ppk"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Fingerprint string    `json:"fingerprint,omitempty"`
+	ID                  uint       `json:"id"`
+	Name                string     `json:"name"`
+	Comment             string     `json:"comment"`
+	Status              string     `json:"status"`
+	Algorithm           string     `json:"algorithm"`
+	Bits                int        `json:"bits"`
+	PublicKey           string     `json:"public_key"`
+	PEM                 string     `json:"pem,omitempty"`
+	PPK                 string     `json:"ppk,omitempty"`
+	PassphraseProtected bool       `json:"passphrase_protected"`
+	HasPrivateKey       bool       `json:"has_private_key"`
+	Source              string     `json:"source"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	Fingerprint         string     `json:"fingerprint,omitempty"` // SHA256 핑거프린트 (이전 버전 호환)
+	FingerprintSHA256   string     `json:"fingerprint_sha256,omitempty"`
+	FingerprintMD5      string     `json:"fingerprint_md5,omitempty"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+	ReplacedByID        *uint      `json:"replaced_by_id,omitempty"` // 교체로 생성된 새 키 ID
 }
 
 // SSHKeyCreateRequest는 SSH 키 생성 요청 구조체입니다.
 type SSHKeyCreateRequest struct {
-	Algorithm string `json:"algorithm,omitempty"` // RSA, ECDSA 등
-	Bits      int    `json:"bits,omitempty"`      // 키 크기
-	Comment   string `json:"comment,omitempty"`   // 키 코멘트
+	Name          string `json:"name,omitempty"`            // 키 이름 (비어있으면 자동 생성)
+	Algorithm     string `json:"algorithm,omitempty"`       // RSA, ECDSA 등
+	Bits          int    `json:"bits,omitempty"`            // 키 크기
+	Comment       string `json:"comment,omitempty"`         // 키 코멘트
+	Passphrase    string `json:"passphrase,omitempty"`      // 개인키 암호화 패스프레이즈 (선택사항)
+	ForceReplace  bool   `json:"force_replace,omitempty"`   // 같은 이름의 키 교체
+	ExpiresInDays int    `json:"expires_in_days,omitempty"` // 만료 기간 (일, 비어있으면 부서/조직 최대 기간 적용)
+}
+
+// SSHKeyUpdateRequest는 SSH 키 정보 수정 요청 구조체입니다.
+type SSHKeyUpdateRequest struct {
+	Name   string `json:"name,omitempty"`   // 새 키 이름
+	Status string `json:"status,omitempty"` // active, revoked
 }
 
 // SSHKeyListRequest는 SSH 키 목록 요청 구조체입니다.
@@ -40,17 +60,21 @@ type SSHKeyListRequest struct {
 
 // SSHKeyGenerationOptions는 키 생성 옵션 구조체입니다.
 type SSHKeyGenerationOptions struct {
-	Algorithm    string `json:"algorithm"`     // 알고리즘 (RSA, ECDSA, Ed25519)
-	Bits         int    `json:"bits"`          // 키 크기
-	Comment      string `json:"comment"`       // 키 코멘트
-	Passphrase   string `json:"passphrase"`    // 개인키 암호화 (선택사항)
-	ForceReplace bool   `json:"force_replace"` // 기존 키 강제 교체
+	Name          string `json:"name"`            // 키 이름
+	Algorithm     string `json:"algorithm"`       // 알고리즘 (RSA, ECDSA, Ed25519)
+	Bits          int    `json:"bits"`            // 키 크기
+	Comment       string `json:"comment"`         // 키 코멘트
+	Passphrase    string `json:"passphrase"`      // 개인키 암호화 (선택사항)
+	ForceReplace  bool   `json:"force_replace"`   // 기존 키 강제 교체
+	ExpiresInDays int    `json:"expires_in_days"` // 만료 기간 (일, 0이면 정책 최대 기간)
 }
 
 // SSHKeyExportRequest는 키 내보내기 요청 구조체입니다.
 type SSHKeyExportRequest struct {
-	Format   string `json:"format" binding:"required"` // PEM, PPK, OpenSSH
-	Password string `json:"password,omitempty"`        // 암호화 비밀번호 (선택사항)
+	Format            string `json:"format" binding:"required"`    // openssh, pem, pkcs8, ppk-v2, ppk-v3, public, rfc4716
+	Password          string `json:"password,omitempty"`           // 암호화 비밀번호 (선택사항)
+	CurrentPassphrase string `json:"current_passphrase,omitempty"` // 패스프레이즈 보호 키의 현재 패스프레이즈
+	Download          bool   `json:"download,omitempty"`           // true면 JSON 대신 파일 첨부로 응답
 }
 
 // SSHKeyExportResponse는 키 내보내기 응답 구조체입니다.
@@ -64,11 +88,47 @@ type SSHKeyExportResponse struct {
 
 // SSHKeyImportRequest는 키 가져오기 요청 구조체입니다.
 type SSHKeyImportRequest struct {
-	Content   string `json:"content" binding:"required"` // 키 내용
-	Format    string `json:"format" binding:"required"`  // 키 형식
-	Password  string `json:"password,omitempty"`         // 복호화 비밀번호
-	Comment   string `json:"comment,omitempty"`          // 새 코멘트
-	Overwrite bool   `json:"overwrite"`                  // 기존 키 덮어쓰기
+	Name          string `json:"name,omitempty"`             // 키 이름 (비어있으면 자동 생성)
+	Content       string `json:"content" binding:"required"` // 키 내용
+	Format        string `json:"format,omitempty"`           // 키 형식 (auto, openssh, pem, pkcs8, ppk, public, rfc4716, 비어있으면 자동 감지)
+	Password      string `json:"password,omitempty"`         // 복호화 비밀번호
+	Comment       string `json:"comment,omitempty"`          // 새 코멘트
+	Overwrite     bool   `json:"overwrite"`                  // 같은 이름 또는 같은 공개키의 기존 키 덮어쓰기
+	ExpiresInDays int    `json:"expires_in_days,omitempty"`  // 만료 기간 (일, 비어있으면 부서/조직 최대 기간 적용)
+}
+
+// KeyFingerprintSearchRequest는 핑거프린트 검색 요청 구조체입니다.
+// query에는 핑거프린트 또는 sshd 로그 줄("Accepted publickey for ... SHA256:...")을 그대로 넣을 수 있습니다.
+type KeyFingerprintSearchRequest struct {
+	Query string `json:"query" query:"q" binding:"required"`
+}
+
+// KeyFingerprintMatch는 핑거프린트와 일치하는 키와 소유자 정보입니다.
+type KeyFingerprintMatch struct {
+	Fingerprint       string     `json:"fingerprint"` // 검색에 사용된 핑거프린트
+	KeyID             uint       `json:"key_id"`
+	KeyName           string     `json:"key_name"`
+	Status            string     `json:"status"`
+	Algorithm         string     `json:"algorithm"`
+	FingerprintSHA256 string     `json:"fingerprint_sha256"`
+	FingerprintMD5    string     `json:"fingerprint_md5"`
+	UserID            uint       `json:"user_id"`
+	Username          string     `json:"username"`
+	CreatedAt         time.Time  `json:"created_at"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty"` // 삭제된 키도 감사 목적상 검색됨
+}
+
+// KeyFingerprintSearchResponse는 핑거프린트 검색 결과입니다.
+type KeyFingerprintSearchResponse struct {
+	Fingerprints []string              `json:"fingerprints"` // 입력에서 추출한 핑거프린트
+	Matches      []KeyFingerprintMatch `json:"matches"`
+	Unmatched    []string              `json:"unmatched,omitempty"` // 등록된 키가 없는 핑거프린트
+}
+
+// SSHKeyValidateRequest는 키 검증 요청 구조체입니다.
+type SSHKeyValidateRequest struct {
+	Content  string `json:"content" binding:"required"` // 공개키 또는 개인키 내용 (형식 자동 감지)
+	Password string `json:"password,omitempty"`         // 암호화된 개인키의 패스프레이즈 (선택사항)
 }
 
 // SSHKeyValidationResult는 키 검증 결과입니다.
@@ -100,6 +160,57 @@ type SSHKeySecurityCheck struct {
 	Recommendations []string `json:"recommendations"`
 	Issues          []string `json:"issues"`
 	Score           int      `json:"score"` // 0-100 점수
+
+	// 평가에 사용된 키 정보
+	KeyName         string `json:"key_name"`
+	UserID          uint   `json:"user_id"`
+	Username        string `json:"username,omitempty"`
+	Algorithm       string `json:"algorithm"`
+	Bits            int    `json:"bits"`
+	Status          string `json:"status"`
+	AgeDays         int    `json:"age_days"`
+	DeployedServers int    `json:"deployed_servers"` // 배포에 성공한 서버 수
+	PrivateKeyHeld  bool   `json:"private_key_held"` // 서버에 개인키가 보관되어 있는지 여부
+}
+
+// SSHKeySecurityReport는 전체 키 보안 평가 보고서입니다 (관리자용).
+type SSHKeySecurityReport struct {
+	GeneratedAt  time.Time             `json:"generated_at"`
+	TotalKeys    int                   `json:"total_keys"`
+	AverageScore float64               `json:"average_score"`
+	LevelCounts  map[string]int        `json:"level_counts"` // HIGH, MEDIUM, LOW별 키 수
+	Checks       []SSHKeySecurityCheck `json:"checks"`       // 점수 낮은 순
+}
+
+// KeyRotationServerResult는 키 교체 중 서버 하나의 처리 결과입니다.
+type KeyRotationServerResult struct {
+	ServerID     uint   `json:"server_id"`
+	ServerName   string `json:"server_name"`
+	DeployStatus string `json:"deploy_status"`           // 새 키 배포 결과 (success, failed)
+	RemoveStatus string `json:"remove_status,omitempty"` // 기존 키 제거 결과 (success, failed, skipped)
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// KeyRotationResult는 키 교체 결과입니다.
+type KeyRotationResult struct {
+	RotationID uint                      `json:"rotation_id"`
+	OldKeyID   uint                      `json:"old_key_id"`
+	NewKeyID   *uint                     `json:"new_key_id,omitempty"`
+	Reason     string                    `json:"reason"`
+	Status     string                    `json:"status"`               // completed, partial, failed, revoked
+	ExpiresAt  *time.Time                `json:"expires_at,omitempty"` // 새 키 만료 시각
+	Servers    []KeyRotationServerResult `json:"servers"`
+	Warnings   []string                  `json:"warnings,omitempty"`
+}
+
+// KeyExpiryCheckSummary는 만료 검사 1회 실행 결과입니다.
+type KeyExpiryCheckSummary struct {
+	CheckedAt        time.Time `json:"checked_at"`
+	Warned           int       `json:"warned"`            // 만료 예정 알림을 보낸 키 수
+	Rotated          int       `json:"rotated"`           // 새 키로 교체된 키 수
+	Revoked          int       `json:"revoked"`           // 교체 없이 폐기된 키 수
+	Failed           int       `json:"failed"`            // 처리에 실패한 키 수
+	PendingRotations int       `json:"pending_rotations"` // 재시도 후에도 기존 키가 남은 서버가 있는 교체 수
 }
 
 // === 변환 헬퍼 함수들 ===
@@ -107,14 +218,32 @@ type SSHKeySecurityCheck struct {
 // ToSSHKeyResponse는 모델을 SSHKeyResponse로 변환합니다.
 func ToSSHKeyResponse(sshKey models.SSHKey, fingerprint string) SSHKeyResponse {
 	return SSHKeyResponse{
-		ID:          sshKey.ID,
-		Algorithm:   sshKey.Algorithm,
-		Bits:        sshKey.Bits,
-		PublicKey:   sshKey.PublicKey,
-		PEM:         sshKey.PEM,
-		PPK:         sshKey.PPK,
-		CreatedAt:   sshKey.CreatedAt,
-		UpdatedAt:   sshKey.UpdatedAt,
-		Fingerprint: fingerprint,
+		ID:                  sshKey.ID,
+		Name:                sshKey.Name,
+		Comment:             sshKey.Comment,
+		Status:              sshKey.Status,
+		Algorithm:           sshKey.Algorithm,
+		Bits:                sshKey.Bits,
+		PublicKey:           sshKey.PublicKey,
+		PEM:                 sshKey.PEM,
+		PPK:                 sshKey.PPK,
+		PassphraseProtected: sshKey.PassphraseProtected,
+		HasPrivateKey:       sshKey.PrivateKey != "",
+		Source:              sshKey.Source,
+		CreatedAt:           sshKey.CreatedAt,
+		UpdatedAt:           sshKey.UpdatedAt,
+		Fingerprint:         fingerprint,
+		FingerprintSHA256:   sshKey.FingerprintSHA256,
+		FingerprintMD5:      sshKey.FingerprintMD5,
+		ExpiresAt:           sshKey.ExpiresAt,
+		ReplacedByID:        sshKey.ReplacedByID,
 	}
 }
+
+// ToSSHKeySummary는 개인키 정보(PEM, PPK)를 제외한 목록용 응답으로 변환합니다.
+func ToSSHKeySummary(sshKey models.SSHKey, fingerprint string) SSHKeyResponse {
+	response := ToSSHKeyResponse(sshKey, fingerprint)
+	response.PEM = ""
+	response.PPK = ""
+	return response
+}
@@ -48,7 +48,9 @@ type UserDetailWithKey struct {
 	UpdatedAt  time.Time         `json:"updated_at"`
 	HasSSHKey  bool              `json:"has_ssh_key"`
 	Department *DepartmentSimple `json:"department,omitempty"`
-	SSHKey     *SSHKeyResponse   `json:"ssh_key,omitempty"`
+	SSHKey     *SSHKeyResponse   `json:"ssh_key,omitempty"`  // 가장 최근에 생성된 키
+	SSHKeys    []SSHKeyResponse  `json:"ssh_keys,omitempty"` // 보유한 전체 키 목록 (개인키 제외)
+	KeyCount   int               `json:"key_count"`
 }
 
 // UserResponse는 API용 사용자 정보 응답 구조체입니다.
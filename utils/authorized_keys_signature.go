@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// pull 방식 authorized_keys 응답 헤더입니다.
+const (
+	PulledKeysIssuedAtHeader  = "X-Authorized-Keys-Issued-At" // 응답 발급 시각 (유닉스 초)
+	PulledKeysSignatureHeader = "X-Authorized-Keys-Signature" // 사용자 CA 서명 (base64, SSH 서명 형식)
+)
+
+// pulledKeysSignaturePrefix는 서명 대상 앞에 붙여 다른 용도의 CA 서명과 구분합니다.
+const pulledKeysSignaturePrefix = "ssh-key-manager-authorized-keys-v2"
+
+// pulledKeysMessage는 서명 대상 메시지를 만듭니다.
+// 호스트, 포트, 계정을 함께 서명하므로 다른 서버나 포트, 계정의 응답을 재사용할 수 없습니다.
+// port가 0이면 호스트의 모든 포트에 대한 응답입니다.
+func pulledKeysMessage(host string, port int, user string, issuedAt int64, content string) []byte {
+	return []byte(pulledKeysSignaturePrefix + "\n" + host + "\n" + strconv.Itoa(port) + "\n" + user + "\n" +
+		strconv.FormatInt(issuedAt, 10) + "\n" + content)
+}
+
+// SignPulledAuthorizedKeys는 pull 방식 authorized_keys 응답에 사용자 CA 키로 서명합니다.
+func SignPulledAuthorizedKeys(caKey crypto.Signer, host string, port int, user string, issuedAt int64, content string) (string, error) {
+	signer, err := ssh.NewSignerFromSigner(caKey)
+	if err != nil {
+		return "", fmt.Errorf("CA 서명 키 변환 실패: %v", err)
+	}
+
+	signature, err := signer.Sign(rand.Reader, pulledKeysMessage(host, port, user, issuedAt, content))
+	if err != nil {
+		return "", fmt.Errorf("authorized_keys 응답 서명 실패: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(ssh.Marshal(signature)), nil
+}
+
+// VerifyPulledAuthorizedKeys는 pull 방식 authorized_keys 응답의 서명을 검증합니다.
+func VerifyPulledAuthorizedKeys(publicKey ssh.PublicKey, host string, port int, user string, issuedAt int64, content, encodedSignature string) error {
+	raw, err := base64.StdEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return errors.New("서명 형식이 올바르지 않습니다")
+	}
+
+	var signature ssh.Signature
+	if err := ssh.Unmarshal(raw, &signature); err != nil {
+		return errors.New("서명 형식이 올바르지 않습니다")
+	}
+
+	if err := publicKey.Verify(pulledKeysMessage(host, port, user, issuedAt, content), &signature); err != nil {
+		return errors.New("서명이 올바르지 않습니다")
+	}
+	return nil
+}
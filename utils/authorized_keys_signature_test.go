@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestVerifyPulledAuthorizedKeys(t *testing.T) {
+	caKey := testSigners(t)["ed25519"]
+	caPublicKey, err := ssh.NewPublicKey(caKey.Public())
+	if err != nil {
+		t.Fatalf("CA 공개키 변환 실패: %v", err)
+	}
+
+	content := testAuthorizedKey(t, 1, "alice@laptop") + "\n"
+	signature, err := SignPulledAuthorizedKeys(caKey, "web01", 22, "deploy", 1700000000, content)
+	if err != nil {
+		t.Fatalf("SignPulledAuthorizedKeys 실패: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		host      string
+		port      int
+		user      string
+		issuedAt  int64
+		content   string
+		signature string
+		wantErr   bool
+	}{
+		{"같은 대상", "web01", 22, "deploy", 1700000000, content, signature, false},
+		{"다른 포트", "web01", 2222, "deploy", 1700000000, content, signature, true},
+		{"모든 포트 응답으로 재사용", "web01", 0, "deploy", 1700000000, content, signature, true},
+		{"다른 호스트", "web02", 22, "deploy", 1700000000, content, signature, true},
+		{"다른 계정", "web01", 22, "root", 1700000000, content, signature, true},
+		{"다른 발급 시각", "web01", 22, "deploy", 1700000001, content, signature, true},
+		{"내용 변조", "web01", 22, "deploy", 1700000000, content + testAuthorizedKey(t, 2, "mallory") + "\n", signature, true},
+		{"서명 형식 오류", "web01", 22, "deploy", 1700000000, content, "!!!", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyPulledAuthorizedKeys(caPublicKey, tt.host, tt.port, tt.user, tt.issuedAt, tt.content, tt.signature)
+			if tt.wantErr && err == nil {
+				t.Fatalf("서명 검증에 실패해야 합니다")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("VerifyPulledAuthorizedKeys 실패: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxDeployRetryBackoff는 재시도 대기 시간의 상한입니다.
+const maxDeployRetryBackoff = 30 * time.Second
+
+// DeployEngineOptions는 여러 서버 배포 실행 설정입니다.
+type DeployEngineOptions struct {
+	MaxParallel  int           // 동시에 작업할 서버 수
+	MaxRetries   int           // 네트워크 오류 시 서버별 재시도 횟수 (0이면 재시도 없음)
+	RetryBackoff time.Duration // 첫 재시도 대기 시간 (재시도마다 두 배, 최대 30초)
+}
+
+var (
+	deployEngineMu      sync.RWMutex
+	deployEngineOptions = DeployEngineOptions{
+		MaxParallel:  10,
+		MaxRetries:   2,
+		RetryBackoff: 2 * time.Second,
+	}
+)
+
+// ConfigureDeployEngine은 배포 실행 설정을 적용합니다. 0 이하인 값은 기존 설정을 유지합니다 (MaxRetries는 0 허용).
+func ConfigureDeployEngine(opts DeployEngineOptions) {
+	deployEngineMu.Lock()
+	defer deployEngineMu.Unlock()
+	if opts.MaxParallel > 0 {
+		deployEngineOptions.MaxParallel = opts.MaxParallel
+	}
+	if opts.MaxRetries >= 0 {
+		deployEngineOptions.MaxRetries = opts.MaxRetries
+	}
+	if opts.RetryBackoff > 0 {
+		deployEngineOptions.RetryBackoff = opts.RetryBackoff
+	}
+}
+
+func currentDeployEngineOptions() DeployEngineOptions {
+	deployEngineMu.RLock()
+	defer deployEngineMu.RUnlock()
+	return deployEngineOptions
+}
+
+// RunDeployTasks는 count개의 서버 작업을 최대 MaxParallel개씩 동시에 실행합니다.
+// ctx가 취소되거나 기한이 지나면 아직 시작하지 않은 작업은 실행하지 않고, 그 index 목록과 중단 사유(ctx 오류)를 반환합니다.
+// 이미 시작한 작업은 ctx로 중단을 전달받으며, 모든 작업이 끝난 뒤 반환합니다.
+func RunDeployTasks(ctx context.Context, count int, run func(ctx context.Context, index int)) ([]int, error) {
+	parallel := currentDeployEngineOptions().MaxParallel
+	if parallel > count {
+		parallel = count
+	}
+
+	slots := make(chan struct{}, max(parallel, 1))
+	var wg sync.WaitGroup
+	var skipped []int
+
+	for i := 0; i < count; i++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			for j := i; j < count; j++ {
+				skipped = append(skipped, j)
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(index int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			run(ctx, index)
+		}(i)
+	}
+
+	wg.Wait()
+	if len(skipped) > 0 {
+		return skipped, ctx.Err()
+	}
+	return nil, nil
+}
+
+// RetryDeploy는 서버 한 대의 작업 fn을 실행하고, 일시적인 네트워크 오류이면 지수 백오프 후 최대 MaxRetries번 다시 실행합니다.
+// 인증 실패, 호스트 키 불일치, 권한 부족, 원격 명령 실패처럼 다시 해도 같은 오류는 재시도하지 않습니다.
+// onRetry는 다음 시도 번호(2부터)와 직전 오류, 대기 시간을 받아 재시도 전에 호출됩니다 (nil 가능).
+func RetryDeploy(ctx context.Context, fn func(ctx context.Context) error, onRetry func(attempt int, err error, delay time.Duration)) error {
+	opts := currentDeployEngineOptions()
+	delay := opts.RetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt > opts.MaxRetries || ctx.Err() != nil || !IsRetryableDeployError(err) {
+			return err
+		}
+
+		// 같은 서버들에 동시에 다시 접속하지 않도록 대기 시간을 조금씩 다르게 함
+		wait := delay + rand.N(delay/4+1)
+		if onRetry != nil {
+			onRetry(attempt+1, err, wait)
+		}
+		log.Printf("🔁 일시적 오류로 %s 후 재시도 (%d/%d): %v", wait.Round(time.Millisecond), attempt, opts.MaxRetries, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		delay = min(delay*2, maxDeployRetryBackoff)
+	}
+}
+
+// IsRetryableDeployError는 다시 시도하면 성공할 수 있는 네트워크 오류인지 확인합니다.
+func IsRetryableDeployError(err error) bool {
+	var sshErr *SSHError
+	if errors.As(err, &sshErr) {
+		return sshErr.Kind == SSHErrorUnreachable || sshErr.Kind == SSHErrorTimeout
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
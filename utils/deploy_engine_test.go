@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// withDeployEngineOptions는 테스트 동안 배포 실행 설정을 바꾸고, 끝나면 원래 설정으로 되돌립니다.
+func withDeployEngineOptions(t *testing.T, opts DeployEngineOptions) {
+	t.Helper()
+	saved := currentDeployEngineOptions()
+	ConfigureDeployEngine(opts)
+	t.Cleanup(func() { ConfigureDeployEngine(saved) })
+}
+
+func TestIsRetryableDeployError(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"연결 불가", &SSHError{Kind: SSHErrorUnreachable, Target: "deploy@host:22", Err: dialErr}, true},
+		{"시간 초과", &SSHError{Kind: SSHErrorTimeout, Target: "deploy@host:22"}, true},
+		{"감싼 연결 불가", fmt.Errorf("배포 실패: %w", &SSHError{Kind: SSHErrorUnreachable}), true},
+		{"네트워크 오류", dialErr, true},
+		{"인증 실패", &SSHError{Kind: SSHErrorAuthFailed, Target: "deploy@host:22", Err: dialErr}, false},
+		{"감싼 인증 실패", fmt.Errorf("배포 실패: %w", &SSHError{Kind: SSHErrorAuthFailed}), false},
+		{"호스트 키 불일치", &SSHError{Kind: SSHErrorHostKeyMismatch}, false},
+		{"권한 부족", &SSHError{Kind: SSHErrorPermissionDenied}, false},
+		{"원격 명령 실패", &SSHError{Kind: SSHErrorCommandFailed}, false},
+		{"취소", &SSHError{Kind: SSHErrorCanceled}, false},
+		{"일반 오류", errors.New("authorized_keys 업데이트 실패"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableDeployError(tt.err); got != tt.want {
+				t.Errorf("IsRetryableDeployError(%v) = %t, 기대값 %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDeploy(t *testing.T) {
+	unreachable := &SSHError{Kind: SSHErrorUnreachable, Target: "deploy@host:22"}
+	authFailed := &SSHError{Kind: SSHErrorAuthFailed, Target: "deploy@host:22"}
+
+	tests := []struct {
+		name         string
+		maxRetries   int
+		errs         []error // 시도별 결과 (부족하면 마지막 값 반복)
+		wantAttempts int
+		wantErr      error
+	}{
+		{"첫 시도 성공", 2, []error{nil}, 1, nil},
+		{"재시도 후 성공", 2, []error{unreachable, nil}, 2, nil},
+		{"재시도 횟수 초과", 2, []error{unreachable}, 3, unreachable},
+		{"재시도 없음 설정", 0, []error{unreachable}, 1, unreachable},
+		{"인증 실패는 재시도하지 않음", 2, []error{authFailed}, 1, authFailed},
+		{"재시도 중 인증 실패", 3, []error{unreachable, authFailed}, 2, authFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDeployEngineOptions(t, DeployEngineOptions{MaxRetries: tt.maxRetries, RetryBackoff: time.Millisecond})
+
+			attempts := 0
+			var retried []int
+			err := RetryDeploy(context.Background(), func(ctx context.Context) error {
+				err := tt.errs[min(attempts, len(tt.errs)-1)]
+				attempts++
+				return err
+			}, func(attempt int, err error, delay time.Duration) {
+				retried = append(retried, attempt)
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("오류 = %v, 기대값 %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("시도 횟수 = %d, 기대값 %d", attempts, tt.wantAttempts)
+			}
+			for i, attempt := range retried {
+				if attempt != i+2 {
+					t.Errorf("onRetry 시도 번호 = %v, 2부터 순서대로 기대", retried)
+					break
+				}
+			}
+			if len(retried) != tt.wantAttempts-1 {
+				t.Errorf("onRetry 호출 횟수 = %d, 기대값 %d", len(retried), tt.wantAttempts-1)
+			}
+		})
+	}
+}
+
+func TestRetryDeployStopsWhenCanceled(t *testing.T) {
+	withDeployEngineOptions(t, DeployEngineOptions{MaxRetries: 5, RetryBackoff: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unreachable := &SSHError{Kind: SSHErrorUnreachable}
+	attempts := 0
+
+	err := RetryDeploy(ctx, func(ctx context.Context) error {
+		attempts++
+		return unreachable
+	}, func(attempt int, err error, delay time.Duration) {
+		cancel() // 대기 중 취소
+	})
+
+	if !errors.Is(err, unreachable) || attempts != 1 {
+		t.Errorf("취소 후 재시도하지 않아야 합니다 (오류: %v, 시도: %d)", err, attempts)
+	}
+}
+
+func TestRunDeployTasks(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxParallel int
+		count       int
+	}{
+		{"동시 실행 제한", 3, 10},
+		{"작업 수보다 큰 제한", 10, 4},
+		{"순차 실행", 1, 5},
+		{"작업 없음", 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDeployEngineOptions(t, DeployEngineOptions{MaxParallel: tt.maxParallel, MaxRetries: 0})
+
+			var running, peak, done atomic.Int32
+			skipped, err := RunDeployTasks(context.Background(), tt.count, func(ctx context.Context, index int) {
+				current := running.Add(1)
+				for {
+					old := peak.Load()
+					if current <= old || peak.CompareAndSwap(old, current) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				running.Add(-1)
+				done.Add(1)
+			})
+
+			if err != nil || len(skipped) != 0 {
+				t.Fatalf("모든 작업이 실행되어야 합니다 (건너뜀: %v, 오류: %v)", skipped, err)
+			}
+			if int(done.Load()) != tt.count {
+				t.Errorf("실행한 작업 수 = %d, 기대값 %d", done.Load(), tt.count)
+			}
+			if int(peak.Load()) > tt.maxParallel {
+				t.Errorf("동시 실행 수 = %d, 최대 %d", peak.Load(), tt.maxParallel)
+			}
+		})
+	}
+}
+
+func TestRunDeployTasksSkipsAfterCancel(t *testing.T) {
+	withDeployEngineOptions(t, DeployEngineOptions{MaxParallel: 1, MaxRetries: 0})
+
+	tests := []struct {
+		name        string
+		cancelAt    int // 이 index 작업 실행 중 취소 (-1이면 시작 전 취소)
+		count       int
+		wantSkipped []int
+	}{
+		{"시작 전 취소", -1, 3, []int{0, 1, 2}},
+		{"첫 작업 중 취소", 0, 4, []int{1, 2, 3}},
+		{"중간 작업 중 취소", 2, 5, []int{3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelAt < 0 {
+				cancel()
+			}
+
+			var ran atomic.Int32
+			skipped, err := RunDeployTasks(ctx, tt.count, func(ctx context.Context, index int) {
+				ran.Add(1)
+				if index == tt.cancelAt {
+					cancel()
+				}
+			})
+
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("오류 = %v, context.Canceled 기대", err)
+			}
+			if fmt.Sprint(skipped) != fmt.Sprint(tt.wantSkipped) {
+				t.Errorf("건너뛴 작업 = %v, 기대값 %v", skipped, tt.wantSkipped)
+			}
+			if int(ran.Load())+len(skipped) != tt.count {
+				t.Errorf("실행(%d) + 건너뜀(%d) != 전체(%d)", ran.Load(), len(skipped), tt.count)
+			}
+		})
+	}
+}
@@ -0,0 +1,481 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"ssh-key-manager/models"
+	"strings"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Deployer는 한 배포 대상의 authorized_keys를 관리합니다.
+// 서버별 배포 방식(models.Server.DeployMethod)에 맞는 구현을 NewDeployer로 선택합니다.
+type Deployer interface {
+	// Install은 공개키를 authorized_keys에 추가합니다. 같은 키(알고리즘 + 키 데이터)가 이미 있으면 추가하지 않습니다.
+	Install(ctx context.Context, publicKey string) error
+	// Remove는 공개키와 같은 키 줄을 authorized_keys에서 제거합니다. 없으면 아무것도 하지 않습니다.
+	Remove(ctx context.Context, publicKey string) error
+	// List는 authorized_keys에 등록된 키 줄을 반환합니다 (빈 줄과 주석 제외).
+	List(ctx context.Context) ([]string, error)
+	// Verify는 대상에 접근하여 authorized_keys를 수정할 수 있는지 확인합니다.
+	Verify(ctx context.Context) error
+	// Snapshot은 현재 authorized_keys 내용을 반환하고, 가능하면 대상에도 백업 파일을 남깁니다.
+	Snapshot(ctx context.Context) (*AuthorizedKeysBackup, error)
+	// Restore는 authorized_keys 전체를 content로 교체합니다 (롤백용).
+	Restore(ctx context.Context, content string) error
+	// String은 로그에 표시할 대상 설명을 반환합니다.
+	String() string
+}
+
+// DeployTarget은 Deployer를 만들 때 필요한 대상 정보입니다.
+type DeployTarget struct {
+	Method   string    // 배포 방식 (models.DeployMethod*)
+	SSH      SSHTarget // ssh, sftp 방식의 접속 대상
+	HomePath string    // local 방식에서 authorized_keys가 있는 홈 디렉토리
+}
+
+// NewDeployer는 배포 방식에 맞는 Deployer를 만듭니다. 방식이 비어있으면 ssh를 사용합니다.
+func NewDeployer(target DeployTarget) (Deployer, error) {
+	switch target.Method {
+	case models.DeployMethodSSH, "":
+		return &sshDeployer{target: target.SSH}, nil
+	case models.DeployMethodSFTP:
+		return &sftpDeployer{target: target.SSH}, nil
+	case models.DeployMethodLocal:
+		if strings.TrimSpace(target.HomePath) == "" {
+			return nil, errors.New("로컬 배포에 사용할 홈 디렉토리(SSH_HOME_PATH)를 입력해주세요")
+		}
+		return &localDeployer{homePath: target.HomePath}, nil
+	case models.DeployMethodSimulated:
+		return &simulatedDeployer{name: target.SSH.String()}, nil
+	}
+	return nil, fmt.Errorf("지원하지 않는 배포 방식입니다: %s (ssh, sftp, local, simulated)", target.Method)
+}
+
+// === SSH 셸 배포 ===
+
+// sshDeployer는 원격 셸로 접근하는 서버용입니다. authorized_keys 편집은 SFTP로 원자적으로 교체합니다.
+type sshDeployer struct {
+	target SSHTarget
+}
+
+func (d *sshDeployer) Install(ctx context.Context, publicKey string) error {
+	return DeploySSHKeyToRemoteServer(ctx, d.target, publicKey)
+}
+
+func (d *sshDeployer) Remove(ctx context.Context, publicKey string) error {
+	return RemoveSSHKeyFromRemoteServer(ctx, d.target, publicKey)
+}
+
+func (d *sshDeployer) List(ctx context.Context) ([]string, error) {
+	output, err := RunRemoteCommand(ctx, d.target, "cat ~/.ssh/authorized_keys 2>/dev/null || true", nil)
+	if err != nil {
+		return nil, err
+	}
+	return authorizedKeyEntries(strings.Split(output, "\n")), nil
+}
+
+func (d *sshDeployer) Verify(ctx context.Context) error {
+	return ValidateRemoteServerAccess(ctx, d.target)
+}
+
+func (d *sshDeployer) Snapshot(ctx context.Context) (*AuthorizedKeysBackup, error) {
+	return BackupRemoteAuthorizedKeys(ctx, d.target)
+}
+
+func (d *sshDeployer) Restore(ctx context.Context, content string) error {
+	return RestoreRemoteAuthorizedKeys(ctx, d.target, content)
+}
+
+func (d *sshDeployer) String() string {
+	return "ssh://" + d.target.String()
+}
+
+// === SFTP 배포 ===
+
+// sftpDeployer는 SFTP만 사용합니다 (원격 셸이 제한된 서버용).
+type sftpDeployer struct {
+	target SSHTarget
+}
+
+func (d *sftpDeployer) Install(ctx context.Context, publicKey string) error {
+	return DeploySSHKeyToRemoteServer(ctx, d.target, publicKey)
+}
+
+func (d *sftpDeployer) Remove(ctx context.Context, publicKey string) error {
+	return RemoveSSHKeyFromRemoteServer(ctx, d.target, publicKey)
+}
+
+func (d *sftpDeployer) List(ctx context.Context) ([]string, error) {
+	var entries []string
+	err := withSFTPClient(ctx, d.target, func(client *sftp.Client) error {
+		content, err := readSFTPFile(client, remoteAuthorizedKeysPath)
+		if err != nil {
+			return err
+		}
+		entries = authorizedKeyEntries(strings.Split(string(content), "\n"))
+		return nil
+	})
+	return entries, err
+}
+
+func (d *sftpDeployer) Verify(ctx context.Context) error {
+	return withSFTPClient(ctx, d.target, func(client *sftp.Client) error {
+		info, err := client.Stat(remoteSSHDir)
+		if errors.Is(err, os.ErrNotExist) {
+			// 디렉토리가 없으면 첫 배포 때 생성하므로 홈 디렉토리만 확인
+			_, err = client.Stat(".")
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("~/.ssh가 디렉토리가 아닙니다")
+		}
+		return nil
+	})
+}
+
+func (d *sftpDeployer) Snapshot(ctx context.Context) (*AuthorizedKeysBackup, error) {
+	return BackupRemoteAuthorizedKeys(ctx, d.target)
+}
+
+func (d *sftpDeployer) Restore(ctx context.Context, content string) error {
+	return RestoreRemoteAuthorizedKeys(ctx, d.target, content)
+}
+
+func (d *sftpDeployer) String() string {
+	return "sftp://" + d.target.String()
+}
+
+// === 로컬 파일 배포 ===
+
+// localDeployer는 이 서버의 authorized_keys 파일을 직접 수정합니다.
+type localDeployer struct {
+	homePath string
+}
+
+func (d *localDeployer) authorizedKeysPath() string {
+	return filepath.Join(d.homePath, ".ssh", "authorized_keys")
+}
+
+func (d *localDeployer) Install(ctx context.Context, publicKey string) error {
+	if err := ensureSSHDirectory(filepath.Join(d.homePath, ".ssh")); err != nil {
+		return fmt.Errorf("SSH 디렉토리 생성 실패: %v", err)
+	}
+	if err := appendToAuthorizedKeys(d.authorizedKeysPath(), publicKey); err != nil {
+		return fmt.Errorf("authorized_keys 업데이트 실패: %v", err)
+	}
+	return nil
+}
+
+func (d *localDeployer) Remove(ctx context.Context, publicKey string) error {
+	return RemovePublicKeyFromServer(publicKey, "", d.homePath)
+}
+
+func (d *localDeployer) List(ctx context.Context) ([]string, error) {
+	lines, err := readAuthorizedKeysFile(d.authorizedKeysPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("authorized_keys 파일 읽기 실패: %v", err)
+	}
+	return authorizedKeyEntries(lines), nil
+}
+
+func (d *localDeployer) Verify(ctx context.Context) error {
+	info, err := os.Stat(d.homePath)
+	if err != nil {
+		return fmt.Errorf("SSH 홈 디렉토리에 접근할 수 없습니다: %s (%v)", d.homePath, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("SSH 홈 경로가 디렉토리가 아닙니다: %s", d.homePath)
+	}
+
+	// 쓰기 권한 확인 (~/.ssh가 있으면 그 안에, 없으면 홈 디렉토리에 임시 파일 생성)
+	dir := filepath.Join(d.homePath, ".ssh")
+	if _, err := os.Stat(dir); err != nil {
+		dir = d.homePath
+	}
+	probe, err := os.CreateTemp(dir, ".ssh-key-manager-*")
+	if err != nil {
+		return fmt.Errorf("authorized_keys를 수정할 권한이 없습니다: %s (%v)", dir, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+func (d *localDeployer) Snapshot(ctx context.Context) (*AuthorizedKeysBackup, error) {
+	filePath := d.authorizedKeysPath()
+	info, err := os.Stat(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return &AuthorizedKeysBackup{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("authorized_keys 파일 확인 실패: %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("authorized_keys 파일 읽기 실패: %v", err)
+	}
+
+	sshDir := filepath.Dir(filePath)
+	var backupPath string
+	var file *os.File
+	for attempt := 0; ; attempt++ {
+		backupPath = filepath.Join(sshDir, authorizedKeysBackupName(attempt))
+		file, err = os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+		if !errors.Is(err, os.ErrExist) || attempt >= 3 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("백업 파일 생성 실패: %v", err)
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("백업 파일 쓰기 실패: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("백업 파일 쓰기 실패: %v", err)
+	}
+
+	// 오래된 백업 파일 정리
+	if entries, err := os.ReadDir(sshDir); err == nil {
+		var names []string
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), authorizedKeysBackupPrefix) {
+				names = append(names, entry.Name())
+			}
+		}
+		for _, name := range expiredBackupNames(names) {
+			os.Remove(filepath.Join(sshDir, name))
+		}
+	}
+
+	return &AuthorizedKeysBackup{Content: string(content), Path: backupPath}, nil
+}
+
+// Restore는 같은 디렉토리의 임시 파일에 쓰고 원래 권한을 맞춘 뒤 이름을 바꿔 교체합니다.
+func (d *localDeployer) Restore(ctx context.Context, content string) error {
+	filePath := d.authorizedKeysPath()
+	sshDir := filepath.Dir(filePath)
+	if err := ensureSSHDirectory(sshDir); err != nil {
+		return fmt.Errorf("SSH 디렉토리 생성 실패: %v", err)
+	}
+
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(filePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(sshDir, "authorized_keys.tmp-*")
+	if err != nil {
+		return fmt.Errorf("임시 파일 생성 실패: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("임시 파일 쓰기 실패: %v", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("임시 파일 권한 설정 실패: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("임시 파일 쓰기 실패: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("authorized_keys 교체 실패: %v", err)
+	}
+	return nil
+}
+
+func (d *localDeployer) String() string {
+	return "local://" + d.authorizedKeysPath()
+}
+
+// === 시뮬레이션 배포 ===
+
+// 시뮬레이션 대상별 authorized_keys 내용 (프로세스 메모리에만 보관)
+var (
+	simulatedMu             sync.Mutex
+	simulatedAuthorizedKeys = make(map[string][]string)
+)
+
+// simulatedDeployer는 실제 서버에 접속하지 않고 메모리에서 authorized_keys를 흉내 냅니다 (테스트, 모의 실행용).
+type simulatedDeployer struct {
+	name string
+}
+
+func (d *simulatedDeployer) Install(ctx context.Context, publicKey string) error {
+	publicKey = strings.TrimSpace(publicKey)
+	if len(strings.Fields(publicKey)) < 2 {
+		return fmt.Errorf("유효하지 않은 공개키 형식입니다")
+	}
+
+	simulatedMu.Lock()
+	defer simulatedMu.Unlock()
+	if !containsPublicKey(simulatedAuthorizedKeys[d.name], publicKey) {
+		simulatedAuthorizedKeys[d.name] = append(simulatedAuthorizedKeys[d.name], publicKey)
+	}
+	log.Printf("🧪 [시뮬레이션] 키 설치: %s", d)
+	return nil
+}
+
+func (d *simulatedDeployer) Remove(ctx context.Context, publicKey string) error {
+	simulatedMu.Lock()
+	defer simulatedMu.Unlock()
+	simulatedAuthorizedKeys[d.name], _ = removePublicKeyLines(simulatedAuthorizedKeys[d.name], publicKey)
+	log.Printf("🧪 [시뮬레이션] 키 제거: %s", d)
+	return nil
+}
+
+func (d *simulatedDeployer) List(ctx context.Context) ([]string, error) {
+	simulatedMu.Lock()
+	defer simulatedMu.Unlock()
+	return authorizedKeyEntries(simulatedAuthorizedKeys[d.name]), nil
+}
+
+func (d *simulatedDeployer) Verify(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (d *simulatedDeployer) Snapshot(ctx context.Context) (*AuthorizedKeysBackup, error) {
+	simulatedMu.Lock()
+	defer simulatedMu.Unlock()
+	return &AuthorizedKeysBackup{Content: joinAuthorizedKeyLines(simulatedAuthorizedKeys[d.name])}, nil
+}
+
+func (d *simulatedDeployer) Restore(ctx context.Context, content string) error {
+	simulatedMu.Lock()
+	defer simulatedMu.Unlock()
+	if content == "" {
+		delete(simulatedAuthorizedKeys, d.name)
+	} else {
+		simulatedAuthorizedKeys[d.name] = splitAuthorizedKeyLines(content)
+	}
+	log.Printf("🧪 [시뮬레이션] authorized_keys 복원: %s", d)
+	return nil
+}
+
+func (d *simulatedDeployer) String() string {
+	return "simulated://" + d.name
+}
+
+// === authorized_keys 줄 처리 ===
+
+// authorizedKeyEntries는 authorized_keys 줄 중 키 줄만 골라냅니다 (빈 줄, 주석 제외).
+func authorizedKeyEntries(lines []string) []string {
+	var entries []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries
+}
+
+// CountAuthorizedKeys는 authorized_keys 내용의 키 줄 수를 반환합니다 (빈 줄, 주석 제외).
+func CountAuthorizedKeys(content string) int {
+	return len(authorizedKeyEntries(strings.Split(content, "\n")))
+}
+
+// AuthorizedKeyLine은 authorized_keys의 키 줄 하나를 파싱한 결과입니다.
+type AuthorizedKeyLine struct {
+	Line        string   // 원래 줄
+	Algorithm   string   // 키 종류 (ssh-ed25519 등)
+	Fingerprint string   // SHA256 핑거프린트
+	Comment     string   // 키 코멘트
+	Options     []string // 키 앞의 옵션 (from=, command= 등)
+}
+
+// ParseAuthorizedKeyLines는 authorized_keys 키 줄을 파싱합니다 (빈 줄, 주석 제외).
+// 옵션이 붙은 줄도 키를 인식하며, 파싱할 수 없는 줄은 invalid로 따로 반환합니다.
+func ParseAuthorizedKeyLines(lines []string) (keys []AuthorizedKeyLine, invalid []string) {
+	for _, line := range authorizedKeyEntries(lines) {
+		publicKey, comment, options, ok := parseAuthorizedKeyLine(line)
+		if !ok {
+			invalid = append(invalid, line)
+			continue
+		}
+		keys = append(keys, AuthorizedKeyLine{
+			Line:        line,
+			Algorithm:   publicKey.Type(),
+			Fingerprint: ssh.FingerprintSHA256(publicKey),
+			Comment:     comment,
+			Options:     options,
+		})
+	}
+	return keys, invalid
+}
+
+// parseAuthorizedKeyLine은 authorized_keys 한 줄(옵션 포함 가능)을 파싱합니다.
+// sshd와 같이 줄에 적힌 키 종류가 키 데이터의 종류와 다르면 유효하지 않은 줄로 봅니다.
+func parseAuthorizedKeyLine(line string) (ssh.PublicKey, string, []string, bool) {
+	publicKey, comment, options, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return nil, "", nil, false
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(publicKey.Marshal())
+	fields := strings.Fields(line)
+	for i := 1; i < len(fields); i++ {
+		if fields[i] == encoded {
+			return publicKey, comment, options, fields[i-1] == publicKey.Type()
+		}
+	}
+	return nil, "", nil, false
+}
+
+// containsPublicKey는 authorized_keys 줄에 같은 키가 있는지 확인합니다.
+func containsPublicKey(lines []string, publicKey string) bool {
+	for _, line := range authorizedKeyEntries(lines) {
+		if isSamePublicKey(line, publicKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// removePublicKeyLines는 같은 키 줄을 제외한 줄 목록을 반환합니다 (빈 줄과 주석은 유지).
+func removePublicKeyLines(lines []string, publicKey string) ([]string, bool) {
+	var kept []string
+	removed := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") && isSamePublicKey(trimmed, publicKey) {
+			removed = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept, removed
+}
+
+// splitAuthorizedKeyLines는 파일 내용을 줄 목록으로 나눕니다 (마지막 개행 제외).
+func splitAuthorizedKeyLines(content string) []string {
+	return strings.Split(strings.TrimRight(content, "\n"), "\n")
+}
+
+// joinAuthorizedKeyLines는 줄 목록을 개행으로 끝나는 파일 내용으로 합칩니다.
+func joinAuthorizedKeyLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
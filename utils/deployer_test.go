@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testAuthorizedKey는 seed 바이트로 만든 Ed25519 공개키를 authorized_keys 형식으로 반환합니다.
+func testAuthorizedKey(t *testing.T, seed byte, comment string) string {
+	t.Helper()
+	seedBytes := make([]byte, ed25519.SeedSize)
+	for i := range seedBytes {
+		seedBytes[i] = seed
+	}
+	publicKey, err := ssh.NewPublicKey(ed25519.NewKeyFromSeed(seedBytes).Public())
+	if err != nil {
+		t.Fatalf("공개키 생성 실패: %v", err)
+	}
+	return strings.TrimSpace(FormatAuthorizedKey(publicKey, comment))
+}
+
+func TestParseAuthorizedKeyLines(t *testing.T) {
+	keyA := testAuthorizedKey(t, 1, "alice@laptop")
+	keyB := testAuthorizedKey(t, 2, "")
+
+	lines := []string{
+		"# 관리자 키",
+		"",
+		keyA,
+		`from="10.0.0.0/8",no-pty ` + keyB,
+		"ssh-ed25519 not-base64",
+		"   ",
+	}
+
+	keys, invalid := ParseAuthorizedKeyLines(lines)
+	if len(keys) != 2 {
+		t.Fatalf("키 줄 수 = %d, 기대값 2", len(keys))
+	}
+	if len(invalid) != 1 || invalid[0] != "ssh-ed25519 not-base64" {
+		t.Errorf("잘못된 줄 = %q", invalid)
+	}
+
+	tests := []struct {
+		name        string
+		got         AuthorizedKeyLine
+		wantComment string
+		wantOptions string
+	}{
+		{"코멘트 있는 키", keys[0], "alice@laptop", ""},
+		{"옵션 있는 키", keys[1], "", `from="10.0.0.0/8",no-pty`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.Algorithm != ssh.KeyAlgoED25519 {
+				t.Errorf("알고리즘 = %q", tt.got.Algorithm)
+			}
+			if !strings.HasPrefix(tt.got.Fingerprint, "SHA256:") {
+				t.Errorf("핑거프린트 = %q", tt.got.Fingerprint)
+			}
+			if tt.got.Comment != tt.wantComment {
+				t.Errorf("코멘트 = %q, 기대값 %q", tt.got.Comment, tt.wantComment)
+			}
+			if strings.Join(tt.got.Options, ",") != tt.wantOptions {
+				t.Errorf("옵션 = %q, 기대값 %q", tt.got.Options, tt.wantOptions)
+			}
+		})
+	}
+
+	if keys[0].Fingerprint == keys[1].Fingerprint {
+		t.Errorf("서로 다른 키의 핑거프린트가 같습니다")
+	}
+}
+
+func TestContainsPublicKey(t *testing.T) {
+	keyA := testAuthorizedKey(t, 1, "alice@laptop")
+	keyB := testAuthorizedKey(t, 2, "bob@desktop")
+	keyC := testAuthorizedKey(t, 3, "ci@runner")
+	lines := []string{"# " + keyB, "", "  " + keyA + "  ", `from="10.0.0.0/8",command="echo hi" ` + keyC}
+
+	tests := []struct {
+		name      string
+		publicKey string
+		want      bool
+	}{
+		{"같은 키", keyA, true},
+		{"코멘트만 다른 키", strings.TrimSuffix(keyA, "alice@laptop") + "other", true},
+		{"코멘트 없는 키", strings.TrimSuffix(keyA, " alice@laptop"), true},
+		{"옵션이 붙은 줄의 키", keyC, true},
+		{"옵션이 붙은 공개키", `no-pty ` + keyA, true},
+		{"주석 처리된 키", keyB, false},
+		{"알고리즘만 다른 키", strings.Replace(keyA, "ssh-ed25519", "ssh-rsa", 1), false},
+		{"빈 값", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containsPublicKey(lines, tt.publicKey); got != tt.want {
+				t.Errorf("containsPublicKey = %t, 기대값 %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemovePublicKeyLines(t *testing.T) {
+	keyA := testAuthorizedKey(t, 1, "alice@laptop")
+	keyB := testAuthorizedKey(t, 2, "bob@desktop")
+
+	tests := []struct {
+		name        string
+		lines       []string
+		publicKey   string
+		want        []string
+		wantRemoved bool
+	}{
+		{
+			name:        "키 제거, 주석과 빈 줄 유지",
+			lines:       []string{"# 팀 키", keyA, "", keyB},
+			publicKey:   keyA,
+			want:        []string{"# 팀 키", "", keyB},
+			wantRemoved: true,
+		},
+		{
+			name:        "같은 키가 여러 줄이면 모두 제거",
+			lines:       []string{keyA, keyB, strings.TrimSuffix(keyA, "alice@laptop") + "old"},
+			publicKey:   strings.TrimSuffix(keyA, " alice@laptop"),
+			want:        []string{keyB},
+			wantRemoved: true,
+		},
+		{
+			name:        "옵션이 붙은 줄 제거",
+			lines:       []string{`from="10.0.0.0/8",no-pty ` + keyA, keyB},
+			publicKey:   keyA,
+			want:        []string{keyB},
+			wantRemoved: true,
+		},
+		{
+			name:        "공백이 있는 옵션이 붙은 줄 제거",
+			lines:       []string{keyB, `command="/usr/bin/rsync --server",restrict ` + keyA},
+			publicKey:   keyA,
+			want:        []string{keyB},
+			wantRemoved: true,
+		},
+		{
+			name:        "키 종류가 다르게 적힌 줄은 같은 키로 보지 않음",
+			lines:       []string{strings.Replace(keyA, "ssh-ed25519", "ssh-rsa", 1), keyB},
+			publicKey:   keyA,
+			want:        []string{strings.Replace(keyA, "ssh-ed25519", "ssh-rsa", 1), keyB},
+			wantRemoved: false,
+		},
+		{
+			name:        "주석 처리된 같은 키는 유지",
+			lines:       []string{"# " + keyA, keyB},
+			publicKey:   keyA,
+			want:        []string{"# " + keyA, keyB},
+			wantRemoved: false,
+		},
+		{
+			name:        "없는 키",
+			lines:       []string{keyB},
+			publicKey:   keyA,
+			want:        []string{keyB},
+			wantRemoved: false,
+		},
+		{
+			name:        "마지막 키 제거",
+			lines:       []string{keyA},
+			publicKey:   keyA,
+			want:        nil,
+			wantRemoved: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed := removePublicKeyLines(tt.lines, tt.publicKey)
+			if removed != tt.wantRemoved {
+				t.Errorf("제거 여부 = %t, 기대값 %t", removed, tt.wantRemoved)
+			}
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+				t.Errorf("결과 = %q, 기대값 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizedKeyLinesSplitJoin(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantLines int
+		wantKeys  int
+		wantJoin  string
+	}{
+		{"개행으로 끝남", "a\nb\n", 2, 2, "a\nb\n"},
+		{"개행 없음", "a\nb", 2, 2, "a\nb\n"},
+		{"주석과 빈 줄", "# c\n\na\n", 3, 1, "# c\n\na\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := splitAuthorizedKeyLines(tt.content)
+			if len(lines) != tt.wantLines {
+				t.Errorf("줄 수 = %d, 기대값 %d", len(lines), tt.wantLines)
+			}
+			if got := CountAuthorizedKeys(tt.content); got != tt.wantKeys {
+				t.Errorf("CountAuthorizedKeys = %d, 기대값 %d", got, tt.wantKeys)
+			}
+			if got := joinAuthorizedKeyLines(lines); got != tt.wantJoin {
+				t.Errorf("joinAuthorizedKeyLines = %q, 기대값 %q", got, tt.wantJoin)
+			}
+		})
+	}
+
+	if joinAuthorizedKeyLines(nil) != "" {
+		t.Errorf("빈 줄 목록은 빈 내용이어야 합니다")
+	}
+}
@@ -0,0 +1,312 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 봉투 암호화(envelope encryption) 관련 상수입니다.
+// 각 행은 고유한 데이터 키(DEK)로 AES-256-GCM 암호화되고,
+// 데이터 키는 다시 마스터 키(KEK)로 AES-256-GCM 래핑되어 저장됩니다.
+// 암호문은 "<테이블>/<행 ID>/<컬럼>"을 AAD로 사용하므로 다른 행이나 컬럼으로 옮기면 복호화되지 않습니다.
+const (
+	EnvelopeCiphertextPrefix = "enc:v2:" // 암호화된 컬럼 값의 접두사
+	MasterKeySize            = 32        // AES-256
+	DataKeySize              = 32        // AES-256
+)
+
+// ErrEnvelopeNotEncrypted는 암호화되어 있어야 할 컬럼 값이 평문일 때 반환됩니다.
+var ErrEnvelopeNotEncrypted = errors.New("암호화되지 않은 값입니다")
+
+// EnvelopeContext는 암호화 값을 행과 컬럼에 묶는 AAD 문자열을 만듭니다 (예: "ssh_keys/12/private_key").
+func EnvelopeContext(table string, rowID uint, column string) string {
+	return fmt.Sprintf("%s/%d/%s", table, rowID, column)
+}
+
+// MasterKeyRing은 활성 마스터 키와 복호화에만 사용하는 이전 마스터 키들을 보관합니다.
+type MasterKeyRing struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// LoadMasterKeyRing은 마스터 키 파일 또는 환경변수 값으로 키 링을 구성합니다.
+// 파일이 지정되면 파일을 우선 사용하며, 첫 번째 키가 활성 키가 됩니다.
+// 각 키 항목은 "<키ID>:<base64 32바이트>" 또는 "<base64 32바이트>" 형식입니다.
+func LoadMasterKeyRing(masterKeyFile, masterKey, previousKeys string) (*MasterKeyRing, error) {
+	var entries []string
+
+	if strings.TrimSpace(masterKeyFile) != "" {
+		fileEntries, err := readMasterKeyFile(masterKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	} else if strings.TrimSpace(masterKey) != "" {
+		entries = append(entries, strings.TrimSpace(masterKey))
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("마스터 키가 설정되지 않았습니다 (MASTER_KEY 또는 MASTER_KEY_FILE)")
+	}
+
+	for _, entry := range strings.Split(previousKeys, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	ring := &MasterKeyRing{keys: make(map[string][]byte)}
+	for i, entry := range entries {
+		id, key, err := ParseMasterKeyEntry(entry)
+		if err != nil {
+			return nil, fmt.Errorf("마스터 키 #%d 파싱 실패: %w", i+1, err)
+		}
+		if _, exists := ring.keys[id]; exists {
+			return nil, fmt.Errorf("중복된 마스터 키 ID입니다: %s", id)
+		}
+		ring.keys[id] = key
+		if i == 0 {
+			ring.activeID = id
+		}
+	}
+
+	return ring, nil
+}
+
+// readMasterKeyFile은 마스터 키 파일에서 빈 줄과 주석(#)을 제외한 키 항목을 읽습니다.
+func readMasterKeyFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("마스터 키 파일 열기 실패: %w", err)
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("마스터 키 파일 읽기 실패: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ParseMasterKeyEntry는 "<키ID>:<base64>" 형식의 마스터 키 항목을 파싱합니다.
+// 키 ID가 없으면 키 값의 SHA256 해시 앞부분으로 ID를 만듭니다.
+func ParseMasterKeyEntry(entry string) (string, []byte, error) {
+	id := ""
+	encoded := strings.TrimSpace(entry)
+	if idx := strings.Index(encoded, ":"); idx >= 0 {
+		id = strings.TrimSpace(encoded[:idx])
+		encoded = strings.TrimSpace(encoded[idx+1:])
+	}
+
+	key, err := decodeMasterKey(encoded)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if id == "" {
+		sum := sha256.Sum256(key)
+		id = "mk-" + hex.EncodeToString(sum[:4])
+	}
+
+	return id, key, nil
+}
+
+// decodeMasterKey는 표준/URL base64로 인코딩된 32바이트 키를 디코딩합니다.
+func decodeMasterKey(encoded string) ([]byte, error) {
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		key, err := encoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		if len(key) != MasterKeySize {
+			return nil, fmt.Errorf("마스터 키는 %d바이트여야 합니다 (현재: %d바이트)", MasterKeySize, len(key))
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("마스터 키가 올바른 base64 형식이 아닙니다")
+}
+
+// GenerateMasterKeyEntry는 새 마스터 키 항목("<키ID>:<base64>")을 생성합니다.
+func GenerateMasterKeyEntry(id string) (string, error) {
+	key := make([]byte, MasterKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("마스터 키 생성 실패: %w", err)
+	}
+	return id + ":" + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ActiveKeyID는 새 데이터 키를 래핑할 때 사용하는 마스터 키 ID를 반환합니다.
+func (r *MasterKeyRing) ActiveKeyID() string {
+	return r.activeID
+}
+
+// KeyIDs는 키 링에 등록된 모든 마스터 키 ID를 정렬하여 반환합니다.
+func (r *MasterKeyRing) KeyIDs() []string {
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// GenerateDataKey는 새 데이터 키를 만들고 활성 마스터 키로 래핑합니다.
+func (r *MasterKeyRing) GenerateDataKey() ([]byte, string, error) {
+	dataKey := make([]byte, DataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, "", fmt.Errorf("데이터 키 생성 실패: %w", err)
+	}
+
+	wrapped, err := r.WrapDataKey(dataKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return dataKey, wrapped, nil
+}
+
+// WrapDataKey는 데이터 키를 활성 마스터 키로 래핑합니다.
+// 마스터 키 ID를 AAD로 사용하므로 다른 키 ID로 바꿔치기할 수 없습니다.
+func (r *MasterKeyRing) WrapDataKey(dataKey []byte) (string, error) {
+	masterKey, ok := r.keys[r.activeID]
+	if !ok {
+		return "", fmt.Errorf("활성 마스터 키를 찾을 수 없습니다")
+	}
+
+	sealed, err := sealAESGCM(masterKey, dataKey, []byte(r.activeID))
+	if err != nil {
+		return "", fmt.Errorf("데이터 키 래핑 실패: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// UnwrapDataKey는 지정된 마스터 키로 래핑된 데이터 키를 복원합니다.
+func (r *MasterKeyRing) UnwrapDataKey(wrapped, masterKeyID string) ([]byte, error) {
+	masterKey, ok := r.keys[masterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("마스터 키를 찾을 수 없습니다: %s", masterKeyID)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("래핑된 데이터 키 디코딩 실패: %w", err)
+	}
+
+	dataKey, err := openAESGCM(masterKey, sealed, []byte(masterKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("데이터 키 언래핑 실패 (마스터 키 ID: %s): %w", masterKeyID, err)
+	}
+
+	return dataKey, nil
+}
+
+// EncryptWithDataKey는 문자열을 데이터 키로 암호화하여 "enc:v2:<base64>" 형식으로 반환합니다.
+// aad는 EnvelopeContext로 만든 행/컬럼 식별자이며, 복호화할 때 같은 값을 넘겨야 합니다.
+// 빈 문자열은 그대로 반환합니다.
+func EncryptWithDataKey(dataKey []byte, plaintext, aad string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	if aad == "" {
+		return "", fmt.Errorf("암호화 AAD(행/컬럼 식별자)가 필요합니다")
+	}
+
+	sealed, err := sealAESGCM(dataKey, []byte(plaintext), []byte(aad))
+	if err != nil {
+		return "", err
+	}
+
+	return EnvelopeCiphertextPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptWithDataKey는 EncryptWithDataKey로 암호화된 값을 복호화합니다.
+// 접두사가 없는 값(평문 또는 알 수 없는 형식)은 ErrEnvelopeNotEncrypted를 반환합니다 (빈 문자열 제외).
+func DecryptWithDataKey(dataKey []byte, value, aad string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if !IsEnvelopeEncrypted(value) {
+		return "", ErrEnvelopeNotEncrypted
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EnvelopeCiphertextPrefix))
+	if err != nil {
+		return "", fmt.Errorf("암호문 디코딩 실패: %w", err)
+	}
+
+	plaintext, err := openAESGCM(dataKey, sealed, []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("복호화 실패: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// IsEnvelopeEncrypted는 값이 봉투 암호화되어 있는지 확인합니다.
+func IsEnvelopeEncrypted(value string) bool {
+	return strings.HasPrefix(value, EnvelopeCiphertextPrefix)
+}
+
+// sealAESGCM은 AES-GCM으로 암호화하고 nonce를 앞에 붙여 반환합니다.
+func sealAESGCM(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("nonce 생성 실패: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// openAESGCM은 nonce가 앞에 붙은 AES-GCM 암호문을 복호화합니다.
+func openAESGCM(key, sealed, additionalData []byte) ([]byte, error) {
+	gcm, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("암호문이 너무 짧습니다")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// newAESGCM은 256비트 키로 AES-GCM 인스턴스를 생성합니다.
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("AES-256 키는 32바이트여야 합니다 (현재: %d바이트)", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
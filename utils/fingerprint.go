@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// 핑거프린트 종류입니다.
+const (
+	FingerprintTypeSHA256 = "sha256"
+	FingerprintTypeMD5    = "md5"
+)
+
+var (
+	// sshd 로그 예: "Accepted publickey for deploy from 10.0.0.5 port 52144 ssh2: ED25519 SHA256:AbC..."
+	sha256FingerprintPattern = regexp.MustCompile(`SHA256:[A-Za-z0-9+/]{43}`)
+	md5FingerprintPattern    = regexp.MustCompile(`(?i)(?:MD5:)?\b([0-9a-f]{2}(?::[0-9a-f]{2}){15})\b`)
+	md5FingerprintExact      = regexp.MustCompile(`^[0-9a-f]{2}(?::[0-9a-f]{2}){15}$`)
+)
+
+// KeyFingerprints는 공개키의 SHA256 / MD5 핑거프린트입니다.
+type KeyFingerprints struct {
+	SHA256 string // SHA256:base64 (ssh-keygen -l 기본 형식)
+	MD5    string // aa:bb:... (ssh-keygen -l -E md5 형식, "MD5:" 접두사 제외)
+}
+
+// ComputeFingerprints는 authorized_keys 형식 공개키의 SHA256 / MD5 핑거프린트를 계산합니다.
+func ComputeFingerprints(authorizedKey string) (KeyFingerprints, error) {
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
+	if err != nil {
+		return KeyFingerprints{}, fmt.Errorf("공개키 형식이 올바르지 않습니다: %v", err)
+	}
+
+	return FingerprintsOf(publicKey), nil
+}
+
+// FingerprintsOf는 파싱된 공개키의 핑거프린트를 계산합니다.
+func FingerprintsOf(publicKey ssh.PublicKey) KeyFingerprints {
+	return KeyFingerprints{
+		SHA256: ssh.FingerprintSHA256(publicKey),
+		MD5:    ssh.FingerprintLegacyMD5(publicKey),
+	}
+}
+
+// NormalizeFingerprint는 입력된 핑거프린트를 저장 형식으로 정규화하고 종류를 반환합니다.
+// "SHA256:..." 또는 접두사 없는 43자 base64는 SHA256, "MD5:aa:bb:..." 또는 콜론 16진수는 MD5로 판단합니다.
+func NormalizeFingerprint(input string) (string, string, error) {
+	value := strings.TrimSpace(input)
+
+	switch {
+	case strings.HasPrefix(strings.ToUpper(value), "SHA256:"):
+		value = "SHA256:" + strings.TrimRight(value[len("SHA256:"):], "=")
+	case strings.HasPrefix(strings.ToUpper(value), "MD5:"):
+		value = value[len("MD5:"):]
+	}
+
+	if strings.HasPrefix(value, "SHA256:") || (len(value) == 43 && !strings.Contains(value, ":")) {
+		encoded := strings.TrimPrefix(value, "SHA256:")
+		if decoded, err := base64.RawStdEncoding.DecodeString(encoded); err == nil && len(decoded) == 32 {
+			return "SHA256:" + encoded, FingerprintTypeSHA256, nil
+		}
+		return "", "", fmt.Errorf("SHA256 핑거프린트 형식이 올바르지 않습니다: %s", input)
+	}
+
+	lower := strings.ToLower(value)
+	if md5FingerprintExact.MatchString(lower) {
+		return lower, FingerprintTypeMD5, nil
+	}
+
+	return "", "", fmt.Errorf("핑거프린트 형식이 올바르지 않습니다 (SHA256:... 또는 aa:bb:... 형식): %s", input)
+}
+
+// ExtractFingerprints는 sshd 로그 등 임의의 텍스트에서 SHA256 / MD5 핑거프린트를 모두 찾아 반환합니다 (중복 제거).
+func ExtractFingerprints(text string) []string {
+	seen := make(map[string]bool)
+	var fingerprints []string
+
+	add := func(value string) {
+		if normalized, _, err := NormalizeFingerprint(value); err == nil && !seen[normalized] {
+			seen[normalized] = true
+			fingerprints = append(fingerprints, normalized)
+		}
+	}
+
+	for _, match := range sha256FingerprintPattern.FindAllString(text, -1) {
+		add(match)
+	}
+	for _, match := range md5FingerprintPattern.FindAllStringSubmatch(text, -1) {
+		add(match[1])
+	}
+
+	return fingerprints
+}
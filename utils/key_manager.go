@@ -2,14 +2,13 @@ package utils
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"ssh-key-manager/types"
 	"strings"
-	"time"
 )
 
 // InstallPublicKeyToServer는 공개키를 로컬 서버의 authorized_keys에 추가합니다.
@@ -171,19 +170,15 @@ func isKeyAlreadyExists(authorizedKeysPath, newPublicKey string) (bool, error) {
 	return false, nil
 }
 
-// isSamePublicKey는 두 공개키가 같은지 비교합니다 (코멘트 제외).
+// isSamePublicKey는 두 authorized_keys 줄(또는 공개키)이 같은 키인지 비교합니다.
+// 앞에 붙은 옵션(from=, no-pty 등)과 코멘트는 무시하고 키 데이터만 비교합니다.
 func isSamePublicKey(key1, key2 string) bool {
-	// 키를 공백으로 분할하여 알고리즘과 키 부분만 비교
-	parts1 := strings.Fields(strings.TrimSpace(key1))
-	parts2 := strings.Fields(strings.TrimSpace(key2))
-
-	// 최소한 알고리즘과 키 데이터가 있어야 함
-	if len(parts1) < 2 || len(parts2) < 2 {
+	publicKey1, _, _, ok1 := parseAuthorizedKeyLine(strings.TrimSpace(key1))
+	publicKey2, _, _, ok2 := parseAuthorizedKeyLine(strings.TrimSpace(key2))
+	if !ok1 || !ok2 {
 		return false
 	}
-
-	// 알고리즘과 키 데이터가 같은지 확인 (코멘트는 제외)
-	return parts1[0] == parts2[0] && parts1[1] == parts2[1]
+	return bytes.Equal(publicKey1.Marshal(), publicKey2.Marshal())
 }
 
 // readAuthorizedKeysFile은 authorized_keys 파일을 읽어서 라인별로 반환합니다.
@@ -286,204 +281,90 @@ func ValidateSSHConfig(sshUser, sshHomePath string) error {
 	return nil
 }
 
-// DeploySSHKeyToRemoteServer는 SSH 키를 원격 서버에 배포합니다.
-func DeploySSHKeyToRemoteServer(publicKey, host string, port int, username string) error {
+// 원격 서버 정보를 "항목: 값" 형식으로 출력하는 명령입니다.
+const remoteServerInfoCommand = `echo "OS: $(uname -s)"; echo "Kernel: $(uname -r)"; echo "Architecture: $(uname -m)"; echo "Hostname: $(hostname)"; echo "Uptime: $(uptime | cut -d',' -f1)"`
+
+// DeploySSHKeyToRemoteServer는 SSH 키를 원격 서버의 authorized_keys에 추가합니다.
+// 같은 키(알고리즘 + 키 데이터)가 이미 있으면 추가하지 않으며, 파일은 SFTP로 원자적으로 교체합니다.
+func DeploySSHKeyToRemoteServer(ctx context.Context, target SSHTarget, publicKey string) error {
 	log.Printf("📡 원격 서버 SSH 키 배포 시작")
-	log.Printf("   - 대상 서버: %s@%s:%d", username, host, port)
+	log.Printf("   - 대상 서버: %s", target)
 
 	// 공개키 검증
-	if strings.TrimSpace(publicKey) == "" {
-		return fmt.Errorf("공개키가 비어있습니다")
-	}
-
-	// SSH 연결 테스트
-	if err := testSSHConnection(host, port, username); err != nil {
-		return fmt.Errorf("SSH 연결 테스트 실패: %v", err)
-	}
-
-	// 공개키 배포
-	if err := deployPublicKeyViaSSH(publicKey, host, port, username); err != nil {
-		return fmt.Errorf("공개키 배포 실패: %v", err)
+	cleanedKey := strings.TrimSpace(publicKey)
+	if len(strings.Fields(cleanedKey)) < 2 || strings.ContainsAny(cleanedKey, "\r\n") {
+		return fmt.Errorf("유효하지 않은 공개키 형식입니다")
 	}
 
-	log.Printf("✅ SSH 키 배포 완료: %s@%s:%d", username, host, port)
-	return nil
-}
-
-// testSSHConnection은 SSH 연결을 테스트합니다.
-func testSSHConnection(host string, port int, username string) error {
-	log.Printf("🔍 SSH 연결 테스트 중...")
-
-	// SSH 연결 테스트 명령
-	// ssh -o BatchMode=yes -o ConnectTimeout=10 -p PORT USER@HOST "echo 'connection test'"
-	cmd := exec.Command("ssh",
-		"-o", "BatchMode=yes", // 비밀번호 프롬프트 비활성화
-		"-o", "ConnectTimeout=10", // 연결 타임아웃 10초
-		"-o", "StrictHostKeyChecking=no", // 호스트 키 확인 비활성화 (첫 연결시)
-		"-o", "UserKnownHostsFile=/dev/null", // known_hosts 파일 사용 안함
-		"-p", fmt.Sprintf("%d", port),
-		fmt.Sprintf("%s@%s", username, host),
-		"echo 'SSH connection test successful'",
-	)
-
-	output, err := cmd.CombinedOutput()
+	err := editRemoteAuthorizedKeys(ctx, target, func(lines []string) ([]string, bool) {
+		if containsPublicKey(lines, cleanedKey) {
+			log.Printf("   - 이미 존재하는 키입니다 (건너뜀)")
+			return lines, false
+		}
+		return append(lines, cleanedKey), true
+	})
 	if err != nil {
-		log.Printf("❌ SSH 연결 실패: %s", string(output))
-		return fmt.Errorf("SSH 연결 실패 (%s@%s:%d): %v", username, host, port, err)
+		log.Printf("❌ 공개키 배포 실패: %v", err)
+		return err
 	}
 
-	log.Printf("✅ SSH 연결 테스트 성공")
+	log.Printf("✅ SSH 키 배포 완료: %s", target)
 	return nil
 }
 
-// deployPublicKeyViaSSH는 SSH를 통해 공개키를 원격 서버에 배포합니다.
-func deployPublicKeyViaSSH(publicKey, host string, port int, username string) error {
-	log.Printf("🔑 공개키 배포 중...")
-
-	// 공개키를 정리 (개행 제거 등)
-	cleanedKey := strings.TrimSpace(publicKey)
-	if !strings.HasSuffix(cleanedKey, "\n") {
-		cleanedKey += "\n"
-	}
-
-	// SSH를 통해 authorized_keys에 공개키 추가
-	// ssh-copy-id와 유사한 기능을 구현
-	sshCommand := fmt.Sprintf(
-		`mkdir -p ~/.ssh && chmod 700 ~/.ssh && echo '%s' >> ~/.ssh/authorized_keys && chmod 600 ~/.ssh/authorized_keys && echo 'Key deployed successfully'`,
-		strings.ReplaceAll(cleanedKey, "'", "'\"'\"'"), // 작은따옴표 이스케이프
-	)
-
-	cmd := exec.Command("ssh",
-		"-o", "BatchMode=yes",
-		"-o", "ConnectTimeout=30",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-p", fmt.Sprintf("%d", port),
-		fmt.Sprintf("%s@%s", username, host),
-		sshCommand,
-	)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		log.Printf("❌ 공개키 배포 실패: %s", string(output))
-		return fmt.Errorf("공개키 배포 실패: %v", err)
-	}
-
-	// 배포 성공 확인
-	if strings.Contains(string(output), "Key deployed successfully") {
-		log.Printf("✅ 공개키 배포 성공")
-		return nil
-	}
-
-	return fmt.Errorf("공개키 배포 확인 실패: %s", string(output))
-}
-
-// TestRemoteServerConnection은 원격 서버 연결을 테스트합니다.
-func TestRemoteServerConnection(host string, port int, username string) error {
-	log.Printf("🔍 원격 서버 연결 테스트: %s@%s:%d", username, host, port)
-
-	// 연결 테스트 (타임아웃 5초)
-	cmd := exec.Command("ssh",
-		"-o", "BatchMode=yes",
-		"-o", "ConnectTimeout=5",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-p", fmt.Sprintf("%d", port),
-		fmt.Sprintf("%s@%s", username, host),
-		"echo 'Connection test successful'",
-	)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("연결 실패: %v (출력: %s)", err, string(output))
-	}
+// TestRemoteServerConnection은 원격 서버에 접속하여 명령을 실행할 수 있는지 테스트합니다.
+func TestRemoteServerConnection(ctx context.Context, target SSHTarget) error {
+	log.Printf("🔍 원격 서버 연결 테스트: %s", target)
 
-	if strings.Contains(string(output), "Connection test successful") {
-		log.Printf("✅ 연결 테스트 성공")
-		return nil
+	if _, err := RunRemoteCommand(ctx, target, "true", nil); err != nil {
+		return err
 	}
 
-	return fmt.Errorf("연결 테스트 응답 확인 실패: %s", string(output))
+	log.Printf("✅ 연결 테스트 성공")
+	return nil
 }
 
-// RemoveSSHKeyFromRemoteServer는 원격 서버에서 SSH 키를 제거합니다.
-func RemoveSSHKeyFromRemoteServer(publicKey, host string, port int, username string) error {
+// RemoveSSHKeyFromRemoteServer는 원격 서버의 authorized_keys에서 SSH 키를 제거합니다.
+// 같은 키 줄만 지우고 주석과 다른 키는 그대로 두며, 파일은 SFTP로 원자적으로 교체합니다.
+func RemoveSSHKeyFromRemoteServer(ctx context.Context, target SSHTarget, publicKey string) error {
 	log.Printf("🗑️ 원격 서버에서 SSH 키 제거 시작")
-	log.Printf("   - 대상 서버: %s@%s:%d", username, host, port)
+	log.Printf("   - 대상 서버: %s", target)
 
-	// 제거할 키 정리
-	cleanedKey := strings.TrimSpace(publicKey)
-	keyParts := strings.Fields(cleanedKey)
-	if len(keyParts) < 2 {
-		return fmt.Errorf("유효하지 않은 공개키 형식")
-	}
-
-	// 키의 주요 부분 (알고리즘 + 키 데이터)만 사용하여 제거
-	keyToRemove := keyParts[0] + " " + keyParts[1]
-
-	// SSH를 통해 authorized_keys에서 키 제거
-	sshCommand := fmt.Sprintf(
-		`grep -v '%s' ~/.ssh/authorized_keys > ~/.ssh/authorized_keys.tmp && mv ~/.ssh/authorized_keys.tmp ~/.ssh/authorized_keys && echo 'Key removed successfully'`,
-		strings.ReplaceAll(keyToRemove, "'", "'\"'\"'"),
-	)
-
-	cmd := exec.Command("ssh",
-		"-o", "BatchMode=yes",
-		"-o", "ConnectTimeout=30",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-p", fmt.Sprintf("%d", port),
-		fmt.Sprintf("%s@%s", username, host),
-		sshCommand,
-	)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		log.Printf("❌ SSH 키 제거 실패: %s", string(output))
-		return fmt.Errorf("SSH 키 제거 실패: %v", err)
+	if len(strings.Fields(publicKey)) < 2 {
+		return fmt.Errorf("유효하지 않은 공개키 형식입니다")
 	}
 
-	if strings.Contains(string(output), "Key removed successfully") {
-		log.Printf("✅ SSH 키 제거 성공")
-		return nil
+	err := editRemoteAuthorizedKeys(ctx, target, func(lines []string) ([]string, bool) {
+		kept, removed := removePublicKeyLines(lines, publicKey)
+		if !removed {
+			log.Printf("⚠️ 제거할 키를 찾을 수 없습니다")
+		}
+		return kept, removed
+	})
+	if err != nil {
+		log.Printf("❌ SSH 키 제거 실패: %v", err)
+		return err
 	}
 
-	return fmt.Errorf("SSH 키 제거 확인 실패: %s", string(output))
+	log.Printf("✅ SSH 키 제거 성공")
+	return nil
 }
 
 // GetRemoteServerInfo는 원격 서버의 기본 정보를 조회합니다.
-func GetRemoteServerInfo(host string, port int, username string) (map[string]string, error) {
-	log.Printf("📊 원격 서버 정보 조회: %s@%s:%d", username, host, port)
-
-	// 서버 정보 조회 명령
-	sshCommand := `echo "OS: $(uname -s)" && echo "Kernel: $(uname -r)" && echo "Architecture: $(uname -m)" && echo "Hostname: $(hostname)" && echo "Uptime: $(uptime | cut -d',' -f1)" && echo "SSH_KEY_INFO_END"`
-
-	cmd := exec.Command("ssh",
-		"-o", "BatchMode=yes",
-		"-o", "ConnectTimeout=10",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-p", fmt.Sprintf("%d", port),
-		fmt.Sprintf("%s@%s", username, host),
-		sshCommand,
-	)
-
-	output, err := cmd.CombinedOutput()
+func GetRemoteServerInfo(ctx context.Context, target SSHTarget) (map[string]string, error) {
+	log.Printf("📊 원격 서버 정보 조회: %s", target)
+
+	output, err := RunRemoteCommand(ctx, target, remoteServerInfoCommand, nil)
 	if err != nil {
-		return nil, fmt.Errorf("서버 정보 조회 실패: %v", err)
+		return nil, err
 	}
 
 	// 출력 파싱
 	info := make(map[string]string)
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, ":") {
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) == 2 {
-				key := strings.TrimSpace(parts[0])
-				value := strings.TrimSpace(parts[1])
-				info[key] = value
-			}
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 {
+			info[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
 		}
 	}
 
@@ -491,90 +372,18 @@ func GetRemoteServerInfo(host string, port int, username string) (map[string]str
 	return info, nil
 }
 
-// ValidateRemoteServerAccess는 원격 서버 접근 권한을 검증합니다.
-func ValidateRemoteServerAccess(host string, port int, username string) error {
-	log.Printf("🔐 원격 서버 접근 권한 검증: %s@%s:%d", username, host, port)
+// ValidateRemoteServerAccess는 원격 서버에 접속하여 ~/.ssh(없으면 홈 디렉토리)에 쓸 수 있는지 검증합니다.
+func ValidateRemoteServerAccess(ctx context.Context, target SSHTarget) error {
+	log.Printf("🔐 원격 서버 접근 권한 검증: %s", target)
 
-	// 기본 연결 테스트
-	if err := TestRemoteServerConnection(host, port, username); err != nil {
-		return fmt.Errorf("기본 연결 실패: %v", err)
+	_, err := RunRemoteCommand(ctx, target, `if [ -e ~/.ssh ]; then test -d ~/.ssh -a -w ~/.ssh; else test -w ~; fi`, nil)
+	if IsSSHErrorKind(err, SSHErrorCommandFailed) {
+		return &SSHError{Kind: SSHErrorPermissionDenied, Target: target.String(), Err: fmt.Errorf("~/.ssh 디렉토리에 쓸 수 없습니다")}
 	}
-
-	// SSH 디렉토리 접근 권한 확인
-	sshCommand := `test -d ~/.ssh && echo "SSH_DIR_OK" || echo "SSH_DIR_NOT_FOUND"`
-
-	cmd := exec.Command("ssh",
-		"-o", "BatchMode=yes",
-		"-o", "ConnectTimeout=10",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-p", fmt.Sprintf("%d", port),
-		fmt.Sprintf("%s@%s", username, host),
-		sshCommand,
-	)
-
-	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("디렉토리 접근 권한 확인 실패: %v", err)
-	}
-
-	if !strings.Contains(string(output), "SSH_DIR_OK") {
-		log.Printf("⚠️ ~/.ssh 디렉토리가 존재하지 않습니다. 키 배포시 자동 생성됩니다.")
+		return err
 	}
 
 	log.Printf("✅ 원격 서버 접근 권한 검증 완료")
 	return nil
 }
-
-// BatchDeployToMultipleServers는 여러 서버에 동시에 키를 배포합니다.
-func BatchDeployToMultipleServers(publicKey string, servers []types.ServerDeployTarget) []types.ServerDeployResult {
-	log.Printf("🚀 배치 키 배포 시작 (서버 수: %d)", len(servers))
-
-	results := make([]types.ServerDeployResult, len(servers))
-
-	// 고루틴을 사용한 병렬 배포
-	resultChan := make(chan types.ServerDeployResult, len(servers))
-
-	for i, server := range servers {
-		go func(idx int, srv types.ServerDeployTarget) {
-			result := types.ServerDeployResult{
-				Index:      idx,
-				ServerName: srv.Name,
-				Host:       srv.Host,
-				Port:       srv.Port,
-				Username:   srv.Username,
-			}
-
-			startTime := time.Now()
-			err := DeploySSHKeyToRemoteServer(publicKey, srv.Host, srv.Port, srv.Username)
-			result.Duration = time.Since(startTime)
-
-			if err != nil {
-				result.Success = false
-				result.ErrorMessage = err.Error()
-				log.Printf("❌ 배포 실패 [%s]: %v", srv.Name, err)
-			} else {
-				result.Success = true
-				log.Printf("✅ 배포 성공 [%s]: %v", srv.Name, result.Duration)
-			}
-
-			resultChan <- result
-		}(i, server)
-	}
-
-	// 결과 수집
-	for i := 0; i < len(servers); i++ {
-		result := <-resultChan
-		results[result.Index] = result
-	}
-
-	successCount := 0
-	for _, result := range results {
-		if result.Success {
-			successCount++
-		}
-	}
-
-	log.Printf("🎯 배치 키 배포 완료: 성공 %d/%d", successCount, len(servers))
-	return results
-}
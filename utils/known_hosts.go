@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// 호스트 키를 수집할 때 시도하는 알고리즘 (선호 순서)
+var hostKeyScanAlgorithms = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoRSASHA512,
+}
+
+var (
+	knownHostsMu   sync.RWMutex
+	knownHostsFile string // 기록된 호스트 키가 없는 서버 접속 시 사용하는 known_hosts 파일 (비어있으면 검증하지 않음)
+)
+
+// errHostKeyCaptured는 호스트 키만 받고 인증 전에 연결을 끊기 위한 내부 오류입니다.
+var errHostKeyCaptured = errors.New("host key captured")
+
+// FetchHostKeys는 서버에 접속하여 알고리즘별 호스트 키를 수집합니다 (ssh-keyscan과 유사).
+// 사용자 인증은 하지 않으며, 인증서를 제시하는 서버도 인증서가 아닌 원래 호스트 키를 반환합니다.
+func FetchHostKeys(host string, port int, timeout time.Duration) ([]ssh.PublicKey, error) {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+
+	var keys []ssh.PublicKey
+	var lastErr error
+	for _, algorithm := range hostKeyScanAlgorithms {
+		key, err := fetchHostKey(address, algorithm, timeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		if lastErr == nil {
+			lastErr = errors.New("호스트 키를 받지 못했습니다")
+		}
+		return nil, fmt.Errorf("호스트 키 조회 실패 (%s): %v", address, lastErr)
+	}
+	return keys, nil
+}
+
+// fetchHostKey는 지정한 알고리즘으로 키 교환만 수행하여 호스트 키를 받습니다.
+func fetchHostKey(address, algorithm string, timeout time.Duration) (ssh.PublicKey, error) {
+	var captured ssh.PublicKey
+	config := &ssh.ClientConfig{
+		User:              "ssh-key-manager",
+		HostKeyAlgorithms: []string{algorithm},
+		Timeout:           timeout,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			captured = key
+			return errHostKeyCaptured
+		},
+	}
+
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	_, _, _, err = ssh.NewClientConn(conn, address, config)
+	if captured != nil {
+		return captured, nil
+	}
+	if err == nil {
+		err = errors.New("호스트 키를 받지 못했습니다")
+	}
+	return nil, err
+}
+
+// PreferredHostKey는 수집한 호스트 키 중 서명에 사용할 키를 선택합니다 (Ed25519 > ECDSA > RSA).
+func PreferredHostKey(keys []ssh.PublicKey) ssh.PublicKey {
+	for _, keyType := range []string{ssh.KeyAlgoED25519, ssh.KeyAlgoECDSA256, ssh.KeyAlgoRSA} {
+		for _, key := range keys {
+			if key.Type() == keyType {
+				return key
+			}
+		}
+	}
+	if len(keys) > 0 {
+		return keys[0]
+	}
+	return nil
+}
+
+// KnownHostsPattern은 known_hosts 파일의 호스트 패턴을 만듭니다 (22번 포트가 아니면 [host]:port).
+func KnownHostsPattern(host string, port int) string {
+	if port == 0 || port == 22 {
+		return host
+	}
+	return fmt.Sprintf("[%s]:%d", host, port)
+}
+
+// FormatCertAuthorityLine은 호스트 CA를 신뢰하는 known_hosts 줄을 만듭니다.
+// patterns가 비어있으면 모든 호스트(*)에 대해 CA를 신뢰합니다.
+func FormatCertAuthorityLine(patterns []string, caPublicKey string) string {
+	pattern := "*"
+	if len(patterns) > 0 {
+		pattern = strings.Join(patterns, ",")
+	}
+	return fmt.Sprintf("@cert-authority %s %s", pattern, strings.TrimSpace(caPublicKey))
+}
+
+// WriteKnownHostsFile은 관리 도구용 known_hosts 파일에 호스트 CA 줄을 기록하고 원격 접속에 사용하도록 설정합니다.
+// 기존 파일에 기록된 일반 호스트 키 줄(첫 접속 시 추가된 키)은 유지합니다.
+func WriteKnownHostsFile(path, certAuthorityLine string) error {
+	if strings.TrimSpace(path) == "" {
+		return errors.New("known_hosts 파일 경로를 입력해주세요")
+	}
+
+	var preserved []string
+	if existing, err := os.ReadFile(path); err == nil {
+		for _, line := range strings.Split(string(existing), "\n") {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "@cert-authority") {
+				continue
+			}
+			preserved = append(preserved, trimmed)
+		}
+	}
+
+	var content strings.Builder
+	content.WriteString("# SSH Key Manager known_hosts (자동 생성됨, @cert-authority 줄은 덮어쓰여집니다)\n")
+	if certAuthorityLine != "" {
+		content.WriteString(certAuthorityLine + "\n")
+	}
+	for _, line := range preserved {
+		content.WriteString(line + "\n")
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("known_hosts 디렉토리 생성 실패: %v", err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0600); err != nil {
+		return fmt.Errorf("known_hosts 파일 저장 실패: %v", err)
+	}
+
+	knownHostsMu.Lock()
+	knownHostsFile = path
+	knownHostsMu.Unlock()
+	return nil
+}
+
+// checkManagedKnownHosts는 기록된 호스트 키가 없는 서버의 호스트 키를 관리용 known_hosts로 검증합니다.
+// @cert-authority로 서명된 인증서는 통과하고, CA로 검증되지 않은 인증서는 원래 호스트 키로 다시 확인합니다.
+// 처음 보는 호스트는 키를 파일에 추가하며(accept-new), 기록된 키와 다르면 errHostKeyMismatch를 반환합니다.
+// known_hosts가 설정되지 않았으면 검증하지 않습니다.
+func checkManagedKnownHosts(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+
+	if knownHostsFile == "" {
+		return nil
+	}
+
+	callback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return fmt.Errorf("known_hosts 파일을 읽을 수 없습니다: %v", err)
+	}
+
+	err = callback(hostname, remote, key)
+	var keyErr *knownhosts.KeyError
+	if err != nil && !errors.As(err, &keyErr) {
+		if cert, ok := key.(*ssh.Certificate); ok {
+			key = cert.Key
+			err = callback(hostname, remote, key)
+		}
+	}
+	if err == nil {
+		return nil
+	}
+
+	if !errors.As(err, &keyErr) {
+		return err
+	}
+	if len(keyErr.Want) > 0 {
+		return errHostKeyMismatch
+	}
+
+	// 처음 접속하는 호스트: 키를 기록
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	file, openErr := os.OpenFile(knownHostsFile, os.O_APPEND|os.O_WRONLY, 0600)
+	if openErr != nil {
+		return fmt.Errorf("known_hosts 파일에 기록할 수 없습니다: %v", openErr)
+	}
+	defer file.Close()
+	if _, writeErr := file.WriteString(line + "\n"); writeErr != nil {
+		return fmt.Errorf("known_hosts 파일에 기록할 수 없습니다: %v", writeErr)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func TestEncryptedPKCS8RoundTrip(t *testing.T) {
+	for keyName, signer := range testSigners(t) {
+		t.Run(keyName, func(t *testing.T) {
+			data, err := EncodePrivateKeyToEncryptedPKCS8(signer, "correct horse")
+			if err != nil {
+				t.Fatalf("EncodePrivateKeyToEncryptedPKCS8 실패: %v", err)
+			}
+			if DetectKeyFormat(data) != KeyFormatEncryptedPEM {
+				t.Fatalf("형식 = %s, 암호화된 PKCS#8 기대", DetectKeyFormat(data))
+			}
+
+			parsed, _, err := ParsePrivateKeyAnyFormat(data, "correct horse")
+			if err != nil {
+				t.Fatalf("ParsePrivateKeyAnyFormat 실패: %v", err)
+			}
+			if !samePrivateKey(signer, parsed) {
+				t.Errorf("복원한 개인키의 공개키가 원래 키와 다릅니다")
+			}
+		})
+	}
+}
+
+// encryptPKCS8ForTest는 OpenSSL 이전 기본값(PBKDF2-HMAC-SHA1, PRF 생략 + AES-128-CBC)으로 PKCS#8을 암호화합니다.
+// 파일에는 iterations를 기록하고, 키 유도는 keyIterations번 수행합니다 (반복 횟수 검증 테스트용).
+func encryptPKCS8ForTest(t *testing.T, signer crypto.Signer, passphrase string, iterations, keyIterations int) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		t.Fatalf("PKCS#8 인코딩 실패: %v", err)
+	}
+	salt := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, keyIterations, 16, sha1.New))
+	if err != nil {
+		t.Fatalf("AES 초기화 실패: %v", err)
+	}
+	padLen := aes.BlockSize - len(der)%aes.BlockSize
+	plaintext := append(der, bytes.Repeat([]byte{byte(padLen)}, padLen)...)
+	encrypted := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, plaintext)
+
+	kdfParams, _ := asn1.Marshal(pbkdf2Params{Salt: salt, IterationCount: iterations})
+	ivParams, _ := asn1.Marshal(iv)
+	schemeParams, _ := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES128CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	encoded, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		EncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: schemeParams}},
+		EncryptedData:       encrypted,
+	})
+	if err != nil {
+		t.Fatalf("암호화된 PKCS#8 인코딩 실패: %v", err)
+	}
+	return encoded
+}
+
+func TestParseEncryptedPKCS8(t *testing.T) {
+	signer := testSigners(t)["ecdsa-p256"]
+
+	current, err := EncodePrivateKeyToEncryptedPKCS8(signer, "secret")
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyToEncryptedPKCS8 실패: %v", err)
+	}
+	block, _ := pem.Decode(current)
+
+	tests := []struct {
+		name       string
+		der        []byte
+		passphrase string
+		wantErr    string
+	}{
+		{"현재 형식 (SHA256, AES-256)", block.Bytes, "secret", ""},
+		{"OpenSSL 이전 형식 (SHA1, AES-128)", encryptPKCS8ForTest(t, signer, "secret", 2048, 2048), "secret", ""},
+		{"잘못된 패스프레이즈", block.Bytes, "wrong", "패스프레이즈가 올바르지 않습니다"},
+		{"패스프레이즈 누락", block.Bytes, "", "패스프레이즈를 입력해주세요"},
+		{"과도한 반복 횟수", encryptPKCS8ForTest(t, signer, "secret", 20000000, 1), "secret", "반복 횟수가 올바르지 않습니다"},
+		{"PKCS#8 아님", []byte("not der"), "secret", "파싱 실패"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseEncryptedPKCS8(tt.der, tt.passphrase)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("오류 = %v, %q 포함 기대", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEncryptedPKCS8 실패: %v", err)
+			}
+			if !samePrivateKey(signer, parsed) {
+				t.Errorf("복원한 개인키의 공개키가 원래 키와 다릅니다")
+			}
+		})
+	}
+}
+
+func TestEncodeEncryptedPKCS8WithoutPassphrase(t *testing.T) {
+	signer := testSigners(t)["ed25519"]
+
+	data, err := EncodePrivateKeyToEncryptedPKCS8(signer, "")
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyToEncryptedPKCS8 실패: %v", err)
+	}
+	if DetectKeyFormat(data) != KeyFormatPKCS8 {
+		t.Errorf("패스프레이즈가 없으면 암호화하지 않은 PKCS#8이어야 합니다 (형식: %s)", DetectKeyFormat(data))
+	}
+}
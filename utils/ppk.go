@@ -0,0 +1,646 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/ssh"
+)
+
+// PPK 파일 버전입니다. v3는 PuTTY 0.75 이상에서 지원됩니다.
+const (
+	PPKVersion2 = 2
+	PPKVersion3 = 3
+)
+
+// PPK v3 Argon2id 기본 파라미터 (puttygen 기본값과 동일한 메모리/병렬도)
+const (
+	ppkV3Argon2Memory      = 8192 // KiB
+	ppkV3Argon2Passes      = 13
+	ppkV3Argon2Parallelism = 1
+	ppkV3Argon2SaltSize    = 16
+)
+
+// PPKEncodeOptions는 PPK 파일 생성 옵션입니다.
+type PPKEncodeOptions struct {
+	Version    int    // 2 또는 3 (0이면 3)
+	Comment    string // 파일에 기록할 코멘트
+	Passphrase string // 비어있으면 비암호화
+
+	// v3 암호화 전용 Argon2id 파라미터 (0이면 기본값)
+	Argon2Memory      uint32
+	Argon2Passes      uint32
+	Argon2Parallelism uint8
+
+	// Rand는 v3 Argon2 salt 생성에 사용됩니다 (nil이면 crypto/rand).
+	// 고정된 입력을 주면 같은 키에 대해 항상 같은 파일이 생성됩니다.
+	Rand io.Reader
+}
+
+// PPKFile은 파싱된 PuTTY 개인키 파일의 내용입니다.
+type PPKFile struct {
+	Version    int
+	KeyType    string
+	Encryption string
+	Comment    string
+	PublicKey  []byte
+	PrivateKey []byte // 파일에 기록된 (암호화되었을 수 있는) 개인키 데이터
+	MAC        []byte
+	Headers    map[string]string // Key-Derivation, Argon2-* 등 기타 헤더
+}
+
+// EncodePPK는 개인키를 PPK v2 또는 v3 파일로 인코딩합니다 (RSA, ECDSA, Ed25519).
+// 패스프레이즈가 있으면 aes256-cbc로 암호화하며, 키 유도는 v2는 SHA1, v3는 Argon2id를 사용합니다.
+// MAC은 v2는 HMAC-SHA1, v3는 HMAC-SHA256입니다. 외부 도구(puttygen)를 사용하지 않습니다.
+func EncodePPK(privateKey crypto.Signer, opts PPKEncodeOptions) ([]byte, error) {
+	version := opts.Version
+	if version == 0 {
+		version = PPKVersion3
+	}
+	if version != PPKVersion2 && version != PPKVersion3 {
+		return nil, fmt.Errorf("지원하지 않는 PPK 버전입니다: %d (2, 3만 지원)", version)
+	}
+
+	publicKey, err := ssh.NewPublicKey(privateKey.Public())
+	if err != nil {
+		return nil, fmt.Errorf("공개키 생성 실패: %v", err)
+	}
+	publicBlob := publicKey.Marshal()
+
+	privateBlob, err := marshalPPKPrivateBlob(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	encryption := "none"
+	privateOut := privateBlob
+	var kdfHeaders [][2]string
+	var macKey []byte
+	newMAC := sha256.New
+
+	if opts.Passphrase != "" {
+		encryption = "aes256-cbc"
+		// 블록 크기(16바이트)에 맞춰 개인키 데이터의 SHA1 해시로 패딩 (PuTTY와 동일)
+		privateBlob = padPPKPrivateBlob(privateBlob)
+	}
+
+	switch version {
+	case PPKVersion2:
+		newMAC = sha1.New
+		sum := sha1.Sum([]byte("putty-private-key-file-mac-key" + opts.Passphrase))
+		macKey = sum[:]
+		if opts.Passphrase != "" {
+			privateOut, err = encryptPPKPrivateBlob(privateBlob, derivePPKv2CipherKey(opts.Passphrase), make([]byte, aes.BlockSize))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	case PPKVersion3:
+		// 비암호화 v3 파일은 빈 키로 MAC 계산
+		if opts.Passphrase != "" {
+			headers, err := newPPKv3KDFHeaders(opts)
+			if err != nil {
+				return nil, err
+			}
+			derived, err := derivePPKv3Keys(headers, opts.Passphrase)
+			if err != nil {
+				return nil, err
+			}
+			// Argon2id 출력 80바이트 = 암호화 키(32) + IV(16) + MAC 키(32)
+			macKey = derived[48:80]
+			privateOut, err = encryptPPKPrivateBlob(privateBlob, derived[:32], derived[32:48])
+			if err != nil {
+				return nil, err
+			}
+			for _, name := range ppkV3KDFHeaderNames {
+				kdfHeaders = append(kdfHeaders, [2]string{name, headers[name]})
+			}
+		}
+	}
+
+	// MAC은 암호화 전(패딩 포함) 개인키 데이터 기준
+	mac := computePPKMAC(newMAC, macKey, publicKey.Type(), encryption, opts.Comment, publicBlob, privateBlob)
+	content := buildPPKFile(version, publicKey.Type(), encryption, opts.Comment, publicBlob, privateOut, kdfHeaders, mac)
+
+	log.Printf("✅ PPK v%d 생성 완료 (코멘트: %s, 암호화: %s)", version, opts.Comment, encryption)
+	return content, nil
+}
+
+// EncodePrivateKeyToPPKWithUser는 사용자명을 코멘트로 하는 비암호화 PPK v3 파일을 생성합니다.
+func EncodePrivateKeyToPPKWithUser(privateKey crypto.Signer, username string) ([]byte, error) {
+	return EncodePPK(privateKey, PPKEncodeOptions{Comment: username})
+}
+
+// EncodePrivateKeyToPPK는 키 타입 이름을 코멘트로 하는 비암호화 PPK v3 파일을 생성합니다.
+func EncodePrivateKeyToPPK(privateKey crypto.Signer) ([]byte, error) {
+	comment := "ssh-key"
+	if sshPublicKey, err := ssh.NewPublicKey(privateKey.Public()); err == nil {
+		comment = sshPublicKey.Type() + "-key"
+	}
+	return EncodePrivateKeyToPPKWithUser(privateKey, comment)
+}
+
+// ppkV3KDFHeaderNames는 PPK v3 파일에 기록되는 키 유도 헤더 순서입니다.
+var ppkV3KDFHeaderNames = []string{"Key-Derivation", "Argon2-Memory", "Argon2-Passes", "Argon2-Parallelism", "Argon2-Salt"}
+
+// newPPKv3KDFHeaders는 옵션으로 PPK v3 Argon2id 헤더 값을 만듭니다.
+func newPPKv3KDFHeaders(opts PPKEncodeOptions) (map[string]string, error) {
+	memory, passes, parallelism := opts.Argon2Memory, opts.Argon2Passes, opts.Argon2Parallelism
+	if memory == 0 {
+		memory = ppkV3Argon2Memory
+	}
+	if passes == 0 {
+		passes = ppkV3Argon2Passes
+	}
+	if parallelism == 0 {
+		parallelism = ppkV3Argon2Parallelism
+	}
+
+	random := opts.Rand
+	if random == nil {
+		random = rand.Reader
+	}
+	salt := make([]byte, ppkV3Argon2SaltSize)
+	if _, err := io.ReadFull(random, salt); err != nil {
+		return nil, fmt.Errorf("salt 생성 실패: %v", err)
+	}
+
+	return map[string]string{
+		"Key-Derivation":     "Argon2id",
+		"Argon2-Memory":      strconv.FormatUint(uint64(memory), 10),
+		"Argon2-Passes":      strconv.FormatUint(uint64(passes), 10),
+		"Argon2-Parallelism": strconv.FormatUint(uint64(parallelism), 10),
+		"Argon2-Salt":        hex.EncodeToString(salt),
+	}, nil
+}
+
+// buildPPKFile은 PPK 파일 텍스트를 조립합니다. 키 유도 헤더는 v3 암호화 파일에만 사용됩니다.
+func buildPPKFile(version int, keyType, encryption, comment string, publicKey, privateKey []byte, kdfHeaders [][2]string, mac []byte) []byte {
+	var ppkContent strings.Builder
+
+	ppkContent.WriteString(fmt.Sprintf("PuTTY-User-Key-File-%d: %s\n", version, keyType))
+	ppkContent.WriteString(fmt.Sprintf("Encryption: %s\n", encryption))
+	ppkContent.WriteString(fmt.Sprintf("Comment: %s\n", comment))
+
+	// 공개키 데이터를 base64로 인코딩하여 64자씩 줄바꿈
+	publicKeyB64 := encodeBase64WithLineBreaks(publicKey, 64)
+	ppkContent.WriteString(fmt.Sprintf("Public-Lines: %d\n", len(strings.Split(publicKeyB64, "\n"))))
+	ppkContent.WriteString(publicKeyB64)
+	ppkContent.WriteString("\n")
+
+	for _, header := range kdfHeaders {
+		ppkContent.WriteString(fmt.Sprintf("%s: %s\n", header[0], header[1]))
+	}
+
+	// 개인키 데이터를 base64로 인코딩하여 64자씩 줄바꿈
+	privateKeyB64 := encodeBase64WithLineBreaks(privateKey, 64)
+	ppkContent.WriteString(fmt.Sprintf("Private-Lines: %d\n", len(strings.Split(privateKeyB64, "\n"))))
+	ppkContent.WriteString(privateKeyB64)
+	ppkContent.WriteString("\n")
+
+	ppkContent.WriteString(fmt.Sprintf("Private-MAC: %x\n", mac))
+	return []byte(ppkContent.String())
+}
+
+// computePPKMAC은 PPK 파일의 MAC을 계산합니다 (v2: HMAC-SHA1, v3: HMAC-SHA256).
+func computePPKMAC(newHash func() hash.Hash, macKey []byte, keyType, encryption, comment string, publicBlob, privateBlob []byte) []byte {
+	var macData bytes.Buffer
+	writeSSHString(&macData, keyType)
+	writeSSHString(&macData, encryption)
+	writeSSHString(&macData, comment)
+	writeSSHBytes(&macData, publicBlob)
+	writeSSHBytes(&macData, privateBlob)
+
+	h := hmac.New(newHash, macKey)
+	h.Write(macData.Bytes())
+	return h.Sum(nil)
+}
+
+// padPPKPrivateBlob은 개인키 데이터를 16바이트 배수로 맞추기 위해 SHA1 해시로 패딩합니다.
+func padPPKPrivateBlob(data []byte) []byte {
+	padLen := (aes.BlockSize - len(data)%aes.BlockSize) % aes.BlockSize
+	if padLen == 0 {
+		return data
+	}
+
+	hash := sha1.Sum(data)
+	padded := make([]byte, 0, len(data)+padLen)
+	padded = append(padded, data...)
+	return append(padded, hash[:padLen]...)
+}
+
+// encryptPPKPrivateBlob은 패딩된 개인키 데이터를 aes256-cbc로 암호화합니다.
+func encryptPPKPrivateBlob(data, key, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("PPK 암호화 초기화 실패: %v", err)
+	}
+
+	encrypted := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, data)
+	return encrypted, nil
+}
+
+// derivePPKv2CipherKey는 PPK v2의 aes256-cbc 암호화 키를 유도합니다 (IV는 0).
+// 키: SHA1(0x00000000 || 패스프레이즈) || SHA1(0x00000001 || 패스프레이즈)의 앞 32바이트
+func derivePPKv2CipherKey(passphrase string) []byte {
+	var key []byte
+	for seq := uint32(0); seq < 2; seq++ {
+		h := sha1.New()
+		binary.Write(h, binary.BigEndian, seq)
+		h.Write([]byte(passphrase))
+		key = append(key, h.Sum(nil)...)
+	}
+	return key[:32]
+}
+
+// marshalPPKPrivateBlob은 PuTTY 형식의 개인키 데이터를 알고리즘별로 마샬링합니다.
+func marshalPPKPrivateBlob(privateKey crypto.Signer) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if len(key.Primes) != 2 {
+			return nil, fmt.Errorf("PPK는 2개의 소수로 구성된 RSA 키만 지원합니다")
+		}
+		// RSA 개인키 형식: d, p, q, iqmp (iqmp = q^-1 mod p)
+		p, q := key.Primes[0], key.Primes[1]
+		writeSSHBigInt(&buf, key.D)
+		writeSSHBigInt(&buf, p)
+		writeSSHBigInt(&buf, q)
+		writeSSHBigInt(&buf, new(big.Int).ModInverse(q, p))
+	case *ecdsa.PrivateKey:
+		// ECDSA 개인키 형식: 개인 스칼라 (mpint)
+		writeSSHBigInt(&buf, key.D)
+	case ed25519.PrivateKey:
+		// Ed25519 개인키 형식: 32바이트 시드를 리틀 엔디언 정수로 취급하여 상위 0바이트를 제거한 문자열
+		seed := key.Seed()
+		end := len(seed)
+		for end > 0 && seed[end-1] == 0 {
+			end--
+		}
+		writeSSHBytes(&buf, seed[:end])
+	case *ed25519.PrivateKey:
+		return marshalPPKPrivateBlob(*key)
+	default:
+		return nil, fmt.Errorf("지원하지 않는 개인키 타입: %T", privateKey)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// IsPPK는 데이터가 PuTTY 개인키 파일인지 확인합니다.
+func IsPPK(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("PuTTY-User-Key-File-"))
+}
+
+// ParsePPK는 PPK v2/v3 파일을 파싱하고 MAC을 검증한 뒤 개인키와 코멘트를 반환합니다.
+// 암호화된 파일은 passphrase로 복호화합니다 (v2: SHA1 키 유도, v3: Argon2).
+func ParsePPK(data []byte, passphrase string) (crypto.Signer, string, error) {
+	file, err := decodePPKFile(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if file.Encryption != "none" && passphrase == "" {
+		return nil, "", fmt.Errorf("암호화된 개인키입니다. 패스프레이즈를 입력해주세요")
+	}
+	if file.Encryption == "none" {
+		passphrase = ""
+	}
+
+	privateBlob, err := decryptPPKPrivateBlob(file, passphrase)
+	if err != nil {
+		return nil, "", err
+	}
+
+	privateKey, err := unmarshalPPKPrivateKey(file.KeyType, file.PublicKey, privateBlob)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return privateKey, file.Comment, nil
+}
+
+// decodePPKFile은 PPK 텍스트 구조를 파싱합니다 (복호화/MAC 검증 없음).
+func decodePPKFile(data []byte) (*PPKFile, error) {
+	file := &PPKFile{Headers: make(map[string]string)}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	readHeader := func() (string, string, error) {
+		for scanner.Scan() {
+			line := strings.TrimRight(scanner.Text(), "\r")
+			if line == "" {
+				continue
+			}
+			idx := strings.Index(line, ": ")
+			if idx < 0 {
+				return "", "", fmt.Errorf("PPK 헤더 형식이 올바르지 않습니다: %s", line)
+			}
+			return line[:idx], line[idx+2:], nil
+		}
+		return "", "", fmt.Errorf("PPK 파일이 예상보다 일찍 끝났습니다")
+	}
+
+	readBlob := func(countValue string) ([]byte, error) {
+		count, err := strconv.Atoi(countValue)
+		if err != nil || count < 0 || count > 1024 {
+			return nil, fmt.Errorf("PPK 줄 수가 올바르지 않습니다: %s", countValue)
+		}
+		var encoded strings.Builder
+		for i := 0; i < count; i++ {
+			if !scanner.Scan() {
+				return nil, fmt.Errorf("PPK 키 데이터가 부족합니다")
+			}
+			encoded.WriteString(strings.TrimSpace(scanner.Text()))
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded.String())
+		if err != nil {
+			return nil, fmt.Errorf("PPK 키 데이터 디코딩 실패: %v", err)
+		}
+		return decoded, nil
+	}
+
+	name, value, err := readHeader()
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "PuTTY-User-Key-File-2":
+		file.Version = 2
+	case "PuTTY-User-Key-File-3":
+		file.Version = 3
+	default:
+		if strings.HasPrefix(name, "PuTTY-User-Key-File-") {
+			return nil, fmt.Errorf("지원하지 않는 PPK 버전입니다: %s (v2, v3만 지원)", name)
+		}
+		return nil, fmt.Errorf("PPK 파일이 아닙니다")
+	}
+	file.KeyType = value
+
+	for {
+		name, value, err := readHeader()
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "Encryption":
+			file.Encryption = value
+		case "Comment":
+			file.Comment = value
+		case "Public-Lines":
+			if file.PublicKey, err = readBlob(value); err != nil {
+				return nil, err
+			}
+		case "Private-Lines":
+			if file.PrivateKey, err = readBlob(value); err != nil {
+				return nil, err
+			}
+		case "Private-MAC":
+			if file.MAC, err = hex.DecodeString(value); err != nil {
+				return nil, fmt.Errorf("PPK MAC 형식이 올바르지 않습니다")
+			}
+			if file.Encryption != "none" && file.Encryption != "aes256-cbc" {
+				return nil, fmt.Errorf("지원하지 않는 PPK 암호화 방식입니다: %s", file.Encryption)
+			}
+			if file.PublicKey == nil || file.PrivateKey == nil {
+				return nil, fmt.Errorf("PPK 파일에 키 데이터가 없습니다")
+			}
+			return file, nil
+		case "Private-Hash":
+			return nil, fmt.Errorf("지원하지 않는 PPK 버전입니다 (v1)")
+		default:
+			file.Headers[name] = value
+		}
+	}
+}
+
+// decryptPPKPrivateBlob은 개인키 데이터를 복호화하고 MAC을 검증합니다.
+func decryptPPKPrivateBlob(file *PPKFile, passphrase string) ([]byte, error) {
+	privateBlob := file.PrivateKey
+	var cipherKey, iv, macKey []byte
+	var newMAC func() hash.Hash
+
+	switch file.Version {
+	case 2:
+		if file.Encryption != "none" {
+			cipherKey = derivePPKv2CipherKey(passphrase)
+			iv = make([]byte, aes.BlockSize)
+		}
+		sum := sha1.Sum([]byte("putty-private-key-file-mac-key" + passphrase))
+		macKey = sum[:]
+		newMAC = sha1.New
+	case 3:
+		if file.Encryption != "none" {
+			derived, err := derivePPKv3Keys(file.Headers, passphrase)
+			if err != nil {
+				return nil, err
+			}
+			cipherKey, iv, macKey = derived[:32], derived[32:48], derived[48:80]
+		}
+		newMAC = sha256.New
+	}
+
+	if cipherKey != nil {
+		if len(privateBlob) == 0 || len(privateBlob)%aes.BlockSize != 0 {
+			return nil, fmt.Errorf("PPK 암호문 길이가 올바르지 않습니다")
+		}
+		block, err := aes.NewCipher(cipherKey)
+		if err != nil {
+			return nil, err
+		}
+		decrypted := make([]byte, len(privateBlob))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, privateBlob)
+		privateBlob = decrypted
+	}
+
+	mac := computePPKMAC(newMAC, macKey, file.KeyType, file.Encryption, file.Comment, file.PublicKey, privateBlob)
+	if subtle.ConstantTimeCompare(mac, file.MAC) != 1 {
+		if file.Encryption != "none" {
+			return nil, fmt.Errorf("패스프레이즈가 올바르지 않습니다")
+		}
+		return nil, fmt.Errorf("PPK 파일 MAC 검증에 실패했습니다 (파일이 손상되었을 수 있습니다)")
+	}
+
+	return privateBlob, nil
+}
+
+// derivePPKv3Keys는 PPK v3 헤더의 Argon2 파라미터로 암호화 키, IV, MAC 키(총 80바이트)를 유도합니다.
+func derivePPKv3Keys(headers map[string]string, passphrase string) ([]byte, error) {
+	memory, err := strconv.ParseUint(headers["Argon2-Memory"], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("PPK Argon2-Memory 값이 올바르지 않습니다")
+	}
+	passes, err := strconv.ParseUint(headers["Argon2-Passes"], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("PPK Argon2-Passes 값이 올바르지 않습니다")
+	}
+	parallelism, err := strconv.ParseUint(headers["Argon2-Parallelism"], 10, 8)
+	if err != nil || parallelism == 0 {
+		return nil, fmt.Errorf("PPK Argon2-Parallelism 값이 올바르지 않습니다")
+	}
+	salt, err := hex.DecodeString(headers["Argon2-Salt"])
+	if err != nil {
+		return nil, fmt.Errorf("PPK Argon2-Salt 값이 올바르지 않습니다")
+	}
+
+	// 악의적인 파일로 인한 과도한 메모리 사용 방지 (1GiB)
+	if memory > 1024*1024 {
+		return nil, fmt.Errorf("PPK Argon2 메모리 설정이 너무 큽니다: %d KiB", memory)
+	}
+
+	switch headers["Key-Derivation"] {
+	case "Argon2id":
+		return argon2.IDKey([]byte(passphrase), salt, uint32(passes), uint32(memory), uint8(parallelism), 80), nil
+	case "Argon2i":
+		return argon2.Key([]byte(passphrase), salt, uint32(passes), uint32(memory), uint8(parallelism), 80), nil
+	}
+
+	return nil, fmt.Errorf("지원하지 않는 PPK 키 유도 방식입니다: %s", headers["Key-Derivation"])
+}
+
+// unmarshalPPKPrivateKey는 PPK 공개키/개인키 데이터로 개인키를 복원합니다.
+func unmarshalPPKPrivateKey(keyType string, publicBlob, privateBlob []byte) (crypto.Signer, error) {
+	pub := &sshReader{data: publicBlob}
+	priv := &sshReader{data: privateBlob}
+
+	if blobType := string(pub.readString()); blobType != keyType {
+		return nil, fmt.Errorf("PPK 키 타입이 일치하지 않습니다: %s != %s", blobType, keyType)
+	}
+
+	switch {
+	case keyType == "ssh-rsa":
+		e := pub.readMPInt()
+		n := pub.readMPInt()
+		d := priv.readMPInt()
+		p := priv.readMPInt()
+		q := priv.readMPInt()
+		priv.readMPInt() // iqmp (Precompute로 다시 계산)
+		if pub.err != nil || priv.err != nil {
+			return nil, fmt.Errorf("PPK RSA 키 데이터가 올바르지 않습니다")
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("PPK RSA 공개 지수가 올바르지 않습니다")
+		}
+
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, fmt.Errorf("PPK RSA 키 검증 실패: %v", err)
+		}
+		key.Precompute()
+		return key, nil
+
+	case keyType == "ssh-ed25519":
+		publicKey := pub.readString()
+		seedLE := priv.readString()
+		if pub.err != nil || priv.err != nil || len(publicKey) != ed25519.PublicKeySize || len(seedLE) > ed25519.SeedSize {
+			return nil, fmt.Errorf("PPK Ed25519 키 데이터가 올바르지 않습니다")
+		}
+
+		// 리틀 엔디언 정수로 저장된 시드를 32바이트로 복원 (상위 0바이트 보충)
+		seed := make([]byte, ed25519.SeedSize)
+		copy(seed, seedLE)
+		key := ed25519.NewKeyFromSeed(seed)
+		if !bytes.Equal(key.Public().(ed25519.PublicKey), publicKey) {
+			return nil, fmt.Errorf("PPK Ed25519 개인키와 공개키가 일치하지 않습니다")
+		}
+		return key, nil
+
+	case strings.HasPrefix(keyType, "ecdsa-sha2-"):
+		curveName := string(pub.readString())
+		point := pub.readString()
+		d := priv.readMPInt()
+		if pub.err != nil || priv.err != nil {
+			return nil, fmt.Errorf("PPK ECDSA 키 데이터가 올바르지 않습니다")
+		}
+
+		var curve elliptic.Curve
+		switch curveName {
+		case "nistp256":
+			curve = elliptic.P256()
+		case "nistp384":
+			curve = elliptic.P384()
+		case "nistp521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("지원하지 않는 ECDSA 곡선입니다: %s", curveName)
+		}
+
+		x, y := elliptic.Unmarshal(curve, point)
+		if x == nil {
+			return nil, fmt.Errorf("PPK ECDSA 공개키 좌표가 올바르지 않습니다")
+		}
+		key := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: d}
+		if cx, cy := curve.ScalarBaseMult(d.Bytes()); cx.Cmp(x) != 0 || cy.Cmp(y) != 0 {
+			return nil, fmt.Errorf("PPK ECDSA 개인키와 공개키가 일치하지 않습니다")
+		}
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("지원하지 않는 PPK 키 타입입니다: %s", keyType)
+}
+
+// sshReader는 SSH 와이어 형식(uint32 길이 + 데이터)을 순서대로 읽습니다.
+type sshReader struct {
+	data []byte
+	err  error
+}
+
+func (r *sshReader) readString() []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.data) < 4 {
+		r.err = fmt.Errorf("데이터가 부족합니다")
+		return nil
+	}
+	length := binary.BigEndian.Uint32(r.data)
+	if uint64(length) > uint64(len(r.data)-4) {
+		r.err = fmt.Errorf("데이터 길이가 올바르지 않습니다")
+		return nil
+	}
+	value := r.data[4 : 4+length]
+	r.data = r.data[4+length:]
+	return value
+}
+
+func (r *sshReader) readMPInt() *big.Int {
+	value := r.readString()
+	if r.err != nil {
+		return new(big.Int)
+	}
+	if len(value) > 0 && value[0]&0x80 != 0 {
+		r.err = fmt.Errorf("음수 정수는 지원하지 않습니다")
+		return new(big.Int)
+	}
+	return new(big.Int).SetBytes(value)
+}
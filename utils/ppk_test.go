@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+	"testing"
+)
+
+// rfc8032Seed는 RFC 8032 7.1절 테스트 1의 Ed25519 개인키 시드입니다.
+const rfc8032Seed = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+
+func testSigners(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+
+	seed, _ := hex.DecodeString(rfc8032Seed)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("RSA 키 생성 실패: %v", err)
+	}
+	signers := map[string]crypto.Signer{
+		"ed25519": ed25519.NewKeyFromSeed(seed),
+		"rsa":     rsaKey,
+	}
+	for name, curve := range map[string]elliptic.Curve{"ecdsa-p256": elliptic.P256(), "ecdsa-p384": elliptic.P384(), "ecdsa-p521": elliptic.P521()} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatalf("ECDSA 키 생성 실패: %v", err)
+		}
+		signers[name] = key
+	}
+	return signers
+}
+
+// samePrivateKey는 두 개인키의 공개키가 같은지 비교합니다.
+func samePrivateKey(a, b crypto.Signer) bool {
+	type equaler interface{ Equal(crypto.PublicKey) bool }
+	return a.Public().(equaler).Equal(b.Public())
+}
+
+func TestEncodeParsePPKRoundTrip(t *testing.T) {
+	signers := testSigners(t)
+
+	tests := []struct {
+		name string
+		opts PPKEncodeOptions
+	}{
+		{"v2 비암호화", PPKEncodeOptions{Version: PPKVersion2, Comment: "v2-plain"}},
+		{"v2 암호화", PPKEncodeOptions{Version: PPKVersion2, Comment: "v2-enc", Passphrase: "correct horse"}},
+		{"v3 비암호화", PPKEncodeOptions{Version: PPKVersion3, Comment: "v3-plain"}},
+		{"v3 암호화", PPKEncodeOptions{Version: PPKVersion3, Comment: "v3-enc", Passphrase: "correct horse", Argon2Memory: 1024, Argon2Passes: 2}},
+		{"기본 버전(v3)", PPKEncodeOptions{Comment: "default"}},
+	}
+
+	for _, tt := range tests {
+		for keyName, signer := range signers {
+			t.Run(tt.name+"/"+keyName, func(t *testing.T) {
+				data, err := EncodePPK(signer, tt.opts)
+				if err != nil {
+					t.Fatalf("EncodePPK 실패: %v", err)
+				}
+
+				version := tt.opts.Version
+				if version == 0 {
+					version = PPKVersion3
+				}
+				if !bytes.HasPrefix(data, []byte(fmt.Sprintf("PuTTY-User-Key-File-%d: ", version))) {
+					t.Fatalf("PPK 버전 헤더가 올바르지 않습니다: %q", strings.SplitN(string(data), "\n", 2)[0])
+				}
+
+				parsed, comment, err := ParsePPK(data, tt.opts.Passphrase)
+				if err != nil {
+					t.Fatalf("ParsePPK 실패: %v", err)
+				}
+				if comment != tt.opts.Comment {
+					t.Errorf("코멘트 = %q, 기대값 %q", comment, tt.opts.Comment)
+				}
+				if !samePrivateKey(signer, parsed) {
+					t.Errorf("복원한 개인키의 공개키가 원래 키와 다릅니다")
+				}
+			})
+		}
+	}
+}
+
+func TestEncodePPKDeterministicWithFixedRand(t *testing.T) {
+	seed, _ := hex.DecodeString(rfc8032Seed)
+	signer := ed25519.NewKeyFromSeed(seed)
+
+	opts := PPKEncodeOptions{Version: PPKVersion3, Comment: "fixed", Passphrase: "pw", Argon2Memory: 1024, Argon2Passes: 1}
+	opts.Rand = bytes.NewReader(make([]byte, ppkV3Argon2SaltSize))
+	first, err := EncodePPK(signer, opts)
+	if err != nil {
+		t.Fatalf("EncodePPK 실패: %v", err)
+	}
+	opts.Rand = bytes.NewReader(make([]byte, ppkV3Argon2SaltSize))
+	second, err := EncodePPK(signer, opts)
+	if err != nil {
+		t.Fatalf("EncodePPK 실패: %v", err)
+	}
+
+	if !bytes.Equal(first, second) {
+		t.Errorf("같은 salt로 생성한 PPK 파일이 서로 다릅니다")
+	}
+}
+
+// specPPKMAC은 PuTTY 문서(PPK 형식)의 정의대로 MAC을 계산합니다.
+// computePPKMAC과 독립적으로 구현하여 생성된 파일의 MAC을 검증하는 데 사용합니다.
+func specPPKMAC(newHash func() hash.Hash, key []byte, fields ...[]byte) string {
+	var data bytes.Buffer
+	for _, field := range fields {
+		binary.Write(&data, binary.BigEndian, uint32(len(field)))
+		data.Write(field)
+	}
+	mac := hmac.New(newHash, key)
+	mac.Write(data.Bytes())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestEncodePPKMACMatchesSpec(t *testing.T) {
+	seed, _ := hex.DecodeString(rfc8032Seed)
+	signer := ed25519.NewKeyFromSeed(seed)
+
+	// 비암호화 Ed25519 개인키 데이터: string(32바이트 시드)
+	privateBlob := append([]byte{0, 0, 0, 32}, seed...)
+	publicBlob := append([]byte("\x00\x00\x00\x0bssh-ed25519\x00\x00\x00\x20"), signer.Public().(ed25519.PublicKey)...)
+	v2Key := sha1.Sum([]byte("putty-private-key-file-mac-key"))
+
+	tests := []struct {
+		name    string
+		version int
+		wantMAC string
+	}{
+		{
+			// v2: HMAC-SHA1, 키 = SHA1("putty-private-key-file-mac-key" + 패스프레이즈)
+			name:    "v2",
+			version: PPKVersion2,
+			wantMAC: specPPKMAC(sha1.New, v2Key[:], []byte("ssh-ed25519"), []byte("none"), []byte("rfc8032"), publicBlob, privateBlob),
+		},
+		{
+			// v3 비암호화: HMAC-SHA256, 빈 키
+			name:    "v3",
+			version: PPKVersion3,
+			wantMAC: specPPKMAC(sha256.New, nil, []byte("ssh-ed25519"), []byte("none"), []byte("rfc8032"), publicBlob, privateBlob),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := EncodePPK(signer, PPKEncodeOptions{Version: tt.version, Comment: "rfc8032"})
+			if err != nil {
+				t.Fatalf("EncodePPK 실패: %v", err)
+			}
+			if !strings.Contains(string(data), "\nPrivate-MAC: "+tt.wantMAC+"\n") {
+				t.Errorf("Private-MAC이 PPK 형식 정의와 다릅니다 (기대값 %s)\n%s", tt.wantMAC, data)
+			}
+		})
+	}
+}
+
+func TestParsePPKRejectsTamperedOrWrongPassphrase(t *testing.T) {
+	seed, _ := hex.DecodeString(rfc8032Seed)
+	signer := ed25519.NewKeyFromSeed(seed)
+
+	encode := func(opts PPKEncodeOptions) string {
+		data, err := EncodePPK(signer, opts)
+		if err != nil {
+			t.Fatalf("EncodePPK 실패: %v", err)
+		}
+		return string(data)
+	}
+	v2Plain := encode(PPKEncodeOptions{Version: PPKVersion2, Comment: "original"})
+	v3Plain := encode(PPKEncodeOptions{Version: PPKVersion3, Comment: "original"})
+	v2Enc := encode(PPKEncodeOptions{Version: PPKVersion2, Comment: "original", Passphrase: "secret"})
+	v3Enc := encode(PPKEncodeOptions{Version: PPKVersion3, Comment: "original", Passphrase: "secret", Argon2Memory: 1024, Argon2Passes: 1})
+
+	tests := []struct {
+		name       string
+		data       string
+		passphrase string
+		wantErr    string
+	}{
+		{"v2 코멘트 변조", strings.Replace(v2Plain, "Comment: original", "Comment: modified", 1), "", "MAC 검증에 실패"},
+		{"v3 코멘트 변조", strings.Replace(v3Plain, "Comment: original", "Comment: modified", 1), "", "MAC 검증에 실패"},
+		{"v2 잘못된 패스프레이즈", v2Enc, "wrong", "패스프레이즈가 올바르지 않습니다"},
+		{"v3 잘못된 패스프레이즈", v3Enc, "wrong", "패스프레이즈가 올바르지 않습니다"},
+		{"암호화 파일 패스프레이즈 누락", v3Enc, "", "패스프레이즈를 입력해주세요"},
+		{"v1 파일", "PuTTY-User-Key-File-1: ssh-rsa\n", "", "지원하지 않는 PPK 버전"},
+		{"PPK 아님", "SSH2-Key-File: ssh-rsa\n", "", "PPK 파일이 아닙니다"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParsePPK([]byte(tt.data), tt.passphrase)
+			if err == nil {
+				t.Fatalf("오류가 발생해야 합니다")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("오류 = %q, %q 포함 기대", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// 원격 홈 디렉토리 기준 authorized_keys 경로 (SFTP 작업 디렉토리는 홈 디렉토리)
+const (
+	remoteSSHDir             = ".ssh"
+	remoteAuthorizedKeysPath = ".ssh/authorized_keys"
+)
+
+// 대상 서버에 남기는 authorized_keys 백업 파일 (~/.ssh/authorized_keys.bak-<시각>) 접두사와 보관 개수
+const (
+	authorizedKeysBackupPrefix = "authorized_keys.bak-"
+	maxAuthorizedKeysBackups   = 10
+)
+
+// 다른 프로세스가 동시에 파일을 바꾼 경우 다시 읽어 편집하는 최대 횟수
+const maxRemoteEditAttempts = 3
+
+// errRemoteFileChanged는 편집하는 동안 원격 파일이 바뀌었음을 나타냅니다.
+var errRemoteFileChanged = errors.New("편집 중 authorized_keys가 다른 작업에 의해 변경되었습니다")
+
+// 대상 계정(user@host:port)별 authorized_keys 편집 잠금
+var (
+	remoteEditLocksMu sync.Mutex
+	remoteEditLocks   = make(map[string]*sync.Mutex)
+)
+
+// lockRemoteAuthorizedKeys는 같은 서버 계정의 authorized_keys 편집을 한 번에 하나만 실행하도록 잠급니다.
+func lockRemoteAuthorizedKeys(target SSHTarget) func() {
+	remoteEditLocksMu.Lock()
+	lock, ok := remoteEditLocks[target.String()]
+	if !ok {
+		lock = &sync.Mutex{}
+		remoteEditLocks[target.String()] = lock
+	}
+	remoteEditLocksMu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// AuthorizedKeysBackup은 변경 직전의 authorized_keys 내용과 대상 서버에 남긴 백업 파일 경로입니다.
+type AuthorizedKeysBackup struct {
+	Content string // authorized_keys 내용 (파일이 없었으면 빈 값)
+	Path    string // 대상 서버의 백업 파일 경로 (원격은 홈 디렉토리 기준, 파일이 없었거나 백업 파일을 남기지 않는 방식이면 빈 값)
+}
+
+// BackupRemoteAuthorizedKeys는 원격 authorized_keys 내용을 읽고 같은 디렉토리에 같은 권한의 백업 파일을 남깁니다.
+// 오래된 백업 파일은 최근 maxAuthorizedKeysBackups개만 남기고 지웁니다.
+func BackupRemoteAuthorizedKeys(ctx context.Context, target SSHTarget) (*AuthorizedKeysBackup, error) {
+	unlock := lockRemoteAuthorizedKeys(target)
+	defer unlock()
+
+	backup := &AuthorizedKeysBackup{}
+	err := withSFTPClient(ctx, target, func(client *sftp.Client) error {
+		filePath, err := resolveSFTPPath(client, remoteAuthorizedKeysPath)
+		if err != nil {
+			return err
+		}
+		info, err := client.Stat(filePath)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		content, err := readSFTPFile(client, filePath)
+		if err != nil {
+			return err
+		}
+		backup.Content = string(content)
+
+		var backupPath string
+		for attempt := 0; ; attempt++ {
+			backupPath = path.Join(remoteSSHDir, authorizedKeysBackupName(attempt))
+			err = writeNewSFTPFile(client, backupPath, content, info.Mode().Perm())
+			if !errors.Is(err, os.ErrExist) || attempt >= 3 {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("원격 백업 파일 생성 실패: %w", err)
+		}
+		backup.Path = backupPath
+
+		pruneSFTPBackups(client)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("💾 authorized_keys 백업 완료: %s (%s)", target, backupPathOrNone(backup.Path))
+	return backup, nil
+}
+
+// RestoreRemoteAuthorizedKeys는 원격 authorized_keys 전체를 content로 원자적으로 교체합니다.
+func RestoreRemoteAuthorizedKeys(ctx context.Context, target SSHTarget, content string) error {
+	var restored []string
+	if content != "" {
+		restored = splitAuthorizedKeyLines(content)
+	}
+
+	return editRemoteAuthorizedKeys(ctx, target, func(lines []string) ([]string, bool) {
+		return restored, joinAuthorizedKeyLines(lines) != joinAuthorizedKeyLines(restored)
+	})
+}
+
+// editRemoteAuthorizedKeys는 SFTP로 원격 authorized_keys를 읽어 edit로 수정한 뒤 원자적으로 교체합니다.
+// edit는 파일의 모든 줄(주석, 빈 줄 포함)을 받아 새 줄 목록과 변경 여부를 반환합니다.
+// 같은 대상의 편집은 프로세스 안에서 순서대로 실행되며, 다른 프로세스가 그 사이 파일을 바꾸면 다시 읽어 편집합니다.
+func editRemoteAuthorizedKeys(ctx context.Context, target SSHTarget, edit func(lines []string) ([]string, bool)) error {
+	unlock := lockRemoteAuthorizedKeys(target)
+	defer unlock()
+
+	return withSFTPClient(ctx, target, func(client *sftp.Client) error {
+		for attempt := 1; ; attempt++ {
+			err := editSFTPAuthorizedKeys(client, edit)
+			if !errors.Is(err, errRemoteFileChanged) || attempt >= maxRemoteEditAttempts {
+				return err
+			}
+			log.Printf("⚠️ authorized_keys 동시 변경 감지, 다시 편집합니다 (%s, %d/%d)", target, attempt, maxRemoteEditAttempts)
+		}
+	})
+}
+
+// editSFTPAuthorizedKeys는 authorized_keys를 한 번 읽고 편집하여 교체합니다.
+func editSFTPAuthorizedKeys(client *sftp.Client, edit func(lines []string) ([]string, bool)) error {
+	if err := client.MkdirAll(remoteSSHDir); err != nil {
+		return err
+	}
+
+	// 심볼릭 링크면 링크를 유지하고 실제 파일을 교체
+	filePath, err := resolveSFTPPath(client, remoteAuthorizedKeysPath)
+	if err != nil {
+		return err
+	}
+
+	original, err := client.Stat(filePath)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	content, err := readSFTPFile(client, filePath)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	if len(content) > 0 {
+		lines = splitAuthorizedKeyLines(string(content))
+	}
+	edited, changed := edit(lines)
+	if !changed {
+		return nil
+	}
+
+	mode := os.FileMode(0600)
+	if exists {
+		mode = original.Mode().Perm()
+	} else if err := client.Chmod(remoteSSHDir, 0700); err != nil {
+		// 새로 만드는 경우에만 디렉토리 권한을 맞춤
+		return err
+	}
+
+	return replaceSFTPFile(client, filePath, []byte(joinAuthorizedKeyLines(edited)), mode, original)
+}
+
+// replaceSFTPFile은 같은 디렉토리의 임시 파일에 data를 쓰고 원래 파일의 권한과 소유자를 맞춘 뒤 이름을 바꿔 교체합니다.
+// original이 nil이 아니면 교체 직전에 원래 파일이 그대로인지 확인하고, 바뀌었으면 errRemoteFileChanged를 반환합니다.
+// SELinux 문맥은 SFTP로 복사할 수 없으므로 새 파일은 디렉토리의 기본 문맥(~/.ssh: ssh_home_t)을 따릅니다.
+func replaceSFTPFile(client *sftp.Client, filePath string, data []byte, mode os.FileMode, original os.FileInfo) error {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	tmpPath := fmt.Sprintf("%s.tmp-%s", filePath, hex.EncodeToString(suffix))
+
+	file, err := client.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			client.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := client.Chmod(tmpPath, mode); err != nil {
+		return err
+	}
+	if err := matchSFTPOwner(client, tmpPath, original); err != nil {
+		return err
+	}
+
+	if original != nil {
+		current, err := client.Stat(filePath)
+		if err != nil || current.Size() != original.Size() || !current.ModTime().Equal(original.ModTime()) {
+			return errRemoteFileChanged
+		}
+	}
+
+	if err := renameSFTPFile(client, tmpPath, filePath); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// renameSFTPFile은 기존 파일을 덮어쓰며 이름을 바꿉니다.
+// SFTP 기본 rename은 대상이 있으면 실패하므로 OpenSSH의 posix-rename 확장을 사용하고,
+// 확장이 없는 서버에서만 기존 파일을 지운 뒤 이름을 바꿉니다 (이 경우 잠깐 파일이 없는 순간이 생김).
+func renameSFTPFile(client *sftp.Client, from, to string) error {
+	if _, ok := client.HasExtension("posix-rename@openssh.com"); ok {
+		return client.PosixRename(from, to)
+	}
+
+	log.Printf("⚠️ SFTP 서버가 posix-rename을 지원하지 않아 원자적으로 교체할 수 없습니다: %s", to)
+	if err := client.Remove(to); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return client.Rename(from, to)
+}
+
+// writeNewSFTPFile은 새 파일을 만들어 data를 씁니다. 같은 이름의 파일이 있으면 실패합니다.
+func writeNewSFTPFile(client *sftp.Client, filePath string, data []byte, mode os.FileMode) error {
+	file, err := client.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return err
+	}
+	if err := file.Chmod(mode); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// pruneSFTPBackups는 오래된 authorized_keys 백업 파일을 지웁니다 (실패는 로그만 남김).
+func pruneSFTPBackups(client *sftp.Client) {
+	entries, err := client.ReadDir(remoteSSHDir)
+	if err != nil {
+		log.Printf("⚠️ 원격 백업 파일 목록 조회 실패: %v", err)
+		return
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), authorizedKeysBackupPrefix) {
+			names = append(names, entry.Name())
+		}
+	}
+	for _, name := range expiredBackupNames(names) {
+		if err := client.Remove(path.Join(remoteSSHDir, name)); err != nil {
+			log.Printf("⚠️ 오래된 원격 백업 파일 삭제 실패: %s (%v)", name, err)
+		}
+	}
+}
+
+// authorizedKeysBackupName은 백업 파일 이름을 만듭니다. 이름이 겹쳐 다시 시도할 때는 순번을 붙입니다.
+func authorizedKeysBackupName(attempt int) string {
+	name := authorizedKeysBackupPrefix + time.Now().Format("20060102-150405.000000")
+	if attempt > 0 {
+		name += fmt.Sprintf("-%d", attempt)
+	}
+	return name
+}
+
+// expiredBackupNames는 백업 파일 이름(시각 순으로 정렬됨) 중 보관 개수를 넘는 오래된 이름을 반환합니다.
+func expiredBackupNames(names []string) []string {
+	if len(names) <= maxAuthorizedKeysBackups {
+		return nil
+	}
+	sort.Strings(names)
+	return names[:len(names)-maxAuthorizedKeysBackups]
+}
+
+// backupPathOrNone은 로그용 백업 경로를 반환합니다.
+func backupPathOrNone(backupPath string) string {
+	if backupPath == "" {
+		return "기존 파일 없음"
+	}
+	return backupPath
+}
+
+// matchSFTPOwner는 임시 파일의 소유자를 원래 파일과 같게 맞춥니다 (다른 경우에만 변경).
+func matchSFTPOwner(client *sftp.Client, tmpPath string, original os.FileInfo) error {
+	if original == nil {
+		return nil
+	}
+	want, ok := original.Sys().(*sftp.FileStat)
+	if !ok {
+		return nil
+	}
+	info, err := client.Stat(tmpPath)
+	if err != nil {
+		return err
+	}
+	if have, ok := info.Sys().(*sftp.FileStat); ok && have.UID == want.UID && have.GID == want.GID {
+		return nil
+	}
+	if err := client.Chown(tmpPath, int(want.UID), int(want.GID)); err != nil {
+		return fmt.Errorf("authorized_keys 소유자를 유지할 수 없습니다 (uid %d, gid %d): %w", want.UID, want.GID, err)
+	}
+	return nil
+}
+
+// resolveSFTPPath는 경로가 심볼릭 링크면 링크가 가리키는 경로를 반환합니다.
+func resolveSFTPPath(client *sftp.Client, filePath string) (string, error) {
+	info, err := client.Lstat(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return filePath, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return filePath, nil
+	}
+
+	link, err := client.ReadLink(filePath)
+	if err != nil {
+		return "", err
+	}
+	if !path.IsAbs(link) {
+		link = path.Join(path.Dir(filePath), link)
+	}
+	return link, nil
+}
+
+// withSFTPClient는 재사용 SSH 연결에서 SFTP 세션을 열어 fn을 실행합니다.
+// ctx가 취소되면 SFTP 세션을 닫아 진행 중인 작업을 중단합니다.
+func withSFTPClient(ctx context.Context, target SSHTarget, fn func(client *sftp.Client) error) error {
+	return withSSHClient(ctx, target, func(ctx context.Context, conn *ssh.Client) error {
+		client, err := sftp.NewClient(conn)
+		if err != nil {
+			// 끊어진 재사용 연결일 수 있으므로 연결 오류로 분류 (withSSHClient가 한 번 다시 연결)
+			return &SSHError{Kind: SSHErrorUnreachable, Target: target.String(), Err: fmt.Errorf("SFTP 세션을 열 수 없습니다: %v", err)}
+		}
+		defer client.Close()
+
+		done := make(chan error, 1)
+		go func() {
+			done <- fn(client)
+		}()
+
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			client.Close()
+			return contextSSHError(ctx, target)
+		}
+
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, os.ErrPermission):
+			return &SSHError{Kind: SSHErrorPermissionDenied, Target: target.String(), Err: err}
+		default:
+			var sshErr *SSHError
+			if errors.As(err, &sshErr) {
+				return err
+			}
+			return &SSHError{Kind: SSHErrorCommandFailed, Target: target.String(), Err: err}
+		}
+	})
+}
+
+// readSFTPFile은 원격 파일 내용을 읽습니다. 파일이 없으면 빈 내용을 반환합니다.
+func readSFTPFile(client *sftp.Client, filePath string) ([]byte, error) {
+	file, err := client.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
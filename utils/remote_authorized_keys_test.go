@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// newTestSFTPClient는 home 디렉토리를 작업 디렉토리로 하는 프로세스 내 SFTP 서버에 연결합니다.
+func newTestSFTPClient(t *testing.T, home string) *sftp.Client {
+	t.Helper()
+
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+
+	server, err := sftp.NewServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverReader, serverWriter}, sftp.WithServerWorkingDirectory(home))
+	if err != nil {
+		t.Fatalf("SFTP 서버 생성 실패: %v", err)
+	}
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientReader, clientWriter)
+	if err != nil {
+		t.Fatalf("SFTP 클라이언트 생성 실패: %v", err)
+	}
+	t.Cleanup(func() {
+		// 서버 쪽 연결을 먼저 닫아야 클라이언트 수신 루프가 끝남
+		server.Close()
+		client.Close()
+	})
+	return client
+}
+
+// writeTestAuthorizedKeys는 home/.ssh/authorized_keys를 주어진 내용과 권한으로 만듭니다.
+func writeTestAuthorizedKeys(t *testing.T, home, content string, mode os.FileMode) string {
+	t.Helper()
+	dir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("디렉토리 생성 실패: %v", err)
+	}
+	path := filepath.Join(dir, "authorized_keys")
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatalf("파일 생성 실패: %v", err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatalf("권한 변경 실패: %v", err)
+	}
+	return path
+}
+
+// assertNoTempFiles는 편집 후 임시 파일이 남지 않았는지 확인합니다.
+func assertNoTempFiles(t *testing.T, home string) {
+	t.Helper()
+	entries, _ := os.ReadDir(filepath.Join(home, ".ssh"))
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("임시 파일이 남아 있습니다: %s", entry.Name())
+		}
+	}
+}
+
+func TestEditSFTPAuthorizedKeys(t *testing.T) {
+	keyA := testAuthorizedKey(t, 1, "alice@laptop")
+	keyB := testAuthorizedKey(t, 2, "bob@desktop")
+
+	install := func(publicKey string) func([]string) ([]string, bool) {
+		return func(lines []string) ([]string, bool) {
+			if containsPublicKey(lines, publicKey) {
+				return lines, false
+			}
+			return append(lines, publicKey), true
+		}
+	}
+	remove := func(publicKey string) func([]string) ([]string, bool) {
+		return func(lines []string) ([]string, bool) {
+			return removePublicKeyLines(lines, publicKey)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		existing    *string // nil이면 파일 없음
+		mode        os.FileMode
+		edit        func([]string) ([]string, bool)
+		wantContent string
+		wantMode    os.FileMode
+	}{
+		{
+			name:        "새 파일에 설치",
+			edit:        install(keyA),
+			wantContent: keyA + "\n",
+			wantMode:    0600,
+		},
+		{
+			name:        "기존 파일에 추가 (주석, 권한 유지)",
+			existing:    stringPtr("# 팀 키\n" + keyB),
+			mode:        0640,
+			edit:        install(keyA),
+			wantContent: "# 팀 키\n" + keyB + "\n" + keyA + "\n",
+			wantMode:    0640,
+		},
+		{
+			name:        "이미 있는 키는 변경하지 않음",
+			existing:    stringPtr(keyA + "\n"),
+			mode:        0644,
+			edit:        install(strings.TrimSuffix(keyA, " alice@laptop")),
+			wantContent: keyA + "\n",
+			wantMode:    0644,
+		},
+		{
+			name:        "키 제거",
+			existing:    stringPtr(keyA + "\n\n" + keyB + "\n"),
+			mode:        0600,
+			edit:        remove(keyA),
+			wantContent: "\n" + keyB + "\n",
+			wantMode:    0600,
+		},
+		{
+			name:        "옵션이 붙은 줄이 있으면 다시 추가하지 않음",
+			existing:    stringPtr(`from="10.0.0.0/8",no-pty ` + keyA + "\n"),
+			mode:        0600,
+			edit:        install(keyA),
+			wantContent: `from="10.0.0.0/8",no-pty ` + keyA + "\n",
+			wantMode:    0600,
+		},
+		{
+			name:        "옵션이 붙은 줄 제거",
+			existing:    stringPtr(keyB + "\n" + `from="10.0.0.0/8",no-pty ` + keyA + "\n"),
+			mode:        0600,
+			edit:        remove(keyA),
+			wantContent: keyB + "\n",
+			wantMode:    0600,
+		},
+		{
+			name:        "마지막 키 제거 시 빈 파일",
+			existing:    stringPtr(keyA + "\n"),
+			mode:        0600,
+			edit:        remove(keyA),
+			wantContent: "",
+			wantMode:    0600,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			path := filepath.Join(home, ".ssh", "authorized_keys")
+			if tt.existing != nil {
+				writeTestAuthorizedKeys(t, home, *tt.existing, tt.mode)
+			}
+			client := newTestSFTPClient(t, home)
+
+			if err := editSFTPAuthorizedKeys(client, tt.edit); err != nil {
+				t.Fatalf("editSFTPAuthorizedKeys 실패: %v", err)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("파일 읽기 실패: %v", err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("내용 = %q, 기대값 %q", content, tt.wantContent)
+			}
+			info, _ := os.Stat(path)
+			if info.Mode().Perm() != tt.wantMode {
+				t.Errorf("권한 = %o, 기대값 %o", info.Mode().Perm(), tt.wantMode)
+			}
+			assertNoTempFiles(t, home)
+		})
+	}
+}
+
+func TestEditSFTPAuthorizedKeysKeepsSymlink(t *testing.T) {
+	keyA := testAuthorizedKey(t, 1, "alice@laptop")
+
+	home := t.TempDir()
+	target := filepath.Join(home, "managed_keys")
+	if err := os.WriteFile(target, nil, 0600); err != nil {
+		t.Fatalf("파일 생성 실패: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatalf("디렉토리 생성 실패: %v", err)
+	}
+	link := filepath.Join(home, ".ssh", "authorized_keys")
+	if err := os.Symlink("../managed_keys", link); err != nil {
+		t.Fatalf("심볼릭 링크 생성 실패: %v", err)
+	}
+
+	client := newTestSFTPClient(t, home)
+	err := editSFTPAuthorizedKeys(client, func(lines []string) ([]string, bool) {
+		return append(lines, keyA), true
+	})
+	if err != nil {
+		t.Fatalf("editSFTPAuthorizedKeys 실패: %v", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("authorized_keys 심볼릭 링크가 유지되어야 합니다")
+	}
+	content, _ := os.ReadFile(target)
+	if string(content) != keyA+"\n" {
+		t.Errorf("링크 대상 내용 = %q", content)
+	}
+}
+
+func TestEditSFTPAuthorizedKeysDetectsConcurrentChange(t *testing.T) {
+	keyA := testAuthorizedKey(t, 1, "alice@laptop")
+	keyB := testAuthorizedKey(t, 2, "bob@desktop")
+
+	home := t.TempDir()
+	path := writeTestAuthorizedKeys(t, home, keyB+"\n", 0600)
+	client := newTestSFTPClient(t, home)
+
+	// 읽은 뒤 교체하기 전에 다른 프로세스가 파일을 바꾼 상황
+	err := editSFTPAuthorizedKeys(client, func(lines []string) ([]string, bool) {
+		if err := os.WriteFile(path, []byte(keyB+"\n# 다른 곳에서 추가\n"), 0600); err != nil {
+			t.Fatalf("파일 변경 실패: %v", err)
+		}
+		return append(lines, keyA), true
+	})
+	if !errors.Is(err, errRemoteFileChanged) {
+		t.Fatalf("오류 = %v, errRemoteFileChanged 기대", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != keyB+"\n# 다른 곳에서 추가\n" {
+		t.Errorf("다른 곳에서 바꾼 내용을 덮어쓰면 안 됩니다: %q", content)
+	}
+	assertNoTempFiles(t, home)
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
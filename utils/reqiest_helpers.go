@@ -32,6 +32,7 @@ func HandleServiceError(c echo.Context, err error, operation string) error {
 		"최대",
 		"필수",
 		"올바르지 않습니다",
+		"지원하지 않는",
 	}
 
 	errMsg := err.Error()
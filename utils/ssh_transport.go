@@ -0,0 +1,580 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// SSHErrorKind는 원격 SSH 작업 오류의 종류입니다.
+type SSHErrorKind string
+
+const (
+	SSHErrorUnreachable      SSHErrorKind = "host_unreachable"  // 서버에 연결할 수 없음 (DNS, 방화벽, 서버 중지, 핸드셰이크 실패)
+	SSHErrorTimeout          SSHErrorKind = "timeout"           // 연결 또는 명령 실행 시간 초과
+	SSHErrorCanceled         SSHErrorKind = "canceled"          // 호출자가 작업을 취소함
+	SSHErrorAuthFailed       SSHErrorKind = "auth_failed"       // 관리 도구의 키로 인증하지 못함
+	SSHErrorHostKeyMismatch  SSHErrorKind = "host_key_mismatch" // 서버가 기록된 키와 다른 호스트 키를 제시함
+	SSHErrorPermissionDenied SSHErrorKind = "permission_denied" // 원격 파일 또는 디렉토리 권한 부족
+	SSHErrorCommandFailed    SSHErrorKind = "command_failed"    // 원격 명령이 0이 아닌 종료 코드로 끝남
+)
+
+// SSHError는 원격 SSH 작업 실패를 종류별로 구분하는 오류입니다.
+type SSHError struct {
+	Kind     SSHErrorKind
+	Target   string // user@host:port
+	Err      error
+	ExitCode int    // 원격 명령 종료 코드 (command_failed, permission_denied)
+	Stderr   string // 원격 명령 표준 오류 출력
+
+	PresentedHostKey ssh.PublicKey // host_key_mismatch일 때 서버가 제시한 호스트 키
+}
+
+func (e *SSHError) Error() string {
+	var description string
+	switch e.Kind {
+	case SSHErrorUnreachable:
+		description = "서버에 연결할 수 없습니다"
+	case SSHErrorTimeout:
+		description = "서버 응답 시간이 초과되었습니다"
+	case SSHErrorCanceled:
+		description = "원격 작업이 취소되었습니다"
+	case SSHErrorAuthFailed:
+		description = "SSH 인증에 실패했습니다"
+	case SSHErrorHostKeyMismatch:
+		description = "서버 호스트 키가 기록된 키와 일치하지 않습니다"
+	case SSHErrorPermissionDenied:
+		description = "원격 파일에 접근할 권한이 없습니다"
+	default:
+		description = "원격 명령 실행에 실패했습니다"
+	}
+
+	message := fmt.Sprintf("%s (%s)", description, e.Target)
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		message += " - " + truncateString(stderr, 200)
+	}
+	return message
+}
+
+func (e *SSHError) Unwrap() error {
+	return e.Err
+}
+
+// IsSSHErrorKind는 오류가 지정한 종류의 SSHError인지 확인합니다.
+func IsSSHErrorKind(err error, kind SSHErrorKind) bool {
+	var sshErr *SSHError
+	return errors.As(err, &sshErr) && sshErr.Kind == kind
+}
+
+// SSHTarget은 원격 작업 대상 서버와 접속 정보입니다.
+type SSHTarget struct {
+	Host     string
+	Port     int
+	Username string
+
+	// 기록된 호스트 키 (비어있으면 관리용 known_hosts로 검증)
+	HostKeyType        string
+	HostKeyFingerprint string
+}
+
+// String은 user@host:port 형식의 대상 문자열을 반환합니다.
+func (t SSHTarget) String() string {
+	return fmt.Sprintf("%s@%s:%d", t.Username, t.Host, t.port())
+}
+
+func (t SSHTarget) port() int {
+	if t.Port <= 0 {
+		return 22
+	}
+	return t.Port
+}
+
+func (t SSHTarget) address() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.port()))
+}
+
+// poolKey는 연결 재사용 단위입니다. 기록된 호스트 키가 바뀌면 새로 연결합니다.
+func (t SSHTarget) poolKey() string {
+	return t.String() + "|" + t.HostKeyFingerprint
+}
+
+// SSHTransportOptions는 원격 SSH 접속 설정입니다.
+type SSHTransportOptions struct {
+	IdentityFiles  []string      // 접속에 사용할 개인키 파일 (비어있으면 ~/.ssh/id_ed25519, id_ecdsa, id_rsa)
+	ConnectTimeout time.Duration // TCP 연결과 SSH 핸드셰이크 타임아웃
+	CommandTimeout time.Duration // 호출자가 기한을 주지 않았을 때의 명령 실행 타임아웃
+	IdleTimeout    time.Duration // 유휴 연결을 닫기까지의 시간
+}
+
+// 연결 하나에서 동시에 여는 세션 수 (sshd MaxSessions 기본값 10보다 작게 유지)
+const maxSessionsPerConnection = 8
+
+type pooledSSHClient struct {
+	client   *ssh.Client
+	active   int
+	lastUsed time.Time
+}
+
+var (
+	sshTransportMu      sync.RWMutex
+	sshTransportOptions = SSHTransportOptions{
+		ConnectTimeout: 10 * time.Second,
+		CommandTimeout: 60 * time.Second,
+		IdleTimeout:    5 * time.Minute,
+	}
+	sshIdentitySigners []ssh.Signer
+	sshIdentityLoaded  bool
+
+	sshPoolMu      sync.Mutex
+	sshPool        = make(map[string]*pooledSSHClient)
+	sshPoolJanitor sync.Once
+)
+
+// ConfigureSSHTransport는 원격 SSH 접속 설정을 적용합니다. 기존 연결은 모두 닫습니다.
+func ConfigureSSHTransport(opts SSHTransportOptions) {
+	sshTransportMu.Lock()
+	if opts.ConnectTimeout > 0 {
+		sshTransportOptions.ConnectTimeout = opts.ConnectTimeout
+	}
+	if opts.CommandTimeout > 0 {
+		sshTransportOptions.CommandTimeout = opts.CommandTimeout
+	}
+	if opts.IdleTimeout > 0 {
+		sshTransportOptions.IdleTimeout = opts.IdleTimeout
+	}
+	sshTransportOptions.IdentityFiles = opts.IdentityFiles
+	sshIdentitySigners = nil
+	sshIdentityLoaded = false
+	sshTransportMu.Unlock()
+
+	CloseSSHConnections()
+}
+
+// CloseSSHConnections는 재사용 중인 모든 SSH 연결을 닫습니다.
+func CloseSSHConnections() {
+	sshPoolMu.Lock()
+	defer sshPoolMu.Unlock()
+	for key, pooled := range sshPool {
+		pooled.client.Close()
+		delete(sshPool, key)
+	}
+}
+
+func currentSSHTransportOptions() SSHTransportOptions {
+	sshTransportMu.RLock()
+	defer sshTransportMu.RUnlock()
+	return sshTransportOptions
+}
+
+// RunRemoteCommand는 원격 서버에서 명령을 실행하고 표준 출력을 반환합니다.
+// stdin이 nil이 아니면 명령의 표준 입력으로 전달합니다 (셸 인용 없이 데이터를 넘길 때 사용).
+// ctx에 기한이 없으면 SSH_COMMAND_TIMEOUT을 적용하며, ctx가 취소되면 원격 명령을 중단합니다.
+func RunRemoteCommand(ctx context.Context, target SSHTarget, command string, stdin []byte) (string, error) {
+	opts := currentSSHTransportOptions()
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.CommandTimeout)
+		defer cancel()
+	}
+
+	for attempt := 0; ; attempt++ {
+		client, release, err := acquireSSHClient(ctx, target, opts)
+		if err != nil {
+			return "", err
+		}
+
+		session, err := client.NewSession()
+		if err != nil {
+			release(true)
+			// 재사용하던 연결이 끊어진 경우 한 번 새로 연결
+			if attempt == 0 && ctx.Err() == nil {
+				continue
+			}
+			return "", &SSHError{Kind: SSHErrorUnreachable, Target: target.String(), Err: err}
+		}
+
+		output, broken, err := runSSHSession(ctx, target, session, command, stdin)
+		release(broken)
+		return output, err
+	}
+}
+
+// withSSHClient는 재사용 연결로 fn을 실행합니다 (SFTP 등 명령 실행 외의 채널을 쓸 때 사용).
+// ctx에 기한이 없으면 SSH_COMMAND_TIMEOUT을 적용합니다. fn이 SSHErrorUnreachable을 반환하면 연결을 버리고,
+// 재사용하던 연결이 끊어진 경우를 위해 한 번 새로 연결하여 다시 실행합니다.
+func withSSHClient(ctx context.Context, target SSHTarget, fn func(ctx context.Context, client *ssh.Client) error) error {
+	opts := currentSSHTransportOptions()
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.CommandTimeout)
+		defer cancel()
+	}
+
+	for attempt := 0; ; attempt++ {
+		client, release, err := acquireSSHClient(ctx, target, opts)
+		if err != nil {
+			return err
+		}
+
+		err = fn(ctx, client)
+		broken := IsSSHErrorKind(err, SSHErrorUnreachable)
+		release(broken)
+		if broken && attempt == 0 && ctx.Err() == nil {
+			continue
+		}
+		return err
+	}
+}
+
+// runSSHSession은 세션에서 명령을 실행합니다. 연결 자체가 끊어졌으면 broken이 true입니다.
+func runSSHSession(ctx context.Context, target SSHTarget, session *ssh.Session, command string, stdin []byte) (string, bool, error) {
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if stdin != nil {
+		session.Stdin = bytes.NewReader(stdin)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(command)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		return "", false, contextSSHError(ctx, target)
+	}
+
+	if err == nil {
+		return stdout.String(), false, nil
+	}
+
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		kind := SSHErrorCommandFailed
+		if isPermissionError(stderr.String()) {
+			kind = SSHErrorPermissionDenied
+		}
+		return stdout.String(), false, &SSHError{
+			Kind:     kind,
+			Target:   target.String(),
+			Err:      fmt.Errorf("종료 코드 %d", exitErr.ExitStatus()),
+			ExitCode: exitErr.ExitStatus(),
+			Stderr:   stderr.String(),
+		}
+	}
+
+	// 종료 상태 없이 끊어짐 (연결 종료 등)
+	return stdout.String(), true, &SSHError{Kind: SSHErrorUnreachable, Target: target.String(), Err: err, Stderr: stderr.String()}
+}
+
+// acquireSSHClient는 재사용 가능한 연결을 반환하거나 새로 연결합니다.
+// 반환된 release는 작업이 끝나면 반드시 호출해야 하며, broken이면 연결을 닫습니다.
+func acquireSSHClient(ctx context.Context, target SSHTarget, opts SSHTransportOptions) (*ssh.Client, func(broken bool), error) {
+	sshPoolJanitor.Do(startSSHPoolJanitor)
+	key := target.poolKey()
+
+	sshPoolMu.Lock()
+	if pooled, ok := sshPool[key]; ok && pooled.active < maxSessionsPerConnection {
+		pooled.active++
+		sshPoolMu.Unlock()
+		return pooled.client, pooledRelease(key, pooled), nil
+	}
+	sshPoolMu.Unlock()
+
+	client, err := dialSSH(ctx, target, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sshPoolMu.Lock()
+	defer sshPoolMu.Unlock()
+	if _, ok := sshPool[key]; ok {
+		// 기존 연결의 동시 세션이 가득 찼거나 다른 작업이 먼저 연결한 경우 이번 작업 전용으로 사용
+		return client, func(bool) { client.Close() }, nil
+	}
+	pooled := &pooledSSHClient{client: client, active: 1, lastUsed: time.Now()}
+	sshPool[key] = pooled
+	return client, pooledRelease(key, pooled), nil
+}
+
+func pooledRelease(key string, pooled *pooledSSHClient) func(broken bool) {
+	return func(broken bool) {
+		sshPoolMu.Lock()
+		defer sshPoolMu.Unlock()
+		pooled.active--
+		pooled.lastUsed = time.Now()
+		if broken && sshPool[key] == pooled {
+			delete(sshPool, key)
+		}
+		// 풀에서 빠진 연결은 마지막 사용자가 닫음
+		if sshPool[key] != pooled && pooled.active <= 0 {
+			pooled.client.Close()
+		}
+	}
+}
+
+// startSSHPoolJanitor는 유휴 연결을 주기적으로 닫는 고루틴을 시작합니다.
+func startSSHPoolJanitor() {
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			idleTimeout := currentSSHTransportOptions().IdleTimeout
+			sshPoolMu.Lock()
+			for key, pooled := range sshPool {
+				if pooled.active == 0 && time.Since(pooled.lastUsed) > idleTimeout {
+					pooled.client.Close()
+					delete(sshPool, key)
+				}
+			}
+			sshPoolMu.Unlock()
+		}
+	}()
+}
+
+// dialSSH는 서버에 새로 연결하고 호스트 키 검증과 사용자 인증을 수행합니다.
+func dialSSH(ctx context.Context, target SSHTarget, opts SSHTransportOptions) (*ssh.Client, error) {
+	signers := loadSSHIdentitySigners(opts.IdentityFiles)
+
+	var agentConn net.Conn
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			agentConn = conn
+			defer agentConn.Close()
+		}
+	}
+	if len(signers) == 0 && agentConn == nil {
+		return nil, &SSHError{
+			Kind:   SSHErrorAuthFailed,
+			Target: target.String(),
+			Err:    errors.New("접속에 사용할 개인키가 없습니다 (SSH_IDENTITY_FILES 또는 ssh-agent 설정 필요)"),
+		}
+	}
+
+	// 같은 인증 방식(publickey)은 한 번만 시도되므로 파일 키와 agent 키를 하나로 묶음
+	authSigners := func() ([]ssh.Signer, error) {
+		all := append([]ssh.Signer{}, signers...)
+		if agentConn != nil {
+			if agentSigners, err := agent.NewClient(agentConn).Signers(); err == nil {
+				all = append(all, agentSigners...)
+			}
+		}
+		return all, nil
+	}
+
+	var presentedKey ssh.PublicKey
+	config := &ssh.ClientConfig{
+		User:              target.Username,
+		Auth:              []ssh.AuthMethod{ssh.PublicKeysCallback(authSigners)},
+		HostKeyCallback:   hostKeyVerifier(target, &presentedKey),
+		HostKeyAlgorithms: pinnedHostKeyAlgorithms(target.HostKeyType),
+		Timeout:           opts.ConnectTimeout,
+	}
+
+	dialer := net.Dialer{Timeout: opts.ConnectTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", target.address())
+	if err != nil {
+		return nil, classifyDialError(ctx, target, err, nil)
+	}
+
+	// 핸드셰이크 중에도 취소와 타임아웃이 적용되도록 연결 기한 설정
+	conn.SetDeadline(time.Now().Add(opts.ConnectTimeout))
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, target.address(), config)
+	stop()
+	if err != nil {
+		conn.Close()
+		return nil, classifyDialError(ctx, target, err, presentedKey)
+	}
+	conn.SetDeadline(time.Time{})
+
+	log.Printf("🔗 SSH 연결 수립: %s", target)
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
+
+// errHostKeyMismatch는 호스트 키 검증 실패를 핸드셰이크 밖으로 전달하기 위한 내부 오류입니다.
+var errHostKeyMismatch = errors.New("호스트 키 불일치")
+
+// hostKeyVerifier는 호스트 키 검증 콜백을 만듭니다.
+// 기록된 호스트 키가 있으면 그 키와 비교하고(인증서는 서명된 원래 키로 비교),
+// 없으면 관리용 known_hosts의 @cert-authority와 첫 접속 기록으로 검증합니다.
+func hostKeyVerifier(target SSHTarget, mismatch *ssh.PublicKey) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if target.HostKeyFingerprint == "" {
+			err := checkManagedKnownHosts(hostname, remote, key)
+			if errors.Is(err, errHostKeyMismatch) {
+				*mismatch = plainHostKey(key)
+			}
+			return err
+		}
+
+		presented := plainHostKey(key)
+		if ssh.FingerprintSHA256(presented) == target.HostKeyFingerprint {
+			return nil
+		}
+		*mismatch = presented
+		return errHostKeyMismatch
+	}
+}
+
+// plainHostKey는 호스트 인증서이면 서명된 원래 호스트 키를 반환합니다.
+func plainHostKey(key ssh.PublicKey) ssh.PublicKey {
+	if cert, ok := key.(*ssh.Certificate); ok {
+		return cert.Key
+	}
+	return key
+}
+
+// pinnedHostKeyAlgorithms는 기록된 호스트 키 종류로 협상할 알고리즘 목록을 반환합니다.
+// 다른 종류의 키로 협상되어 불일치로 오인되는 것을 막습니다.
+func pinnedHostKeyAlgorithms(keyType string) []string {
+	switch keyType {
+	case ssh.KeyAlgoED25519:
+		return []string{ssh.CertAlgoED25519v01, ssh.KeyAlgoED25519}
+	case ssh.KeyAlgoECDSA256:
+		return []string{ssh.CertAlgoECDSA256v01, ssh.KeyAlgoECDSA256}
+	case ssh.KeyAlgoECDSA384:
+		return []string{ssh.CertAlgoECDSA384v01, ssh.KeyAlgoECDSA384}
+	case ssh.KeyAlgoECDSA521:
+		return []string{ssh.CertAlgoECDSA521v01, ssh.KeyAlgoECDSA521}
+	case ssh.KeyAlgoRSA:
+		return []string{ssh.CertAlgoRSASHA512v01, ssh.CertAlgoRSASHA256v01, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256}
+	}
+	return nil
+}
+
+// classifyDialError는 연결 실패 원인을 SSHError 종류로 구분합니다.
+func classifyDialError(ctx context.Context, target SSHTarget, err error, presentedKey ssh.PublicKey) error {
+	if presentedKey != nil || errors.Is(err, errHostKeyMismatch) {
+		expected := target.HostKeyFingerprint
+		if expected == "" {
+			expected = "known_hosts"
+		}
+		return &SSHError{
+			Kind:             SSHErrorHostKeyMismatch,
+			Target:           target.String(),
+			Err:              fmt.Errorf("기록된 키: %s, 현재 키: %s", expected, fingerprintOrEmpty(presentedKey)),
+			PresentedHostKey: presentedKey,
+		}
+	}
+	if ctx.Err() != nil {
+		return contextSSHError(ctx, target)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &SSHError{Kind: SSHErrorTimeout, Target: target.String(), Err: err}
+	}
+	if strings.Contains(err.Error(), "unable to authenticate") || strings.Contains(err.Error(), "no supported methods remain") {
+		return &SSHError{Kind: SSHErrorAuthFailed, Target: target.String(), Err: err}
+	}
+	return &SSHError{Kind: SSHErrorUnreachable, Target: target.String(), Err: err}
+}
+
+func contextSSHError(ctx context.Context, target SSHTarget) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &SSHError{Kind: SSHErrorTimeout, Target: target.String(), Err: ctx.Err()}
+	}
+	return &SSHError{Kind: SSHErrorCanceled, Target: target.String(), Err: ctx.Err()}
+}
+
+func fingerprintOrEmpty(key ssh.PublicKey) string {
+	if key == nil {
+		return "-"
+	}
+	return ssh.FingerprintSHA256(key)
+}
+
+// isPermissionError는 원격 명령 오류 출력이 권한 문제인지 확인합니다.
+func isPermissionError(stderr string) bool {
+	for _, marker := range []string{"Permission denied", "Operation not permitted", "Read-only file system"} {
+		if strings.Contains(stderr, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ManagerKeyFingerprints는 관리 도구가 원격 접속에 사용하는 공개키(개인키 파일, ssh-agent)의 SHA256 핑거프린트입니다.
+// authorized_keys를 정리할 때 관리 도구 자신의 접속 키를 지우지 않도록 사용합니다.
+func ManagerKeyFingerprints() []string {
+	var fingerprints []string
+	for _, signer := range loadSSHIdentitySigners(currentSSHTransportOptions().IdentityFiles) {
+		fingerprints = append(fingerprints, ssh.FingerprintSHA256(signer.PublicKey()))
+	}
+
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			defer conn.Close()
+			if keys, err := agent.NewClient(conn).List(); err == nil {
+				for _, key := range keys {
+					fingerprints = append(fingerprints, ssh.FingerprintSHA256(key))
+				}
+			}
+		}
+	}
+	return fingerprints
+}
+
+// loadSSHIdentitySigners는 접속에 사용할 개인키를 읽습니다 (처음 한 번만 읽고 재사용).
+// 암호화된 개인키는 사용할 수 없으므로 건너뜁니다 (ssh-agent 사용).
+func loadSSHIdentitySigners(identityFiles []string) []ssh.Signer {
+	sshTransportMu.Lock()
+	defer sshTransportMu.Unlock()
+	if sshIdentityLoaded {
+		return sshIdentitySigners
+	}
+
+	explicit := len(identityFiles) > 0
+	if !explicit {
+		if home, err := os.UserHomeDir(); err == nil {
+			for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+				identityFiles = append(identityFiles, filepath.Join(home, ".ssh", name))
+			}
+		}
+	}
+
+	var signers []ssh.Signer
+	for _, path := range identityFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if explicit {
+				log.Printf("⚠️ SSH 접속 키를 읽을 수 없습니다: %s (%v)", path, err)
+			}
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			log.Printf("⚠️ SSH 접속 키를 사용할 수 없습니다 (암호화된 키는 ssh-agent를 사용하세요): %s (%v)", path, err)
+			continue
+		}
+		signers = append(signers, signer)
+		log.Printf("🔑 SSH 접속 키 로드: %s (%s)", path, ssh.FingerprintSHA256(signer.PublicKey()))
+	}
+
+	sshIdentitySigners = signers
+	sshIdentityLoaded = true
+	return signers
+}